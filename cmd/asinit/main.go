@@ -29,6 +29,7 @@ import (
 
 var (
 	nodeId    string
+	rackId    string
 	peerList  string
 	sourceCfg string
 	targetCfg string
@@ -36,13 +37,15 @@ var (
 
 func init() {
 	flag.StringVar(&nodeId, "node-id", "", "the node id for the current aerospike node")
+	flag.StringVar(&rackId, "rack-id", "", "the rack id for the current aerospike node")
 	flag.StringVar(&peerList, "peer-list", "", "comma-separated list of peers for the current aerospike node")
 	flag.StringVar(&sourceCfg, "source-config", "", "path to the source configuration file")
 	flag.StringVar(&targetCfg, "target-config", "", "path to the target configuration file")
 }
 
-// asinit takes a node id and a list of peers for a given aerospike
-// node and updates the source configuration file with these values.
+// asinit takes a node id, a list of peers and a rack id for a given
+// aerospike node and updates the source configuration file with these
+// values.
 // this allows for setting node-specific configuration parameter
 // which can't be set using the common configmap.
 func main() {
@@ -61,17 +64,22 @@ func main() {
 		return c == ','
 	}
 
-	// build the list of peers
-	var peers strings.Builder
+	// build the list of peers, as well as the list of peers to use when tls
+	// is enabled for heartbeats
+	var peers, tlsPeers strings.Builder
 	for _, peer := range strings.FieldsFunc(peerList, splitFn) {
 		peers.WriteString(fmt.Sprintf("mesh-seed-address-port %s %d", peer, reconciler.HeartbeatPort))
 		peers.WriteString("\n")
+		tlsPeers.WriteString(fmt.Sprintf("tls-mesh-seed-address-port %s %d", peer, reconciler.HeartbeatTLSPort))
+		tlsPeers.WriteString("\n")
 	}
 
 	// replace the required placeholders in the source config
 	cfg := string(input)
 	cfg = strings.Replace(cfg, reconciler.ServiceNodeIdValue, nodeId, -1)
 	cfg = strings.Replace(cfg, reconciler.HeartbeatAddressesValue, peers.String(), -1)
+	cfg = strings.Replace(cfg, reconciler.TLSHeartbeatAddressesValue, tlsPeers.String(), -1)
+	cfg = strings.Replace(cfg, reconciler.RackIdValue, rackId, -1)
 
 	// create the target configuration file
 	if err := ioutil.WriteFile(targetCfg, []byte(cfg), 0777); err != nil {
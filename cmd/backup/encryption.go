@@ -0,0 +1,62 @@
+/*
+Copyright 2019 The aerospike-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/travelaudience/aerospike-operator/pkg/backuprestore/encryption"
+)
+
+// readEncryptionKey reads the key used to encrypt backup data from the file
+// specified in the command-line flags. It returns nil if no file was
+// specified.
+func readEncryptionKey() ([]byte, error) {
+	if encryptionKeyPath == "" {
+		return nil, nil
+	}
+	key, err := ioutil.ReadFile(encryptionKeyPath)
+	if err != nil {
+		return nil, err
+	}
+	if err := encryption.ValidateKey(key); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// getDecryptionKey returns the key that must be used to decrypt the backup
+// described by m, failing if the backup is encrypted and key does not match
+// the one used to encrypt it.
+func getDecryptionKey(m *backupMetadata, key []byte) ([]byte, error) {
+	if m.EncryptionKeyFingerprint == "" {
+		if key != nil {
+			log.Warn("backup is not encrypted, ignoring the encryption key")
+		}
+		return nil, nil
+	}
+	if key == nil {
+		return nil, fmt.Errorf("backup is encrypted but no encryption key was provided")
+	}
+	if fingerprint := encryption.Fingerprint(key); fingerprint != m.EncryptionKeyFingerprint {
+		return nil, fmt.Errorf("the encryption key (fingerprint %s) does not match the one used to encrypt the backup (fingerprint %s)", fingerprint, m.EncryptionKeyFingerprint)
+	}
+	return key, nil
+}
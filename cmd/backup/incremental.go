@@ -0,0 +1,145 @@
+/*
+Copyright 2019 The aerospike-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/travelaudience/aerospike-operator/pkg/backuprestore/storage"
+)
+
+const (
+	// asbackupTimeFormat is the format of the times passed to asbackup in
+	// order to filter records by their last update time.
+	asbackupTimeFormat = "2006-01-02_15:04:05"
+)
+
+// setBackupFilter records the records selected by the command-line flags in
+// m, reading the time at which the base backup (if any) was made from its
+// metadata.
+func setBackupFilter(client storage.Client, m *backupMetadata) error {
+	if sets != "" {
+		m.Sets = strings.Split(sets, ",")
+		// the version of asbackup in use only supports backing up a single
+		// set at a time
+		if len(m.Sets) > 1 {
+			return fmt.Errorf("only a single set can be backed up")
+		}
+	}
+	if bins != "" {
+		m.Bins = strings.Split(bins, ",")
+	}
+	var err error
+	if m.ModifiedAfter, err = parseTime(modifiedAfterFlag, modifiedAfter); err != nil {
+		return err
+	}
+	if m.ModifiedBefore, err = parseTime(modifiedBeforeFlag, modifiedBefore); err != nil {
+		return err
+	}
+	if base == "" {
+		return nil
+	}
+	if m.ModifiedAfter != nil {
+		return fmt.Errorf("-%s cannot be specified together with -%s", modifiedAfterFlag, baseFlag)
+	}
+	baseShards, err := readShards(client, base)
+	if err != nil {
+		return fmt.Errorf("failed to read the metadata of base backup %s: %v", base, err)
+	}
+	// backup the records modified since the earliest shard of the base backup
+	// started, so that records modified while it was running are not missed
+	for _, s := range baseShards {
+		ts := s.metadata.CreationTimestamp
+		if ts == nil {
+			return fmt.Errorf("the metadata of base backup %s does not contain its creation time", base)
+		}
+		if m.ModifiedAfter == nil || ts.Before(*m.ModifiedAfter) {
+			m.ModifiedAfter = ts
+		}
+	}
+	m.Base = base
+	log.Infof("backing up the records modified since %s (base backup %s)", m.ModifiedAfter.UTC().Format(time.RFC3339), base)
+	return nil
+}
+
+// parseTime parses the value of the specified flag as a time in RFC 3339
+// format, returning nil if the flag is empty.
+func parseTime(flag, value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid value for -%s: %v", flag, err)
+	}
+	return &t, nil
+}
+
+// getBackupFilterArgs returns the asbackup arguments that select the records
+// described by m.
+func getBackupFilterArgs(m *backupMetadata) []string {
+	args := make([]string, 0)
+	if len(m.Sets) > 0 {
+		args = append(args, "--set", strings.Join(m.Sets, ","))
+	}
+	if len(m.Bins) > 0 {
+		args = append(args, "--bin-list", strings.Join(m.Bins, ","))
+	}
+	// times are rounded to whole seconds (down for the lower bound and up for
+	// the upper bound), erring on the side of including more records
+	if m.ModifiedAfter != nil {
+		args = append(args, "--modified-after", m.ModifiedAfter.UTC().Format(asbackupTimeFormat))
+	}
+	if m.ModifiedBefore != nil {
+		args = append(args, "--modified-before", m.ModifiedBefore.UTC().Add(time.Second-1).Format(asbackupTimeFormat))
+	}
+	return args
+}
+
+// readChain reads the metadata of every shard of the target backup and, in
+// the case of an incremental backup, of the backups on top of which it was
+// made. Shards are returned in the order in which they must be restored, i.e.
+// starting with the shards of the oldest (full) backup.
+func readChain(client storage.Client) ([]*backupShard, error) {
+	var (
+		res  []*backupShard
+		seen = make(map[string]bool)
+	)
+	for current := name; current != ""; {
+		if seen[current] {
+			return nil, fmt.Errorf("backup %s is its own base backup", current)
+		}
+		seen[current] = true
+		backupShards, err := readShards(client, current)
+		if err != nil {
+			if current != name {
+				return nil, fmt.Errorf("failed to read the metadata of base backup %s: %v", current, err)
+			}
+			return nil, err
+		}
+		res = append(backupShards, res...)
+		current = backupShards[0].metadata.Base
+	}
+	if len(seen) > 1 {
+		log.Infof("backup is made on top of %d base backup(s)", len(seen)-1)
+	}
+	return res, nil
+}
@@ -0,0 +1,96 @@
+/*
+Copyright 2019 The aerospike-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/travelaudience/aerospike-operator/pkg/backuprestore"
+	"github.com/travelaudience/aerospike-operator/pkg/backuprestore/pvc"
+)
+
+func TestGetBackupFilterArgs(t *testing.T) {
+	after := time.Date(2019, time.January, 1, 1, 2, 3, 500, time.UTC)
+	before := time.Date(2019, time.January, 2, 1, 2, 3, 500, time.FixedZone("CET", 3600))
+
+	tests := []struct {
+		metadata *backupMetadata
+		expected []string
+	}{
+		{&backupMetadata{}, []string{}},
+		{&backupMetadata{Sets: []string{"set-0"}, Bins: []string{"bin-0", "bin-1"}},
+			[]string{"--set", "set-0", "--bin-list", "bin-0,bin-1"}},
+		// times are passed in utc and rounded to whole seconds
+		{&backupMetadata{ModifiedAfter: &after, ModifiedBefore: &before},
+			[]string{"--modified-after", "2019-01-01_01:02:03", "--modified-before", "2019-01-02_00:02:04"}},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.expected, getBackupFilterArgs(test.metadata))
+	}
+}
+
+func TestReadChain(t *testing.T) {
+	dir, err := ioutil.TempDir("", "backup")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	client, err := pvc.NewPVCClient(dir)
+	assert.NoError(t, err)
+
+	// a full backup split into two shards, and two incremental backups made on
+	// top of it
+	assert.NoError(t, dumpMetadata(client, backuprestore.GetShardName("full", 0), &backupMetadata{Shard: 0, Shards: 2}))
+	assert.NoError(t, dumpMetadata(client, backuprestore.GetShardName("full", 1), &backupMetadata{Shard: 1, Shards: 2}))
+	assert.NoError(t, dumpMetadata(client, "incremental-0", &backupMetadata{Base: "full"}))
+	assert.NoError(t, dumpMetadata(client, "incremental-1", &backupMetadata{Base: "incremental-0"}))
+	// an incremental backup whose base backup does not exist
+	assert.NoError(t, dumpMetadata(client, "orphan", &backupMetadata{Base: "missing"}))
+	// incremental backups that are each other's base backup
+	assert.NoError(t, dumpMetadata(client, "cycle-0", &backupMetadata{Base: "cycle-1"}))
+	assert.NoError(t, dumpMetadata(client, "cycle-1", &backupMetadata{Base: "cycle-0"}))
+
+	tests := []struct {
+		name     string
+		expected []string
+		valid    bool
+	}{
+		{"full", []string{"full-shard-0", "full-shard-1"}, true},
+		{"incremental-0", []string{"full-shard-0", "full-shard-1", "incremental-0"}, true},
+		{"incremental-1", []string{"full-shard-0", "full-shard-1", "incremental-0", "incremental-1"}, true},
+		{"orphan", nil, false},
+		{"cycle-0", nil, false},
+		{"missing", nil, false},
+	}
+	for _, test := range tests {
+		name = test.name
+		backupShards, err := readChain(client)
+		if !test.valid {
+			assert.Error(t, err)
+			continue
+		}
+		assert.NoError(t, err)
+		names := make([]string, 0, len(backupShards))
+		for _, s := range backupShards {
+			names = append(names, s.name)
+		}
+		assert.Equal(t, test.expected, names)
+	}
+}
@@ -20,22 +20,32 @@ import (
 	"encoding/json"
 	"flag"
 	"fmt"
+	"io"
+	"io/ioutil"
 	"os"
 	"os/exec"
 	"strconv"
 	"strings"
+	"time"
 
 	log "github.com/sirupsen/logrus"
-	"golang.org/x/net/context"
 
+	"github.com/travelaudience/aerospike-operator/pkg/apis/aerospike/common"
+	aerospikev1alpha2 "github.com/travelaudience/aerospike-operator/pkg/apis/aerospike/v1alpha2"
+	"github.com/travelaudience/aerospike-operator/pkg/asutils"
 	"github.com/travelaudience/aerospike-operator/pkg/backuprestore"
-	"github.com/travelaudience/aerospike-operator/pkg/backuprestore/gcs"
+	"github.com/travelaudience/aerospike-operator/pkg/backuprestore/compression"
+	"github.com/travelaudience/aerospike-operator/pkg/backuprestore/encryption"
+	"github.com/travelaudience/aerospike-operator/pkg/backuprestore/storage"
+	"github.com/travelaudience/aerospike-operator/pkg/pointers"
 	flagutils "github.com/travelaudience/aerospike-operator/pkg/utils/flags"
 )
 
 const (
 	backupCommand  = "backup"
 	restoreCommand = "restore"
+	deleteCommand  = "delete"
+	verifyCommand  = "verify"
 
 	debugFlag      = "debug"
 	bucketNameFlag = "bucket-name"
@@ -44,11 +54,47 @@ const (
 	hostFlag       = "host"
 	portFlag       = "port"
 	namespaceFlag  = "namespace"
+
+	storageTypeFlag      = "storage-type"
+	s3EndpointFlag       = "s3-endpoint"
+	s3RegionFlag         = "s3-region"
+	s3ForcePathStyleFlag = "s3-force-path-style"
+
+	azureStorageAccountFlag = "azure-storage-account"
+	azureEndpointFlag       = "azure-endpoint"
+
+	encryptionKeyPathFlag = "encryption-key-path"
+	compressionFlag       = "compression"
+	compressionLevelFlag  = "compression-level"
+	shardFlag             = "shard"
+	shardsFlag            = "shards"
+	nodesFlag             = "nodes"
+
+	writePolicyFlag        = "write-policy"
+	noGenerationFlag       = "no-generation"
+	setsFlag               = "sets"
+	binsFlag               = "bins"
+	ignoreRecordErrorsFlag = "ignore-record-errors"
+	ttlExtensionFlag       = "ttl-extension"
+
+	modifiedAfterFlag  = "modified-after"
+	modifiedBeforeFlag = "modified-before"
+	baseFlag           = "base"
+
+	// terminationMessagePath is the path to which the reason for a failure is
+	// written so that it is reported in the status of the pod.
+	terminationMessagePath = "/dev/termination-log"
+
+	// passwordArgPrefix is the prefix of the argument that passes the
+	// password of the user to asbackup and asrestore.
+	passwordArgPrefix = "--password="
 )
 
 var (
 	bfs *flag.FlagSet
 	rfs *flag.FlagSet
+	dfs *flag.FlagSet
+	vfs *flag.FlagSet
 
 	debug      bool
 	bucketName string
@@ -57,6 +103,32 @@ var (
 	host       string
 	port       int
 	namespace  string
+
+	storageType      string
+	s3Endpoint       string
+	s3Region         string
+	s3ForcePathStyle bool
+
+	azureStorageAccount string
+	azureEndpoint       string
+
+	encryptionKeyPath string
+	compressionCodec  string
+	compressionLevel  int
+	shard             int
+	shards            int
+	nodes             string
+
+	writePolicy        string
+	noGeneration       bool
+	sets               string
+	bins               string
+	ignoreRecordErrors bool
+	ttlExtension       int64
+
+	modifiedAfter  string
+	modifiedBefore string
+	base           string
 )
 
 // backupMetadata stores metadata about a backup operation.
@@ -64,26 +136,128 @@ type backupMetadata struct {
 	// Namespace holds the original name of the namespace at the time the backup
 	// was performed.
 	Namespace string `json:"namespace"`
+	// CreationTimestamp holds the time at which the backup operation started.
+	CreationTimestamp *time.Time `json:"creationTimestamp,omitempty"`
+	// AerospikeVersion holds the version of Aerospike from which the backup
+	// was performed.
+	AerospikeVersion string `json:"aerospikeVersion,omitempty"`
+	// Records holds the number of records in the backup.
+	Records *int64 `json:"records,omitempty"`
+	// Bytes holds the size in bytes of the (uncompressed) backup data.
+	Bytes *int64 `json:"bytes,omitempty"`
+	// Checksum holds the hex-encoded SHA-256 checksum of the (uncompressed)
+	// backup data.
+	Checksum string `json:"checksum,omitempty"`
+	// EncryptionKeyFingerprint holds the fingerprint of the key used to
+	// encrypt the backup data, if any.
+	EncryptionKeyFingerprint string `json:"encryptionKeyFingerprint,omitempty"`
+	// Compression holds the codec used to compress the backup data. Backups
+	// created before the codec became configurable are compressed using gzip.
+	Compression string `json:"compression,omitempty"`
+	// Shard holds the index of the shard described by the metadata, when the
+	// backup is split into more than one shard.
+	Shard int `json:"shard,omitempty"`
+	// Shards holds the number of shards into which the backup is split, when
+	// it is split into more than one shard.
+	Shards int `json:"shards,omitempty"`
+	// Sets holds the sets included in the backup, when not all sets were
+	// backed up.
+	Sets []string `json:"sets,omitempty"`
+	// Bins holds the bins included in the backup, when not all bins were
+	// backed up.
+	Bins []string `json:"bins,omitempty"`
+	// ModifiedAfter holds the time after which the records included in the
+	// backup were last modified, if any.
+	ModifiedAfter *time.Time `json:"modifiedAfter,omitempty"`
+	// ModifiedBefore holds the time before which the records included in the
+	// backup were last modified, if any.
+	ModifiedBefore *time.Time `json:"modifiedBefore,omitempty"`
+	// Base holds the name of the backup on top of which the backup was made,
+	// when it is an incremental backup.
+	Base string `json:"base,omitempty"`
+}
+
+// getCompression returns the codec used to compress the backup data.
+func (m *backupMetadata) getCompression() string {
+	if m.Compression == "" {
+		return compression.DefaultCodec
+	}
+	return m.Compression
+}
+
+// streamOptions returns the options used to download the backup data, which
+// is decrypted using key if not nil.
+func (m *backupMetadata) streamOptions(key []byte) storage.StreamOptions {
+	return storage.StreamOptions{
+		Compression:   m.getCompression(),
+		EncryptionKey: key,
+	}
 }
 
 func init() {
 	bfs = flag.NewFlagSet(backupCommand, flag.ExitOnError)
 	bfs.BoolVar(&debug, debugFlag, false, "[DEPRECATED] whether to enable debug logging")
 	bfs.StringVar(&bucketName, bucketNameFlag, "", "the name of the bucket to upload the backup to")
-	bfs.StringVar(&name, nameFlag, "", "the name of the backup file to be stored on GCS")
-	bfs.StringVar(&secretPath, secretPathFlag, "/secret/key.json", "the path to the service account credentials file")
+	bfs.StringVar(&name, nameFlag, "", "the name of the backup file to be stored on cloud storage")
+	bfs.StringVar(&secretPath, secretPathFlag, "/secret/key.json", "the path to the cloud storage credentials file")
 	bfs.StringVar(&host, hostFlag, "", "the host to which asbackup will connect")
 	bfs.IntVar(&port, portFlag, 3000, "the port to which asbackup will connect")
 	bfs.StringVar(&namespace, namespaceFlag, "", "the name of the namespace which to backup")
+	bfs.StringVar(&encryptionKeyPath, encryptionKeyPathFlag, "", "the path to the key with which to encrypt the backup (disables encryption if empty)")
+	bfs.StringVar(&compressionCodec, compressionFlag, compression.DefaultCodec, "the codec with which to compress the backup (none, gzip or zstd)")
+	bfs.IntVar(&compressionLevel, compressionLevelFlag, 0, "the compression level (defaults to the codec's default level)")
+	bfs.IntVar(&shard, shardFlag, 0, "the index of the shard to backup")
+	bfs.IntVar(&shards, shardsFlag, 1, "the number of shards into which the backup is split")
+	bfs.StringVar(&nodes, nodesFlag, "", "the comma-separated addresses (in host:port format) of the nodes to backup")
+	bfs.StringVar(&sets, setsFlag, "", "the set to backup (defaults to all sets)")
+	bfs.StringVar(&bins, binsFlag, "", "the comma-separated list of bins to backup (defaults to all bins)")
+	bfs.StringVar(&modifiedAfter, modifiedAfterFlag, "", "only backup the records last modified after the specified time (in rfc 3339 format)")
+	bfs.StringVar(&modifiedBefore, modifiedBeforeFlag, "", "only backup the records last modified before the specified time (in rfc 3339 format)")
+	bfs.StringVar(&base, baseFlag, "", "the name of the backup since which to backup modified records (makes an incremental backup)")
+	addStorageFlags(bfs)
 
 	rfs = flag.NewFlagSet(restoreCommand, flag.ExitOnError)
 	rfs.BoolVar(&debug, debugFlag, false, "[DEPRECATED] whether to enable debug logging")
 	rfs.StringVar(&bucketName, bucketNameFlag, "", "the name of the bucket to download the backup from")
-	rfs.StringVar(&name, nameFlag, "", "the name of the backup file to be retrieved from GCS")
-	rfs.StringVar(&secretPath, secretPathFlag, "/secret/key.json", "the path to the service account credentials file")
+	rfs.StringVar(&name, nameFlag, "", "the name of the backup file to be retrieved from cloud storage")
+	rfs.StringVar(&secretPath, secretPathFlag, "/secret/key.json", "the path to the cloud storage credentials file")
 	rfs.StringVar(&host, hostFlag, "", "the host to which asrestore will connect")
 	rfs.IntVar(&port, portFlag, 3000, "the port to which asrestore will connect")
 	rfs.StringVar(&namespace, namespaceFlag, "", "the name of the namespace which to restore data into")
+	rfs.StringVar(&encryptionKeyPath, encryptionKeyPathFlag, "", "the path to the key with which to decrypt the backup")
+	rfs.StringVar(&writePolicy, writePolicyFlag, common.WritePolicyUpdate, "the policy used to write records that already exist (update, unique or replace)")
+	rfs.BoolVar(&noGeneration, noGenerationFlag, false, "whether to write records regardless of their generation")
+	rfs.StringVar(&sets, setsFlag, "", "the comma-separated list of sets to restore (defaults to all sets)")
+	rfs.StringVar(&bins, binsFlag, "", "the comma-separated list of bins to restore (defaults to all bins)")
+	rfs.BoolVar(&ignoreRecordErrors, ignoreRecordErrorsFlag, false, "whether to ignore permanent errors affecting single records")
+	rfs.Int64Var(&ttlExtension, ttlExtensionFlag, 0, "the number of seconds to add to the time-to-live of records that expire")
+	addStorageFlags(rfs)
+
+	dfs = flag.NewFlagSet(deleteCommand, flag.ExitOnError)
+	dfs.BoolVar(&debug, debugFlag, false, "[DEPRECATED] whether to enable debug logging")
+	dfs.StringVar(&bucketName, bucketNameFlag, "", "the name of the bucket to delete the backup from")
+	dfs.StringVar(&name, nameFlag, "", "the name of the backup file to be deleted")
+	dfs.StringVar(&secretPath, secretPathFlag, "/secret/key.json", "the path to the cloud storage credentials file")
+	dfs.IntVar(&shards, shardsFlag, 1, "the number of shards into which the backup is split")
+	addStorageFlags(dfs)
+
+	vfs = flag.NewFlagSet(verifyCommand, flag.ExitOnError)
+	vfs.BoolVar(&debug, debugFlag, false, "[DEPRECATED] whether to enable debug logging")
+	vfs.StringVar(&bucketName, bucketNameFlag, "", "the name of the bucket to download the backup from")
+	vfs.StringVar(&name, nameFlag, "", "the name of the backup file to be verified")
+	vfs.StringVar(&secretPath, secretPathFlag, "/secret/key.json", "the path to the cloud storage credentials file")
+	vfs.StringVar(&encryptionKeyPath, encryptionKeyPathFlag, "", "the path to the key with which to decrypt the backup")
+	addStorageFlags(vfs)
+}
+
+// addStorageFlags adds the flags describing the cloud storage backend to fs.
+func addStorageFlags(fs *flag.FlagSet) {
+	fs.StringVar(&storageType, storageTypeFlag, common.StorageTypeGCS, "the type of storage to use (gcs, s3, azure or pvc)")
+	fs.StringVar(&s3Endpoint, s3EndpointFlag, "", "the url of the s3-compatible service (defaults to aws s3)")
+	fs.StringVar(&s3Region, s3RegionFlag, "", "the region in which the s3 bucket is located")
+	fs.BoolVar(&s3ForcePathStyle, s3ForcePathStyleFlag, false, "whether to use path-style requests to the s3-compatible service")
+	fs.StringVar(&azureStorageAccount, azureStorageAccountFlag, "", "the name of the azure storage account containing the container")
+	fs.StringVar(&azureEndpoint, azureEndpointFlag, "", "the url of the azure blob service (defaults to the public azure endpoint)")
 }
 
 func main() {
@@ -101,9 +275,12 @@ func main() {
 			log.SetLevel(log.DebugLevel)
 		}
 		log.Info("backup is starting")
-		if err := doBackup(); err != nil {
-			log.Fatal(err)
+		t := newProgressTracker()
+		t.serve()
+		if err := doBackup(t); err != nil {
+			fatal(err)
 		}
+		t.finish()
 		log.Info("backup is complete")
 	case restoreCommand:
 		rfs.Parse(os.Args[2:])
@@ -115,82 +292,333 @@ func main() {
 			log.SetLevel(log.DebugLevel)
 		}
 		log.Info("restore is starting")
-		if err := doRestore(); err != nil {
-			log.Fatal(err)
+		t := newProgressTracker()
+		t.serve()
+		if err := doRestore(t); err != nil {
+			fatal(err)
 		}
+		t.finish()
 		log.Info("restore is complete")
+	case deleteCommand:
+		dfs.Parse(os.Args[2:])
+
+		// warn about deprecated flags
+		flagutils.DeprecateFlags(dfs, debugFlag)
+
+		if debug {
+			log.SetLevel(log.DebugLevel)
+		}
+		log.Info("delete is starting")
+		if err := doDelete(); err != nil {
+			fatal(err)
+		}
+		log.Info("delete is complete")
+	case verifyCommand:
+		vfs.Parse(os.Args[2:])
+
+		// warn about deprecated flags
+		flagutils.DeprecateFlags(vfs, debugFlag)
+
+		if debug {
+			log.SetLevel(log.DebugLevel)
+		}
+		log.Info("verify is starting")
+		t := newProgressTracker()
+		t.serve()
+		if err := doVerify(t); err != nil {
+			fatal(err)
+		}
+		t.finish()
+		log.Info("verify is complete")
 	default:
 		log.Fatalf("invalid command %q", os.Args[1])
 	}
 }
 
-// doBackup performs a backup operation on the target namespace.
-func doBackup() error {
-	// initialize the gcs client and get handles to the meta and backup objects
+// fatal writes err to the termination message path, so that it can be reported
+// by aerospike-operator, and exits.
+func fatal(err error) {
+	if err := ioutil.WriteFile(terminationMessagePath, []byte(err.Error()), 0644); err != nil {
+		log.Debugf("failed to write termination message: %v", err)
+	}
+	log.Fatal(err)
+}
+
+// getCredentials returns the credentials with which to authenticate against
+// the aerospike cluster, read from the environment, or nil if security is not
+// enabled.
+func getCredentials() *asutils.Credentials {
+	user := os.Getenv(backuprestore.UserEnvVar)
+	if user == "" {
+		return nil
+	}
+	return &asutils.Credentials{
+		User:     user,
+		Password: os.Getenv(backuprestore.PasswordEnvVar),
+	}
+}
+
+// getAuthArgs returns the arguments that make asbackup and asrestore
+// authenticate with creds, if not nil.
+func getAuthArgs(creds *asutils.Credentials) []string {
+	if creds == nil {
+		return nil
+	}
+	// the password is optional, so it must be passed in the same argument
+	return []string{"--user", creds.User, passwordArgPrefix + creds.Password}
+}
+
+// redactArgs returns args joined by spaces, hiding the password of the user.
+func redactArgs(args []string) string {
+	res := make([]string, len(args))
+	for i, arg := range args {
+		if strings.HasPrefix(arg, passwordArgPrefix) {
+			arg = passwordArgPrefix + "<redacted>"
+		}
+		res[i] = arg
+	}
+	return strings.Join(res, " ")
+}
+
+// newStorageClient returns a client for the cloud storage backend described
+// by the command-line flags.
+func newStorageClient() (storage.Client, error) {
+	// build the storage spec from the command-line flags
+	spec := &aerospikev1alpha2.BackupStorageSpec{
+		Type:   storageType,
+		Bucket: bucketName,
+	}
+	if storageType == common.StorageTypeS3 {
+		spec.S3 = &aerospikev1alpha2.S3StorageSpec{
+			Endpoint:       &s3Endpoint,
+			Region:         &s3Region,
+			ForcePathStyle: &s3ForcePathStyle,
+		}
+	}
+	if storageType == common.StorageTypeAzure {
+		spec.Azure = &aerospikev1alpha2.AzureStorageSpec{
+			StorageAccount: azureStorageAccount,
+			Endpoint:       &azureEndpoint,
+		}
+	}
+	// backups stored in a persistent volume claim require no credentials
+	if storageType == common.StorageTypePVC {
+		return storage.NewClient(spec, nil)
+	}
+	// read the credentials from the secret file
+	credentials, err := ioutil.ReadFile(secretPath)
+	if err != nil {
+		return nil, err
+	}
+	return storage.NewClient(spec, credentials)
+}
+
+// doBackup performs a backup operation on the target namespace, reporting its
+// progress to t.
+func doBackup(t *progressTracker) error {
+	// initialize the storage client
 	log.Debug("initing cloud storage")
-	client, err := gcs.NewGCSClientFromCredentials(secretPath)
+	client, err := newStorageClient()
 	if err != nil {
 		return err
 	}
 	defer client.Close()
 
-	// dump metadata to the meta file
-	log.Debug("dumping metadata")
-	if err := dumpMetadata(client); err != nil {
+	// make sure that the compression settings are valid
+	if err := compression.Validate(compressionCodec, compressionLevel); err != nil {
+		return err
+	}
+
+	// read the encryption key, if any
+	key, err := readEncryptionKey()
+	if err != nil {
+		return err
+	}
+
+	// gather metadata about the backup
+	m := &backupMetadata{
+		Namespace:         namespace,
+		CreationTimestamp: &t.start,
+		Compression:       compressionCodec,
+	}
+	shardName := name
+	if shards > 1 {
+		m.Shard, m.Shards = shard, shards
+		shardName = backuprestore.GetShardName(name, shard)
+	}
+	if key != nil {
+		m.EncryptionKeyFingerprint = encryption.Fingerprint(key)
+	}
+	creds := getCredentials()
+	if m.AerospikeVersion, err = asutils.GetVersion(host, port, creds); err != nil {
+		log.Warnf("failed to get aerospike version: %v", err)
+	}
+	// determine the records to backup
+	if err := setBackupFilter(client, m); err != nil {
 		return err
 	}
 
 	// build the asbackup command
-	cmd := exec.Command("asbackup", "-h", host, "-p", strconv.Itoa(port), "-n", namespace, "-o", "-", "-c", "-v")
+	args := []string{"-h", host, "-p", strconv.Itoa(port), "-n", namespace, "-o", "-", "-c", "-v"}
+	args = append(args, getAuthArgs(creds)...)
+	args = append(args, getBackupFilterArgs(m)...)
+	if shards > 1 {
+		// backup only the nodes assigned to the current shard
+		if nodes == "" {
+			return fmt.Errorf("the nodes to backup must be specified when the backup is split into shards")
+		}
+		log.Infof("backing up shard %d of %d (nodes %s)", shard, shards, nodes)
+		args = append(args, "-l", nodes)
+	}
+	cmd := exec.Command("asbackup", args...)
+	// asbackup interprets the times used to filter records in local time
+	cmd.Env = append(os.Environ(), "TZ=UTC")
 	// get a handle to stdout
 	o, err := cmd.StdoutPipe()
 	if err != nil {
 		return err
 	}
-	// capture asbackup's stderr
+	// capture asbackup's stderr, looking for the number of records that were
+	// backed up and the percentage of completion
 	errw := log.New().Writer()
 	defer errw.Close()
-	cmd.Stderr = errw
+	records := &recordCounter{}
+	cmd.Stderr = io.MultiWriter(errw, records)
+	t.setRecordCounter(records)
 
 	// give some feedback about what is going to be executed
 	log.Debug("==== asbackup ====")
-	log.Debug(strings.Join(cmd.Args, " "))
+	log.Debug(redactArgs(cmd.Args))
 	log.Debug("==================")
 
+	// create a writer that compresses, encrypts and uploads the backup data
+	w, err := storage.NewWriter(client, backuprestore.GetBackupObjectName(shardName, compressionCodec), storage.StreamOptions{
+		Compression:      compressionCodec,
+		CompressionLevel: compressionLevel,
+		EncryptionKey:    key,
+	})
+	if err != nil {
+		return err
+	}
+
 	// launch the asbackup process
 	log.Debug("running asbackup and streaming to cloud storage")
 	if err := cmd.Start(); err != nil {
+		w.Abort()
 		return err
 	}
-	// transfer data from asbackup's stdout to gcs
-	if err := client.TransferToGCS(o, bucketName, backuprestore.GetBackupObjectName(name)); err != nil {
+	// transfer data from asbackup's stdout to cloud storage, computing the
+	// checksum and size of the data along the way
+	cw := newChecksumWriter()
+	t.startPass(cw.c)
+	if _, err := io.Copy(w, io.TeeReader(o, cw)); err != nil {
+		// kill asbackup so that it does not block writing to stdout
+		cmd.Process.Kill()
+		cmd.Wait()
+		w.Abort()
 		return err
 	}
-	// wait for asbackup to terminate
-	return cmd.Wait()
+	// wait for asbackup to terminate, and only complete the upload if it
+	// succeeded so that no truncated backup is stored
+	if err := cmd.Wait(); err != nil {
+		w.Abort()
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	log.Infof("%d bytes written", cw.Bytes())
+
+	// dump metadata to the meta file
+	log.Debug("dumping metadata")
+	m.Records = records.Records()
+	m.Bytes = pointers.NewInt64(cw.Bytes())
+	m.Checksum = cw.Checksum()
+	return dumpMetadata(client, shardName, m)
 }
 
-// doRestore performs a restore operation to the target namespace.
-func doRestore() error {
-	// initialize the gcs client and get handles to the meta and backup objects
+// doRestore performs a restore operation to the target namespace, reporting
+// its progress to t.
+func doRestore(t *progressTracker) error {
+	// make sure that the restore options are valid
+	opts, err := getRestoreOptionsArgs()
+	if err != nil {
+		return err
+	}
+
+	// initialize the storage client
 	log.Debug("initing cloud storage")
-	client, err := gcs.NewGCSClientFromCredentials(secretPath)
+	client, err := newStorageClient()
 	if err != nil {
 		return err
 	}
 	defer client.Close()
 
-	// read metadata to the meta file
+	// read metadata to the meta file, as well as the metadata of the base
+	// backups of an incremental backup
 	log.Debug("reading metadata")
-	n, err := readMetadata(client)
+	backupShards, err := readChain(client)
+	if err != nil {
+		return err
+	}
+	// records restored from incremental backups must overwrite the ones
+	// restored from their base backups
+	if writePolicy == common.WritePolicyUnique && backupShards[len(backupShards)-1].metadata.Base != "" {
+		return fmt.Errorf("the %s write policy cannot be used to restore incremental backups", writePolicy)
+	}
+
+	// make sure that the backup can be decrypted before doing anything else
+	key, err := readEncryptionKey()
 	if err != nil {
 		return err
 	}
+	if err := setDecryptionKeys(backupShards, key); err != nil {
+		return err
+	}
 
+	// verify the integrity of the backup data before restoring it, so that
+	// corrupted or incomplete data never reaches asrestore. backups created by
+	// older versions of aerospike-operator have no checksum.
+	verify := true
+	for _, s := range backupShards {
+		verify = verify && s.metadata.Checksum != ""
+	}
+	bytes, records := getTotals(backupShards)
+	if verify {
+		log.Debug("verifying backup data")
+		t.setTotals(bytes, records, 2)
+		for _, s := range backupShards {
+			if err := verifyBackup(client, s, t); err != nil {
+				return err
+			}
+		}
+		t.startPhase()
+	} else {
+		log.Warn("backup metadata does not contain a checksum, skipping verification")
+		t.setTotals(bytes, records, 1)
+	}
+
+	// restore every shard of the backup
+	for _, s := range backupShards {
+		if err := restoreShard(client, s, opts, t); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// restoreShard restores the data of the specified shard to the target
+// namespace using the specified asrestore options, reporting its progress to t.
+func restoreShard(client storage.Client, s *backupShard, opts []string, t *progressTracker) error {
 	// build the asrestore command
-	cmd := exec.Command("asrestore", "-h", host, "-p", strconv.Itoa(port), "-i", "-", "-n", fmt.Sprintf("%s,%s", n.Namespace, namespace), "-v")
+	args := append([]string{"-h", host, "-p", strconv.Itoa(port), "-i", "-", "-n", fmt.Sprintf("%s,%s", s.metadata.Namespace, namespace), "-v"}, getAuthArgs(getCredentials())...)
+	args = append(args, opts...)
+	cmd := exec.Command("asrestore", args...)
 	// get a handle to stdin
 	i, err := cmd.StdinPipe()
+	if err != nil {
+		return err
+	}
 	// capture asrestore's stderr
 	errw := log.New().Writer()
 	defer errw.Close()
@@ -198,7 +626,7 @@ func doRestore() error {
 
 	// give some feedback about what is going to be executed
 	log.Debug("==== asrestore ====")
-	log.Debug(strings.Join(cmd.Args, " "))
+	log.Debug(redactArgs(cmd.Args))
 	log.Debug("===================")
 
 	// launch the asrestore process
@@ -206,8 +634,12 @@ func doRestore() error {
 	if err := cmd.Start(); err != nil {
 		return err
 	}
-	// transfer data from gcs to asrestore's stdin
-	if err := client.TransferFromGCS(i, bucketName, backuprestore.GetBackupObjectName(name)); err != nil {
+	// transfer data from cloud storage to asrestore's stdin, computing the
+	// checksum of the data along the way in case it changes after having been
+	// verified
+	cw := newChecksumWriter()
+	t.startPass(cw.c)
+	if err := storage.Download(client, io.MultiWriter(i, cw), s.objectName(), s.metadata.streamOptions(s.key)); err != nil {
 		return err
 	}
 	// close stdin when we're done
@@ -215,36 +647,100 @@ func doRestore() error {
 		return err
 	}
 	// wait for asrestore to terminate
-	return cmd.Wait()
+	if err := cmd.Wait(); err != nil {
+		return err
+	}
+	if s.metadata.Checksum != "" {
+		return cw.verify(s.metadata)
+	}
+	return nil
 }
 
-// dumpMetadata dumps backup metadata to GCS.
-func dumpMetadata(client *gcs.GCSClient) error {
-	// get the object
-	metaObject, err := client.GetObject(bucketName, backuprestore.GetMetadataObjectName(name))
+// getRestoreOptionsArgs returns the asrestore arguments corresponding to the
+// restore options specified in the command line.
+func getRestoreOptionsArgs() ([]string, error) {
+	args := make([]string, 0)
+	switch writePolicy {
+	case common.WritePolicyUpdate:
+	case common.WritePolicyUnique:
+		if noGeneration {
+			return nil, fmt.Errorf("the %s write policy cannot be combined with -%s", writePolicy, noGenerationFlag)
+		}
+		args = append(args, "--unique")
+	case common.WritePolicyReplace:
+		args = append(args, "--replace")
+	default:
+		return nil, fmt.Errorf("unsupported write policy %q", writePolicy)
+	}
+	if noGeneration {
+		args = append(args, "--no-generation")
+	}
+	if sets != "" {
+		args = append(args, "--set-list", sets)
+	}
+	if bins != "" {
+		args = append(args, "--bin-list", bins)
+	}
+	if ignoreRecordErrors {
+		args = append(args, "--ignore-record-error")
+	}
+	if ttlExtension != 0 {
+		args = append(args, "--extra-ttl", strconv.FormatInt(ttlExtension, 10))
+	}
+	return args, nil
+}
+
+// doDelete deletes the data of the target backup from storage.
+func doDelete() error {
+	// initialize the storage client
+	log.Debug("initing storage")
+	client, err := newStorageClient()
 	if err != nil {
 		return err
 	}
+	defer client.Close()
+
+	// delete the metadata and the backup data, ignoring files that do not
+	// exist (e.g., because the backup has failed). the metadata may not
+	// exist, so the backup data is deleted regardless of the codec used to
+	// compress it.
+	var objectNames []string
+	for _, shardName := range backuprestore.GetShardNames(name, shards) {
+		objectNames = append(objectNames, backuprestore.GetMetadataObjectName(shardName))
+		for _, codec := range compression.Codecs() {
+			objectNames = append(objectNames, backuprestore.GetBackupObjectName(shardName, codec))
+		}
+	}
+	for _, objectName := range objectNames {
+		if err := client.DeleteObject(objectName); err != nil {
+			if err != storage.ErrNotFound {
+				return err
+			}
+			log.Debugf("%s does not exist", objectName)
+		}
+	}
+	return nil
+}
+
+// dumpMetadata dumps the metadata of the specified shard to cloud storage.
+func dumpMetadata(client storage.Client, shardName string, m *backupMetadata) error {
 	// create a writer that writes to the target object
-	w := metaObject.NewWriter(context.Background())
-	defer w.Close()
+	w, err := client.NewWriter(backuprestore.GetMetadataObjectName(shardName))
+	if err != nil {
+		return err
+	}
 	// dump the backup metadata to the writer
-	m := &backupMetadata{Namespace: namespace}
 	if err := json.NewEncoder(w).Encode(m); err != nil {
+		w.Abort()
 		return err
 	}
-	return nil
+	return w.Close()
 }
 
-// readMetadata reads backup metadata from GCS.
-func readMetadata(client *gcs.GCSClient) (*backupMetadata, error) {
-	// get the object
-	metaObject, err := client.GetObject(bucketName, backuprestore.GetMetadataObjectName(name))
-	if err != nil {
-		return nil, err
-	}
+// readMetadata reads the metadata of the specified shard from cloud storage.
+func readMetadata(client storage.Client, shardName string) (*backupMetadata, error) {
 	// create a reader that reads from the source object
-	r, err := metaObject.NewReader(context.Background())
+	r, err := client.NewReader(backuprestore.GetMetadataObjectName(shardName))
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,72 @@
+/*
+Copyright 2019 The aerospike-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/travelaudience/aerospike-operator/pkg/apis/aerospike/common"
+	"github.com/travelaudience/aerospike-operator/pkg/asutils"
+)
+
+func TestGetRestoreOptionsArgs(t *testing.T) {
+	tests := []struct {
+		writePolicy        string
+		noGeneration       bool
+		sets               string
+		bins               string
+		ignoreRecordErrors bool
+		ttlExtension       int64
+		expected           []string
+		valid              bool
+	}{
+		// the default options require no flags
+		{common.WritePolicyUpdate, false, "", "", false, 0, []string{}, true},
+		{common.WritePolicyUnique, false, "", "", false, 0, []string{"--unique"}, true},
+		{common.WritePolicyReplace, true, "", "", false, 0, []string{"--replace", "--no-generation"}, true},
+		{common.WritePolicyUpdate, false, "set-0,set-1", "bin-0", true, 3600,
+			[]string{"--set-list", "set-0,set-1", "--bin-list", "bin-0", "--ignore-record-error", "--extra-ttl", "3600"}, true},
+		// asrestore does not support combining --unique and --no-generation
+		{common.WritePolicyUnique, true, "", "", false, 0, nil, false},
+		{"overwrite", false, "", "", false, 0, nil, false},
+	}
+	for _, test := range tests {
+		writePolicy = test.writePolicy
+		noGeneration = test.noGeneration
+		sets = test.sets
+		bins = test.bins
+		ignoreRecordErrors = test.ignoreRecordErrors
+		ttlExtension = test.ttlExtension
+		args, err := getRestoreOptionsArgs()
+		if test.valid {
+			assert.NoError(t, err)
+		} else {
+			assert.Error(t, err)
+		}
+		assert.Equal(t, test.expected, args)
+	}
+}
+
+func TestGetAuthArgs(t *testing.T) {
+	assert.Nil(t, getAuthArgs(nil))
+	args := getAuthArgs(&asutils.Credentials{User: "admin", Password: "s3cr3t"})
+	assert.Equal(t, []string{"--user", "admin", "--password=s3cr3t"}, args)
+	// the password is never logged
+	assert.Equal(t, "asbackup -h localhost --user admin --password=<redacted>", redactArgs(append([]string{"asbackup", "-h", "localhost"}, args...)))
+}
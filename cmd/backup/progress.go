@@ -0,0 +1,176 @@
+/*
+Copyright 2019 The aerospike-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/travelaudience/aerospike-operator/pkg/backuprestore"
+)
+
+// progressTracker keeps track of the progress of an operation, which it
+// serves over HTTP so that it can be reported by aerospike-operator.
+type progressTracker struct {
+	mu         sync.Mutex
+	start      time.Time
+	completion *time.Time
+	// counter counts the bytes transferred in the current pass over the data.
+	counter *byteCounter
+	// transferred is the number of bytes transferred in previous passes over
+	// the data, and phaseTransferred is the number of those bytes transferred
+	// in the current phase of the operation (e.g., restores verify the data
+	// before restoring it).
+	transferred      int64
+	phaseTransferred int64
+	// records reports the number of records and the percentage of completion
+	// of a backup operation.
+	records *recordCounter
+	// expectedBytes holds the number of bytes that are expected to be
+	// transferred over all passes over the data, and totalRecords holds the
+	// number of records in the backup, if known.
+	expectedBytes *int64
+	totalRecords  *int64
+}
+
+func newProgressTracker() *progressTracker {
+	return &progressTracker{start: time.Now().UTC()}
+}
+
+// setRecordCounter makes t report the number of records and the percentage of
+// completion reported by asbackup.
+func (t *progressTracker) setRecordCounter(c *recordCounter) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.records = c
+}
+
+// setTotals makes t report progress relative to the size of a backup holding
+// the specified number of bytes and records, whose data is read the specified
+// number of times.
+func (t *progressTracker) setTotals(bytes, records *int64, passes int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if bytes != nil {
+		expected := *bytes * int64(passes)
+		t.expectedBytes = &expected
+	}
+	t.totalRecords = records
+}
+
+// startPass makes t report the number of bytes counted by c as the current
+// pass over the data.
+func (t *progressTracker) startPass(c *byteCounter) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.counter != nil {
+		t.transferred += t.counter.N()
+		t.phaseTransferred += t.counter.N()
+	}
+	t.counter = c
+}
+
+// startPhase makes t report the number of bytes transferred from the start of
+// the next pass over the data.
+func (t *progressTracker) startPhase() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.counter != nil {
+		t.transferred += t.counter.N()
+	}
+	t.counter = nil
+	t.phaseTransferred = 0
+}
+
+// Progress returns the current progress of the operation.
+func (t *progressTracker) Progress() backuprestore.Progress {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	p := backuprestore.Progress{
+		StartTime:      t.start,
+		CompletionTime: t.completion,
+		Records:        t.totalRecords,
+	}
+	var current int64
+	if t.counter != nil {
+		current = t.counter.N()
+	}
+	p.Bytes = t.phaseTransferred + current
+	if t.records != nil {
+		p.Records = t.records.Records()
+		p.Percentage = t.records.Percentage()
+	}
+	if t.expectedBytes != nil && *t.expectedBytes > 0 {
+		p.Percentage = percentage(t.transferred+current, *t.expectedBytes)
+	}
+	if t.completion != nil {
+		p.Percentage = percentage(1, 1)
+	} else if p.Percentage != nil && *p.Percentage > 99 {
+		// the operation is not complete until the data has been fully
+		// transferred and verified
+		p.Percentage = percentage(99, 100)
+	}
+	return p
+}
+
+// percentage returns the percentage that n represents out of total.
+func percentage(n, total int64) *int32 {
+	p := int32(n * 100 / total)
+	return &p
+}
+
+// serve starts serving the progress of the operation over HTTP in the
+// background.
+func (t *progressTracker) serve() {
+	mux := http.NewServeMux()
+	mux.HandleFunc(backuprestore.ProgressPath, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(t.Progress()); err != nil {
+			log.Debugf("failed to write progress: %v", err)
+		}
+	})
+	go func() {
+		if err := http.ListenAndServe(fmt.Sprintf(":%d", backuprestore.ProgressPort), mux); err != nil {
+			log.Warnf("failed to serve progress: %v", err)
+		}
+	}()
+}
+
+// finish marks the operation as complete and writes its final progress to the
+// termination message path, so that it can be reported by aerospike-operator
+// after the pod terminates.
+func (t *progressTracker) finish() {
+	t.mu.Lock()
+	now := time.Now().UTC()
+	t.completion = &now
+	t.mu.Unlock()
+
+	b, err := json.Marshal(t.Progress())
+	if err != nil {
+		log.Debugf("failed to marshal progress: %v", err)
+		return
+	}
+	if err := ioutil.WriteFile(terminationMessagePath, b, 0644); err != nil {
+		log.Debugf("failed to write termination message: %v", err)
+	}
+}
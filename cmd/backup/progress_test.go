@@ -0,0 +1,74 @@
+/*
+Copyright 2019 The aerospike-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/travelaudience/aerospike-operator/pkg/pointers"
+)
+
+func TestRecordCounterPercentage(t *testing.T) {
+	c := &recordCounter{}
+	assert.Equal(t, (*int32)(nil), c.Percentage())
+	_, err := c.Write([]byte("2019-07-02 14:48:26 GMT [INF] [   36] 42% complete (~1234 KiB/s, ~5678 rec/s, ~12 s remaining)\n"))
+	assert.NoError(t, err)
+	assert.Equal(t, pointers.NewInt32(42), c.Percentage())
+	assert.Equal(t, (*int64)(nil), c.Records())
+}
+
+func TestProgressTracker(t *testing.T) {
+	tests := []struct {
+		passes     int
+		phases     [][]int64
+		completion bool
+		bytes      int64
+		expected   int32
+	}{
+		{1, [][]int64{{0}}, false, 0, 0},
+		{1, [][]int64{{50}}, false, 50, 50},
+		{1, [][]int64{{100}}, false, 100, 99},
+		{1, [][]int64{{100}}, true, 100, 100},
+		{1, [][]int64{{30, 20}}, false, 50, 50},
+		{2, [][]int64{{50}}, false, 50, 25},
+		{2, [][]int64{{100}, {50}}, false, 50, 75},
+		{2, [][]int64{{60, 40}, {60, 20}}, false, 80, 90},
+	}
+	for _, test := range tests {
+		tracker := newProgressTracker()
+		tracker.setTotals(pointers.NewInt64(100), pointers.NewInt64(10), test.passes)
+		for i, phase := range test.phases {
+			if i > 0 {
+				tracker.startPhase()
+			}
+			for _, n := range phase {
+				tracker.startPass(&byteCounter{n: n})
+			}
+		}
+		if test.completion {
+			now := time.Now()
+			tracker.completion = &now
+		}
+		p := tracker.Progress()
+		assert.Equal(t, test.bytes, p.Bytes)
+		assert.Equal(t, pointers.NewInt64(10), p.Records)
+		assert.Equal(t, pointers.NewInt32(test.expected), p.Percentage)
+	}
+}
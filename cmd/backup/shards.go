@@ -0,0 +1,113 @@
+/*
+Copyright 2019 The aerospike-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/travelaudience/aerospike-operator/pkg/backuprestore"
+	"github.com/travelaudience/aerospike-operator/pkg/backuprestore/storage"
+)
+
+// backupShard describes a shard of a backup. Backups that are not split are
+// made of a single shard named after the backup.
+type backupShard struct {
+	// name is the name from which the names of the shard's objects are
+	// generated.
+	name string
+	// metadata is the shard's metadata.
+	metadata *backupMetadata
+	// key is the key used to decrypt the shard's data, or nil if the data is
+	// not encrypted.
+	key []byte
+}
+
+// objectName returns the name of the object holding the shard's data.
+func (s *backupShard) objectName() string {
+	return backuprestore.GetBackupObjectName(s.name, s.metadata.getCompression())
+}
+
+// readShards reads the metadata of every shard of the specified backup.
+func readShards(client storage.Client, name string) ([]*backupShard, error) {
+	m, err := readMetadata(client, name)
+	if err == nil {
+		return []*backupShard{{name: name, metadata: m}}, nil
+	}
+	// the backup may have been split into shards, in which case the metadata
+	// of the first shard holds the number of shards
+	first, ferr := readMetadata(client, backuprestore.GetShardName(name, 0))
+	if ferr != nil || first.Shards <= 1 {
+		return nil, err
+	}
+	res := []*backupShard{{name: backuprestore.GetShardName(name, 0), metadata: first}}
+	for i := 1; i < first.Shards; i++ {
+		shardName := backuprestore.GetShardName(name, i)
+		m, err := readMetadata(client, shardName)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the metadata of shard %d: %v", i, err)
+		}
+		if m.Namespace != first.Namespace || m.Shards != first.Shards {
+			return nil, fmt.Errorf("the metadata of shard %d does not match the metadata of shard 0", i)
+		}
+		res = append(res, &backupShard{name: shardName, metadata: m})
+	}
+	log.Infof("backup %s is split into %d shards", name, len(res))
+	return res, nil
+}
+
+// setDecryptionKeys determines the key that must be used to decrypt each
+// shard, failing if any shard is encrypted and key does not match the one
+// used to encrypt it.
+func setDecryptionKeys(shards []*backupShard, key []byte) error {
+	for _, s := range shards {
+		k, err := getDecryptionKey(s.metadata, key)
+		if err != nil {
+			return err
+		}
+		s.key = k
+	}
+	return nil
+}
+
+// getTotals returns the total size and number of records of the backup made
+// of the specified shards, if known.
+func getTotals(shards []*backupShard) (*int64, *int64) {
+	var bytes, records int64
+	bytesKnown, recordsKnown := true, true
+	for _, s := range shards {
+		if s.metadata.Bytes != nil {
+			bytes += *s.metadata.Bytes
+		} else {
+			bytesKnown = false
+		}
+		if s.metadata.Records != nil {
+			records += *s.metadata.Records
+		} else {
+			recordsKnown = false
+		}
+	}
+	var resBytes, resRecords *int64
+	if bytesKnown {
+		resBytes = &bytes
+	}
+	if recordsKnown {
+		resRecords = &records
+	}
+	return resBytes, resRecords
+}
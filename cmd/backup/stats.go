@@ -0,0 +1,113 @@
+/*
+Copyright 2019 The aerospike-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"regexp"
+	"strconv"
+	"sync"
+	"sync/atomic"
+)
+
+var (
+	// recordCountRegexp matches the summary line printed by asbackup when a
+	// backup completes (e.g., "Backed up 1234 record(s), 0 secondary index(es),
+	// ...").
+	recordCountRegexp = regexp.MustCompile(`Backed up (\d+) record\(s\)`)
+	// percentageRegexp matches the progress lines periodically printed by
+	// asbackup (e.g., "42% complete (~1234 KiB/s, ~5678 rec/s, ~12 s
+	// remaining)").
+	percentageRegexp = regexp.MustCompile(`(\d+)% complete`)
+)
+
+// byteCounter is an io.Writer that counts the number of bytes written to it.
+// It is safe to read the count while data is being written.
+type byteCounter struct {
+	n int64
+}
+
+func (c *byteCounter) Write(p []byte) (int, error) {
+	atomic.AddInt64(&c.n, int64(len(p)))
+	return len(p), nil
+}
+
+// N returns the number of bytes written so far.
+func (c *byteCounter) N() int64 {
+	return atomic.LoadInt64(&c.n)
+}
+
+// recordCounter is an io.Writer that scans asbackup's output line by line for
+// the number of records that were backed up and the reported percentage of
+// completion.
+type recordCounter struct {
+	mu         sync.Mutex
+	buf        []byte
+	records    *int64
+	percentage *int32
+}
+
+func (c *recordCounter) Write(p []byte) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.buf = append(c.buf, p...)
+	for {
+		i := bytes.IndexByte(c.buf, '\n')
+		if i < 0 {
+			break
+		}
+		c.parseLine(c.buf[:i])
+		c.buf = c.buf[i+1:]
+	}
+	return len(p), nil
+}
+
+// Records returns the number of records that were backed up, or nil if
+// asbackup has not reported it.
+func (c *recordCounter) Records() *int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	// the last line may not be terminated by a newline
+	if len(c.buf) > 0 {
+		c.parseLine(c.buf)
+		c.buf = nil
+	}
+	return c.records
+}
+
+// Percentage returns the latest percentage of completion reported by
+// asbackup, or nil if asbackup has not reported it.
+func (c *recordCounter) Percentage() *int32 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.percentage
+}
+
+func (c *recordCounter) parseLine(line []byte) {
+	if m := percentageRegexp.FindSubmatch(line); m != nil {
+		if n, err := strconv.ParseInt(string(m[1]), 10, 32); err == nil {
+			p := int32(n)
+			c.percentage = &p
+		}
+		return
+	}
+	if m := recordCountRegexp.FindSubmatch(line); m != nil {
+		if n, err := strconv.ParseInt(string(m[1]), 10, 64); err == nil {
+			c.records = &n
+		}
+	}
+}
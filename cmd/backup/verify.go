@@ -0,0 +1,127 @@
+/*
+Copyright 2019 The aerospike-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/travelaudience/aerospike-operator/pkg/backuprestore/storage"
+)
+
+// checksumWriter is an io.Writer that computes the SHA-256 checksum and size
+// of the data written to it.
+type checksumWriter struct {
+	h hash.Hash
+	c *byteCounter
+	w io.Writer
+}
+
+func newChecksumWriter() *checksumWriter {
+	cw := &checksumWriter{h: sha256.New(), c: &byteCounter{}}
+	cw.w = io.MultiWriter(cw.h, cw.c)
+	return cw
+}
+
+func (cw *checksumWriter) Write(p []byte) (int, error) {
+	return cw.w.Write(p)
+}
+
+// Checksum returns the hex-encoded SHA-256 checksum of the data written so far.
+func (cw *checksumWriter) Checksum() string {
+	return hex.EncodeToString(cw.h.Sum(nil))
+}
+
+// Bytes returns the number of bytes written so far.
+func (cw *checksumWriter) Bytes() int64 {
+	return cw.c.N()
+}
+
+// verify checks that the checksum and size of the data written to cw match the
+// ones stored in the backup's metadata.
+func (cw *checksumWriter) verify(m *backupMetadata) error {
+	if m.Bytes != nil && *m.Bytes != cw.Bytes() {
+		return fmt.Errorf("backup data is corrupted: expected %d bytes, got %d", *m.Bytes, cw.Bytes())
+	}
+	if checksum := cw.Checksum(); checksum != m.Checksum {
+		return fmt.Errorf("backup data is corrupted: expected checksum %s, got %s", m.Checksum, checksum)
+	}
+	return nil
+}
+
+// verifyBackup downloads the data of the specified shard and checks it
+// against the checksum stored in the shard's metadata. The download is
+// reported to t as a pass over the data.
+func verifyBackup(client storage.Client, s *backupShard, t *progressTracker) error {
+	cw := newChecksumWriter()
+	t.startPass(cw.c)
+	if err := storage.Download(client, cw, s.objectName(), s.metadata.streamOptions(s.key)); err != nil {
+		return err
+	}
+	if err := cw.verify(s.metadata); err != nil {
+		return err
+	}
+	log.Infof("backup data matches checksum %s", s.metadata.Checksum)
+	return nil
+}
+
+// doVerify verifies the integrity of the target backup without restoring it,
+// reporting its progress to t.
+func doVerify(t *progressTracker) error {
+	// initialize the storage client
+	log.Debug("initing storage")
+	client, err := newStorageClient()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	// read metadata from the meta file, as well as the metadata of the base
+	// backups of an incremental backup
+	log.Debug("reading metadata")
+	backupShards, err := readChain(client)
+	if err != nil {
+		return err
+	}
+	for _, s := range backupShards {
+		if s.metadata.Checksum == "" {
+			return fmt.Errorf("backup metadata does not contain a checksum")
+		}
+	}
+
+	// read the encryption key, if any
+	key, err := readEncryptionKey()
+	if err != nil {
+		return err
+	}
+	if err := setDecryptionKeys(backupShards, key); err != nil {
+		return err
+	}
+	bytes, records := getTotals(backupShards)
+	t.setTotals(bytes, records, 1)
+	for _, s := range backupShards {
+		if err := verifyBackup(client, s, t); err != nil {
+			return err
+		}
+	}
+	return nil
+}
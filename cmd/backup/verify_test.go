@@ -0,0 +1,75 @@
+/*
+Copyright 2019 The aerospike-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/travelaudience/aerospike-operator/pkg/pointers"
+)
+
+func TestChecksumWriterVerify(t *testing.T) {
+	// sha256("hello world")
+	checksum := "b94d27b9934d3e08a52e52d7da7dabfac484efe37a5380ee9088f7ace2efcde9"
+
+	tests := []struct {
+		data     string
+		metadata *backupMetadata
+		valid    bool
+	}{
+		{"hello world", &backupMetadata{Checksum: checksum}, true},
+		{"hello world", &backupMetadata{Checksum: checksum, Bytes: pointers.NewInt64(11)}, true},
+		{"hello world", &backupMetadata{Checksum: checksum, Bytes: pointers.NewInt64(10)}, false},
+		{"hello", &backupMetadata{Checksum: checksum}, false},
+		{"hello world!", &backupMetadata{Checksum: checksum}, false},
+	}
+	for _, test := range tests {
+		cw := newChecksumWriter()
+		_, err := io.Copy(cw, strings.NewReader(test.data))
+		assert.NoError(t, err)
+		err = cw.verify(test.metadata)
+		if test.valid {
+			assert.NoError(t, err)
+		} else {
+			assert.Error(t, err)
+		}
+	}
+}
+
+func TestRecordCounter(t *testing.T) {
+	tests := []struct {
+		output   []string
+		expected *int64
+	}{
+		{[]string{"Processing 1 node(s)\n"}, nil},
+		{[]string{"Backed up 1234 record(s), 0 secondary index(es), 0 UDF file(s) from 1 node(s)\n"}, pointers.NewInt64(1234)},
+		// the summary may be split across writes and not terminated by a newline
+		{[]string{"Processing 1 node(s)\nBacked up 12", "34 record(s), 0 secondary index(es)"}, pointers.NewInt64(1234)},
+	}
+	for _, test := range tests {
+		c := &recordCounter{}
+		for _, s := range test.output {
+			_, err := c.Write([]byte(s))
+			assert.NoError(t, err)
+		}
+		assert.Equal(t, test.expected, c.Records())
+	}
+}
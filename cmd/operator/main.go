@@ -20,12 +20,15 @@ import (
 	"context"
 	"flag"
 	"os"
+	"strings"
 	"sync"
 	"time"
 
 	log "github.com/sirupsen/logrus"
 	"k8s.io/api/core/v1"
 	extsclientset "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	kubeinformers "k8s.io/client-go/informers"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/kubernetes/scheme"
@@ -45,6 +48,7 @@ import (
 	"github.com/travelaudience/aerospike-operator/pkg/crd"
 	v1alpha2converters "github.com/travelaudience/aerospike-operator/pkg/crd/converters/v1alpha2"
 	"github.com/travelaudience/aerospike-operator/pkg/debug"
+	"github.com/travelaudience/aerospike-operator/pkg/images"
 	"github.com/travelaudience/aerospike-operator/pkg/signals"
 	flagutils "github.com/travelaudience/aerospike-operator/pkg/utils/flags"
 	"github.com/travelaudience/aerospike-operator/pkg/versioning"
@@ -54,18 +58,37 @@ const (
 	admissionEnabledFlag = "admission-enabled"
 	debugEnabledFlag     = "debug"
 	kubeconfigFlag       = "kubeconfig"
+	versionCatalogueFlag = "version-catalogue"
+	serverRepositoryFlag = "server-image-repository"
+	toolsImageFlag       = "tools-image"
+	exporterImageFlag    = "exporter-image"
+	pullPolicyFlag       = "image-pull-policy"
+	pullSecretsFlag      = "image-pull-secrets"
+
+	// versionCatalogueReloadPeriod is the interval at which the version
+	// catalogue is checked for changes
+	versionCatalogueReloadPeriod = 30 * time.Second
 )
 
 var (
-	fs         *flag.FlagSet
-	kubeconfig string
-	wh         *admission.ValidatingAdmissionWebhook
+	fs               *flag.FlagSet
+	kubeconfig       string
+	versionCatalogue string
+	pullPolicy       string
+	pullSecrets      string
+	wh               *admission.ValidatingAdmissionWebhook
 )
 
 func init() {
 	fs = flag.NewFlagSet("", flag.ExitOnError)
 	fs.BoolVar(&debug.DebugEnabled, debugEnabledFlag, false, "[DEPRECATED] Whether to enable debug mode.")
 	fs.StringVar(&kubeconfig, kubeconfigFlag, "", "Path to a kubeconfig. Only required if out-of-cluster.")
+	fs.StringVar(&images.DefaultServerRepository, serverRepositoryFlag, images.DefaultServerRepository, "Repository of the images used to run the versions of Aerospike for which the version catalogue does not specify an image.")
+	fs.StringVar(&images.DefaultTools, toolsImageFlag, "", "Image used to run the init container of Aerospike pods and backup/restore jobs. Defaults to the tools image matching the version of aerospike-operator.")
+	fs.StringVar(&images.DefaultExporter, exporterImageFlag, "", "Image used to run the Prometheus exporter in Aerospike pods. Defaults to the tools image.")
+	fs.StringVar(&pullPolicy, pullPolicyFlag, "", "Pull policy for every image (Always, IfNotPresent or Never). Defaults to the pull policy of each container.")
+	fs.StringVar(&pullSecrets, pullSecretsFlag, "", "Comma-separated list of the names of the secrets used to pull images, which must exist in the Kubernetes namespace of every Aerospike cluster.")
+	fs.StringVar(&versionCatalogue, versionCatalogueFlag, "", "Path to a file (e.g. mounted from a ConfigMap) holding the catalogue of supported Aerospike versions. Defaults to the versions supported by this build.")
 	fs.BoolVar(&admission.Enabled, admissionEnabledFlag, true, "[DEPRECATED] Whether to enable the validating admission webhook.")
 }
 
@@ -88,6 +111,17 @@ func main() {
 		"version": versioning.OperatorVersion,
 	}).Infof("aerospike-operator is starting")
 
+	// validate and set the image pull policy and secrets
+	switch v1.PullPolicy(pullPolicy) {
+	case "", v1.PullAlways, v1.PullIfNotPresent, v1.PullNever:
+		images.DefaultPullPolicy = v1.PullPolicy(pullPolicy)
+	default:
+		log.Fatalf("invalid value for --%s: %q", pullPolicyFlag, pullPolicy)
+	}
+	if pullSecrets != "" {
+		images.DefaultPullSecrets = strings.Split(pullSecrets, ",")
+	}
+
 	// grab the name of the current namespace so we can do leader election
 	namespace := os.Getenv("POD_NAMESPACE")
 	if namespace == "" {
@@ -119,6 +153,16 @@ func main() {
 		log.Fatalf("failed to create aerospike clientset: %v", err)
 	}
 
+	// load the version catalogue (if specified) and reload it whenever it
+	// changes, keeping the versions run by existing aerospike clusters
+	if versionCatalogue != "" {
+		inUse := versionsInUse(aerospikeClient)
+		if err := versioning.LoadCatalogue(versionCatalogue, inUse); err != nil {
+			log.Fatalf("failed to load version catalogue: %v", err)
+		}
+		go versioning.WatchCatalogue(versionCatalogue, versionCatalogueReloadPeriod, inUse, shCh)
+	}
+
 	// register (if enabled) and run the validating admission webhook and health
 	// endpoint
 	wh = admission.NewValidatingAdmissionWebhook(namespace, kubeClient, aerospikeClient)
@@ -179,6 +223,31 @@ func createRecorder(kubeClient kubernetes.Interface, name, namespace string) rec
 	return eventBroadcaster.NewRecorder(scheme.Scheme, v1.EventSource{Component: name})
 }
 
+// versionsInUse returns a function listing the versions of Aerospike that
+// existing aerospike clusters are running or being upgraded to.
+func versionsInUse(aerospikeClient aerospikeclientset.Interface) versioning.VersionsInUseFunc {
+	return func() ([]versioning.Version, error) {
+		clusters, err := aerospikeClient.AerospikeV1alpha2().AerospikeClusters(metav1.NamespaceAll).List(metav1.ListOptions{})
+		if err != nil {
+			// the custom resource definition has not been registered yet,
+			// so there are no aerospike clusters
+			if kerrors.IsNotFound(err) {
+				return nil, nil
+			}
+			return nil, err
+		}
+		var res []versioning.Version
+		for _, cluster := range clusters.Items {
+			for _, s := range []string{cluster.Spec.Version, cluster.Status.Version} {
+				if v, err := versioning.NewVersionFromString(s); err == nil {
+					res = append(res, v)
+				}
+			}
+		}
+		return res, nil
+	}
+}
+
 func run(stopCh chan struct{}, cfg *restclient.Config, kubeClient *kubernetes.Clientset, aerospikeClient *aerospikeclientset.Clientset) {
 	extsClient, err := extsclientset.NewForConfig(cfg)
 	if err != nil {
@@ -207,6 +276,7 @@ func run(stopCh chan struct{}, cfg *restclient.Config, kubeClient *kubernetes.Cl
 	backupController := controller.NewAerospikeNamespaceBackupController(kubeClient, aerospikeClient, kubeInformerFactory, aerospikeInformerFactory)
 	restoreController := controller.NewAerospikeNamespaceRestoreController(kubeClient, aerospikeClient, kubeInformerFactory, aerospikeInformerFactory)
 	gcController := controller.NewGarbageCollectorController(kubeClient, aerospikeClient, kubeInformerFactory, aerospikeInformerFactory)
+	scheduleController := controller.NewAerospikeNamespaceBackupScheduleController(kubeClient, aerospikeClient, aerospikeInformerFactory)
 
 	// start the shared informer factories
 	go kubeInformerFactory.Start(stopCh)
@@ -214,7 +284,7 @@ func run(stopCh chan struct{}, cfg *restclient.Config, kubeClient *kubernetes.Cl
 
 	// start the controllers
 	var wg sync.WaitGroup
-	controllers := []controller.Controller{clusterController, backupController, restoreController, gcController}
+	controllers := []controller.Controller{clusterController, backupController, restoreController, gcController, scheduleController}
 	for _, c := range controllers {
 		wg.Add(1)
 		go func(c controller.Controller) {
@@ -12,6 +12,8 @@ sedi () {
     (sed --version >/dev/null 2>&1 && sed -i "$@") || sed -i "" "$@"
 }
 
+# BACKUP_STORAGE_TYPE is the type of storage used by the end-to-end test suite to store backups ("gcs" or "pvc").
+BACKUP_STORAGE_TYPE=${BACKUP_STORAGE_TYPE:-gcs}
 # NAMESPACE is the namespace where to deploy the build artifacts.
 NAMESPACE=${NAMESPACE:-aerospike-operator}
 # ROOT_DIR is the absolute path to the root of the repository.
@@ -42,10 +44,16 @@ sedi -e "s|__TMP_DIR__|${TMP_DIR}|" "${TMP_DIR}/"*.yaml
 # Replace the "__PROJECT_ID__" placeholder.
 sedi -e "s|__PROJECT_ID__|${PROJECT_ID}|g" "${TMP_DIR}/"*.yaml
 # Replace the "__BASE64_ENCODED_ADMIN_KEY_JSON__" placeholder.
-BASE64_ENCODED_STORAGE_ADMIN_KEY_JSON="$(base64w < "${STORAGE_ADMIN_KEY_JSON_FILE}")"
+# The credentials are not required when backups are stored in persistent volume claims.
+BASE64_ENCODED_STORAGE_ADMIN_KEY_JSON=""
+if [[ "${BACKUP_STORAGE_TYPE}" != "pvc" ]] || [[ -f "${STORAGE_ADMIN_KEY_JSON_FILE}" ]]; then
+    BASE64_ENCODED_STORAGE_ADMIN_KEY_JSON="$(base64w < "${STORAGE_ADMIN_KEY_JSON_FILE}")"
+fi
 sedi -e "s|__BASE64_ENCODED_STORAGE_ADMIN_KEY_JSON__|${BASE64_ENCODED_STORAGE_ADMIN_KEY_JSON}|g" "${TMP_DIR}/"*.yaml
 # Replace the "__GCS_BUCKET_NAME__" placeholder.
 sedi -e "s|__GCS_BUCKET_NAME__|${GCS_BUCKET_NAME}|g" "${TMP_DIR}/"*.yaml
+# Replace the "__BACKUP_STORAGE_TYPE__" placeholder.
+sedi -e "s|__BACKUP_STORAGE_TYPE__|${BACKUP_STORAGE_TYPE}|g" "${TMP_DIR}/"*.yaml
 # Replace the "__FOCUS__" placeholder.
 sedi -e "s|__FOCUS__|${FOCUS}|g" "${TMP_DIR}/"*.yaml
 # Replace the "__SKIP__" placeholder.
@@ -0,0 +1,54 @@
+/*
+Copyright 2019 The aerospike-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package admission
+
+import (
+	"fmt"
+
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+)
+
+// checkAccess makes sure that the user described by userInfo is allowed to
+// perform the action described by attributes. It is used to prevent users from
+// gaining access to resources in other namespaces through aerospike-operator.
+func (s *ValidatingAdmissionWebhook) checkAccess(userInfo authenticationv1.UserInfo, attributes authorizationv1.ResourceAttributes) error {
+	extra := make(map[string]authorizationv1.ExtraValue, len(userInfo.Extra))
+	for key, value := range userInfo.Extra {
+		extra[key] = authorizationv1.ExtraValue(value)
+	}
+	review, err := s.kubeClient.AuthorizationV1().SubjectAccessReviews().Create(&authorizationv1.SubjectAccessReview{
+		Spec: authorizationv1.SubjectAccessReviewSpec{
+			ResourceAttributes: &attributes,
+			User:               userInfo.Username,
+			Groups:             userInfo.Groups,
+			Extra:              extra,
+			UID:                userInfo.UID,
+		},
+	})
+	if err != nil {
+		return err
+	}
+	if review.Status.Allowed {
+		return nil
+	}
+	resource := attributes.Resource
+	if attributes.Name != "" {
+		resource = fmt.Sprintf("%s %q", resource, attributes.Name)
+	}
+	return fmt.Errorf("user %q cannot %s %s in namespace %q", userInfo.Username, attributes.Verb, resource, attributes.Namespace)
+}
@@ -19,12 +19,18 @@ package admission
 import (
 	"fmt"
 	"reflect"
+	"strings"
 
 	av1beta1 "k8s.io/api/admission/v1beta1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/apis/meta/v1"
 
+	"github.com/travelaudience/aerospike-operator/pkg/apis/aerospike/common"
 	aerospikev1alpha2 "github.com/travelaudience/aerospike-operator/pkg/apis/aerospike/v1alpha2"
+	"github.com/travelaudience/aerospike-operator/pkg/backuprestore"
+	"github.com/travelaudience/aerospike-operator/pkg/backuprestore/compression"
+	"github.com/travelaudience/aerospike-operator/pkg/backuprestore/encryption"
+	"github.com/travelaudience/aerospike-operator/pkg/backuprestore/storage"
 )
 
 func (s *ValidatingAdmissionWebhook) admitAerospikeNamespaceBackup(ar av1beta1.AdmissionReview) *av1beta1.AdmissionResponse {
@@ -51,6 +57,28 @@ func (s *ValidatingAdmissionWebhook) admitAerospikeNamespaceBackup(ar av1beta1.A
 		return admissionResponseFromError(err)
 	}
 
+	// validate the selection of the records to backup
+	if err = validateBackupFilter(&obj.Spec); err != nil {
+		return admissionResponseFromError(err)
+	}
+
+	// make sure that the backup can be split into the requested number of
+	// shards. the size of the cluster may change afterwards, so this is only
+	// checked upon creation.
+	if ar.Request.Operation == av1beta1.Create {
+		aerospikeCluster, err := s.aerospikeClient.AerospikeV1alpha2().AerospikeClusters(obj.Namespace).Get(obj.Spec.Target.Cluster, v1.GetOptions{})
+		if err != nil {
+			return admissionResponseFromError(err)
+		}
+		storageSpec := obj.Spec.Storage
+		if storageSpec == nil && aerospikeCluster.Spec.BackupSpec != nil {
+			storageSpec = &aerospikeCluster.Spec.BackupSpec.Storage
+		}
+		if err = validateParallelism(obj.Spec.Parallelism, storageSpec, aerospikeCluster); err != nil {
+			return admissionResponseFromError(err)
+		}
+	}
+
 	// admit the AerospikeNamespaceBackup object
 	return &av1beta1.AdmissionResponse{Allowed: true}
 }
@@ -79,6 +107,34 @@ func (s *ValidatingAdmissionWebhook) admitAerospikeNamespaceRestore(ar av1beta1.
 		}
 	}
 
+	// validate the source of the new AerospikeNamespaceRestore
+	if err = validateRestoreSource(&obj.Spec); err != nil {
+		return admissionResponseFromError(err)
+	}
+
+	// validate the options of the new AerospikeNamespaceRestore
+	if err = validateRestoreOptions(obj.Spec.Options); err != nil {
+		return admissionResponseFromError(err)
+	}
+
+	// determine the backup to restore and, upon creation, make sure that it
+	// exists and that the user creating the restore is allowed to read it
+	backup, err := s.getRestoreSource(obj)
+	if err != nil {
+		return admissionResponseFromError(err)
+	}
+	if ar.Request.Operation == av1beta1.Create {
+		if err = s.validateRestoreSourceAccess(obj, backup, ar.Request.UserInfo); err != nil {
+			return admissionResponseFromError(err)
+		}
+	}
+
+	// restores use the storage of the backup they restore unless otherwise
+	// specified
+	if obj.Spec.Storage == nil && backup != nil {
+		obj.Spec.Storage = backuprestore.GetSourceStorage(backup, obj.Namespace)
+	}
+
 	// validate the new AerospikeNamespaceRestore
 	if err = s.validateBackupRestoreObj(obj); err != nil {
 		return admissionResponseFromError(err)
@@ -96,7 +152,7 @@ func (s *ValidatingAdmissionWebhook) validateBackupRestoreObj(obj aerospikev1alp
 	}
 
 	// make sure that the target namespace exists
-	if !namespaceExists(aerospikeCluster, obj) {
+	if !namespaceExists(aerospikeCluster, obj.GetTarget()) {
 		return fmt.Errorf("cluster %s does not contain a namespace named %s", aerospikeCluster.Name, obj.GetTarget().Namespace)
 	}
 
@@ -113,9 +169,169 @@ func (s *ValidatingAdmissionWebhook) validateBackupRestoreObj(obj aerospikev1alp
 		return fmt.Errorf("must specify .spec.storage")
 	}
 
+	// make sure that the storage can be accessed from the object's namespace
+	return s.validateBackupStorage(storageSpec, obj.GetNamespace())
+}
+
+// validateBackupFilter makes sure that the records to backup are selected in
+// a way supported by asbackup.
+func validateBackupFilter(spec *aerospikev1alpha2.AerospikeNamespaceBackupSpec) error {
+	// the bundled version of asbackup only supports backing up a single set
+	if len(spec.Sets) > 1 {
+		return fmt.Errorf(".spec.sets cannot contain more than one set")
+	}
+	if err := validateNames(".spec.sets", spec.Sets); err != nil {
+		return err
+	}
+	if err := validateNames(".spec.bins", spec.Bins); err != nil {
+		return err
+	}
+	if spec.ModifiedAfter != nil && spec.ModifiedBefore != nil && !spec.ModifiedAfter.Before(spec.ModifiedBefore) {
+		return fmt.Errorf(".spec.modifiedAfter must be before .spec.modifiedBefore")
+	}
+	if spec.IsIncremental() && spec.ModifiedAfter != nil {
+		return fmt.Errorf(".spec.modifiedAfter cannot be specified together with .spec.incremental")
+	}
+	return nil
+}
+
+// validateRestoreSource makes sure that the source of a restore identifies a
+// single backup, either by name, by time or by its path in storage.
+func validateRestoreSource(spec *aerospikev1alpha2.AerospikeNamespaceRestoreSpec) error {
+	source := spec.Source
+	if source == nil {
+		return nil
+	}
+	if source.Name != nil && source.Before != nil {
+		return fmt.Errorf(".spec.source.name and .spec.source.before are mutually exclusive")
+	}
+	if source.Path != nil && (source.Name != nil || source.Before != nil) {
+		return fmt.Errorf(".spec.source.path cannot be specified together with .spec.source.name or .spec.source.before")
+	}
+	if source.Name == nil && source.Before == nil && source.Path == nil {
+		return fmt.Errorf("one of .spec.source.name, .spec.source.before or .spec.source.path must be specified")
+	}
+	if source.Before == nil && (source.Cluster != nil || source.Namespace != nil) {
+		return fmt.Errorf(".spec.source.cluster and .spec.source.namespace can only be specified together with .spec.source.before")
+	}
+	if source.Path != nil {
+		if source.KubernetesNamespace != nil {
+			return fmt.Errorf(".spec.source.kubernetesNamespace cannot be specified together with .spec.source.path")
+		}
+		if spec.Storage == nil {
+			return fmt.Errorf(".spec.storage must be specified together with .spec.source.path")
+		}
+		if err := validateBackupPath(*source.Path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateBackupPath makes sure that path is a valid path of a backup in
+// storage, i.e. a non-empty relative path without "." or ".." elements.
+func validateBackupPath(path string) error {
+	for _, element := range strings.Split(path, "/") {
+		if element == "" || element == "." || element == ".." {
+			return fmt.Errorf("invalid backup path %q", path)
+		}
+	}
+	return nil
+}
+
+// validateRestoreOptions makes sure that options can be translated into a
+// valid combination of asrestore flags.
+func validateRestoreOptions(options *aerospikev1alpha2.RestoreOptions) error {
+	if options == nil {
+		return nil
+	}
+	switch options.GetWritePolicy() {
+	case common.WritePolicyUpdate, common.WritePolicyReplace:
+	case common.WritePolicyUnique:
+		if options.NoGeneration != nil && *options.NoGeneration {
+			return fmt.Errorf(".spec.options.noGeneration cannot be specified together with the %s write policy", common.WritePolicyUnique)
+		}
+	default:
+		return fmt.Errorf("unsupported write policy %q", options.GetWritePolicy())
+	}
+	if err := validateNames(".spec.options.sets", options.Sets); err != nil {
+		return err
+	}
+	if err := validateNames(".spec.options.bins", options.Bins); err != nil {
+		return err
+	}
+	if options.TTLExtension != nil && *options.TTLExtension < 0 {
+		return fmt.Errorf(".spec.options.ttlExtension cannot be negative")
+	}
+	return nil
+}
+
+// validateNames makes sure that the names in the specified list of sets or
+// bins can be passed to asbackup/asrestore as a comma-separated list.
+func validateNames(field string, names []string) error {
+	for _, name := range names {
+		if name == "" || strings.Contains(name, ",") {
+			return fmt.Errorf("%s contains an invalid name %q", field, name)
+		}
+	}
+	return nil
+}
+
+// validateParallelism makes sure that a backup of aerospikeCluster stored as
+// described by storageSpec can be split into the specified number of shards.
+func validateParallelism(parallelism *int32, storageSpec *aerospikev1alpha2.BackupStorageSpec, aerospikeCluster *aerospikev1alpha2.AerospikeCluster) error {
+	if parallelism == nil {
+		return nil
+	}
+	if *parallelism < 1 {
+		return fmt.Errorf(".spec.parallelism must be at least 1")
+	}
+	// every shard is backed up by a separate job, and these cannot all mount
+	// the same persistent volume claim
+	if *parallelism > 1 && storageSpec != nil && storageSpec.Type == common.StorageTypePVC {
+		return fmt.Errorf(".spec.parallelism cannot be greater than 1 when backups are stored in a persistent volume claim")
+	}
+	if *parallelism > aerospikeCluster.Spec.NodeCount {
+		return fmt.Errorf(".spec.parallelism (%d) cannot exceed the number of nodes in cluster %s (%d)", *parallelism, aerospikeCluster.Name, aerospikeCluster.Spec.NodeCount)
+	}
+	return nil
+}
+
+// validateBackupStorage makes sure that the storage described by storageSpec
+// can be accessed by backup/restore jobs running in the specified namespace.
+func (s *ValidatingAdmissionWebhook) validateBackupStorage(storageSpec *aerospikev1alpha2.BackupStorageSpec, namespace string) error {
+	// make sure that the compression settings are valid
+	if err := compression.Validate(storageSpec.GetCompressionCodec(), storageSpec.GetCompressionLevel()); err != nil {
+		return err
+	}
+
+	// make sure that the encryption key, if any, exists in the namespace
+	// where jobs will run
+	if err := s.validateEncryption(storageSpec.Encryption, namespace); err != nil {
+		return err
+	}
+
+	// make sure that the persistent volume claim where backups are stored
+	// exists in the namespace where jobs will run
+	if storageSpec.Type == common.StorageTypePVC {
+		if err := storage.Validate(storageSpec, nil); err != nil {
+			return err
+		}
+		if _, err := s.kubeClient.CoreV1().PersistentVolumeClaims(namespace).Get(storageSpec.PVC.ClaimName, v1.GetOptions{}); err != nil {
+			if errors.IsNotFound(err) {
+				return fmt.Errorf("persistentvolumeclaim %q not found in namespace %q", storageSpec.PVC.ClaimName, namespace)
+			}
+			return err
+		}
+		return nil
+	}
+
 	// make sure that the secret containing cloud storage credentials exists and
 	// matches the expected format
-	secretNamespace := storageSpec.GetSecretNamespace(obj.GetNamespace())
+	if storageSpec.GetSecret() == "" {
+		return fmt.Errorf("the name of the secret must be specified")
+	}
+	secretNamespace := storageSpec.GetSecretNamespace(namespace)
 	secret, err := s.kubeClient.CoreV1().Secrets(secretNamespace).Get(storageSpec.GetSecret(), v1.GetOptions{})
 	if err != nil {
 		if errors.IsNotFound(err) {
@@ -127,12 +343,35 @@ func (s *ValidatingAdmissionWebhook) validateBackupRestoreObj(obj aerospikev1alp
 	if _, ok := secret.Data[secretKey]; !ok {
 		return fmt.Errorf("secret %q does not contain expected field %q", secret.Name, secretKey)
 	}
-	return nil
+	// make sure that the storage spec and the credentials are valid for the
+	// requested storage type
+	return storage.Validate(storageSpec, secret.Data[secretKey])
+}
+
+// validateEncryption makes sure that the secret containing the key used to
+// encrypt backup data exists in the specified namespace and contains a valid
+// key.
+func (s *ValidatingAdmissionWebhook) validateEncryption(encryptionSpec *aerospikev1alpha2.EncryptionSpec, namespace string) error {
+	if encryptionSpec == nil {
+		return nil
+	}
+	secret, err := s.kubeClient.CoreV1().Secrets(namespace).Get(encryptionSpec.Secret, v1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return fmt.Errorf("secret %q not found in namespace %q", encryptionSpec.Secret, namespace)
+		}
+		return err
+	}
+	key, ok := secret.Data[encryptionSpec.GetSecretKey()]
+	if !ok {
+		return fmt.Errorf("secret %q does not contain expected field %q", secret.Name, encryptionSpec.GetSecretKey())
+	}
+	return encryption.ValidateKey(key)
 }
 
-func namespaceExists(aerospikeCluster *aerospikev1alpha2.AerospikeCluster, obj aerospikev1alpha2.BackupRestoreObject) bool {
+func namespaceExists(aerospikeCluster *aerospikev1alpha2.AerospikeCluster, target *aerospikev1alpha2.TargetNamespace) bool {
 	for _, ns := range aerospikeCluster.Spec.Namespaces {
-		if ns.Name == obj.GetTarget().Namespace {
+		if ns.Name == target.Namespace {
 			return true
 		}
 	}
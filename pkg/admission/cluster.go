@@ -21,11 +21,15 @@ import (
 	"reflect"
 
 	av1beta1 "k8s.io/api/admission/v1beta1"
-	"k8s.io/apimachinery/pkg/apis/meta/v1"
-
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
+	"github.com/travelaudience/aerospike-operator/pkg/apis/aerospike/common"
 	aerospikev1alpha2 "github.com/travelaudience/aerospike-operator/pkg/apis/aerospike/v1alpha2"
+	"github.com/travelaudience/aerospike-operator/pkg/asconfig"
+	"github.com/travelaudience/aerospike-operator/pkg/images"
+	astime "github.com/travelaudience/aerospike-operator/pkg/utils/time"
 	"github.com/travelaudience/aerospike-operator/pkg/versioning"
 )
 
@@ -55,9 +59,22 @@ const (
 	// backup/restore suffix is appended to the jobs by backups handler. (restore is used for calculation
 	// because it has a greater length)
 	aerospikeNamespaceMaxNameLen = 23
+	// aerospikeClusterMaxNamespaces represents the maximum number of namespaces in an AerospikeCluster.
+	// Raw devices are exposed to the aerospike-server container as /dev/xvda, /dev/xvdb, ..., /dev/xvdz
+	// based on the index of the namespace, so there can be at most 26 of them.
+	aerospikeClusterMaxNamespaces = 26
 	// the default replication factor for an aerospike namespace
 	// https://www.aerospike.com/docs/reference/configuration#replication-factor
 	defaultNamespaceReplicationFactor int32 = 2
+	// writeBlockSizeParameter is the name of the parameter of the storage-engine
+	// subcontext that cannot be changed once data has been written
+	writeBlockSizeParameter = "write-block-size"
+)
+
+var (
+	// featureKeyVersion is the first version of Aerospike Enterprise that
+	// refuses to start without a feature key file
+	featureKeyVersion = versioning.Version{Major: 4, Minor: 6, Patch: 0, Revision: 0}
 )
 
 func (s *ValidatingAdmissionWebhook) admitAerospikeCluster(ar av1beta1.AdmissionReview) *av1beta1.AdmissionResponse {
@@ -103,9 +120,17 @@ func (s *ValidatingAdmissionWebhook) validateAerospikeCluster(aerospikeCluster *
 		return fmt.Errorf("aerospike version %q is not supported", aerospikeCluster.Spec.Version)
 	}
 
-	// enforce the existence of a single namespace per cluster
-	if len(aerospikeCluster.Spec.Namespaces) != 1 {
-		return fmt.Errorf("the number of namespaces in the cluster must be exactly one")
+	// enforce the existence of at least one namespace per cluster, and that
+	// the number of namespaces does not exceed the supported maximum
+	if len(aerospikeCluster.Spec.Namespaces) == 0 {
+		return fmt.Errorf("the cluster must have at least one namespace")
+	}
+	if len(aerospikeCluster.Spec.Namespaces) > aerospikeClusterMaxNamespaces {
+		return fmt.Errorf("the number of namespaces in the cluster cannot exceed %d", aerospikeClusterMaxNamespaces)
+	}
+	// prevent two namespaces with the same name from appearing in the spec
+	if len(namespaceMap(aerospikeCluster)) < len(aerospikeCluster.Spec.Namespaces) {
+		return fmt.Errorf("namespace names must be unique")
 	}
 
 	// validate every namespace's name and that its replication factor
@@ -125,22 +150,46 @@ func (s *ValidatingAdmissionWebhook) validateAerospikeCluster(aerospikeCluster *
 		}
 	}
 
-	// if backupSpec is specified, make sure that the secret containing
-	// cloud storage credentials exists and matches the expected format
+	// validate the racks across which the nodes are spread
+	if err := validateRacks(aerospikeCluster); err != nil {
+		return err
+	}
+
+	// validate the overrides for the aerospike configuration against the
+	// parameters supported by the requested version
+	if err := validateConfig(aerospikeCluster); err != nil {
+		return err
+	}
+
+	// validate the upgrade policy
+	if err := validateUpgradePolicy(aerospikeCluster.Spec.UpgradePolicy); err != nil {
+		return err
+	}
+
+	// make sure that aerospike enterprise is given a feature key when it
+	// requires one, and that the secret holding it exists
+	if err := s.validateFeatureKey(aerospikeCluster); err != nil {
+		return err
+	}
+
+	// validate the users and roles, and make sure that the secrets holding
+	// their credentials exist
+	if err := s.validateSecurity(aerospikeCluster); err != nil {
+		return err
+	}
+
+	// validate the tls configuration, and make sure that the secrets holding
+	// the certificates exist
+	if err := s.validateTLS(aerospikeCluster); err != nil {
+		return err
+	}
+
+	// if backupSpec is specified, make sure that the storage it describes can
+	// be accessed
 	if aerospikeCluster.Spec.BackupSpec != nil {
-		secretNamespace := aerospikeCluster.Spec.BackupSpec.Storage.GetSecretNamespace(aerospikeCluster.Namespace)
-		secretName := aerospikeCluster.Spec.BackupSpec.Storage.GetSecret()
-		secret, err := s.kubeClient.CoreV1().Secrets(secretNamespace).Get(secretName, v1.GetOptions{})
-		if err != nil {
-			if errors.IsNotFound(err) {
-				return fmt.Errorf("secret %q not found in namespace %q", secretName, secretNamespace)
-			}
+		if err := s.validateBackupStorage(&aerospikeCluster.Spec.BackupSpec.Storage, aerospikeCluster.Namespace); err != nil {
 			return err
 		}
-		secretKey := aerospikeCluster.Spec.BackupSpec.Storage.GetSecretKey()
-		if _, ok := secret.Data[secretKey]; !ok {
-			return fmt.Errorf("secret %q does not contain expected field %q", secretName, secretKey)
-		}
 	}
 	return nil
 }
@@ -151,8 +200,12 @@ func (s *ValidatingAdmissionWebhook) validateAerospikeClusterUpdate(old, new *ae
 	if old.Spec.Version != new.Spec.Version {
 		// create a copy of the new spec
 		tmp := new.DeepCopy()
-		// set tmp.Spec.Version to old.Spec.Version
+		// set tmp.Spec.Version, tmp.Spec.UpgradePolicy and tmp.Spec.Images to
+		// their old values, as the upgrade policy and images may be changed
+		// together with the version
 		tmp.Spec.Version = old.Spec.Version
+		tmp.Spec.UpgradePolicy = old.Spec.UpgradePolicy
+		tmp.Spec.Images = old.Spec.Images
 		// check if old.Spec and tmp.Spec differ
 		// if they do, more than just .spec.Version has been been changed
 		// between old and new, and new must be rejected
@@ -160,7 +213,8 @@ func (s *ValidatingAdmissionWebhook) validateAerospikeClusterUpdate(old, new *ae
 			return fmt.Errorf("when changing .spec.version no other changes to .spec can be performed")
 		}
 		// fail if the aerospikecluster resource doesn't contain .spec.backupSpec
-		if new.Spec.BackupSpec == nil {
+		// unless the upgrade policy allows for skipping the pre-upgrade backup
+		if new.Spec.BackupSpec == nil && new.Spec.GetBackupPolicy() != common.UpgradeBackupPolicySkip {
 			return fmt.Errorf("no value for .spec.backupSpec has been specified")
 		}
 	}
@@ -173,6 +227,26 @@ func (s *ValidatingAdmissionWebhook) validateAerospikeClusterUpdate(old, new *ae
 	if err := validateNamespaces(old, new); err != nil {
 		return err
 	}
+	// prevent the racks from being changed, as this would require moving
+	// nodes (and their persistent volumes) to other racks
+	if !reflect.DeepEqual(old.Spec.Racks, new.Spec.Racks) {
+		return fmt.Errorf("cannot change .spec.racks after the cluster has been created")
+	}
+	// prevent security from being enabled or disabled, as well as the admin
+	// user from being replaced, as aerospike-operator would be unable to
+	// access the cluster
+	if (old.Spec.Security == nil) != (new.Spec.Security == nil) {
+		return fmt.Errorf("cannot add or remove .spec.security")
+	}
+	if old.Spec.Security != nil && old.Spec.Security.AdminSecret != new.Spec.Security.AdminSecret {
+		return fmt.Errorf("cannot change .spec.security.adminSecret")
+	}
+	// prevent the tls configuration from being changed, as nodes using
+	// different configurations would be unable to communicate during the
+	// rolling restart
+	if !reflect.DeepEqual(old.Spec.TLS, new.Spec.TLS) {
+		return fmt.Errorf("cannot change .spec.tls")
+	}
 
 	return nil
 }
@@ -196,6 +270,286 @@ func validateVersion(old, new *aerospikev1alpha2.AerospikeCluster) error {
 	if !upgrade.IsValid() {
 		return fmt.Errorf("cannot upgrade from version %v to %v", sourceVersion, targetVersion)
 	}
+	// the data of each node is wiped by some upgrades and re-populated through
+	// migrations, which is not possible for the data held by a single node
+	strategy, err := upgrade.GetStrategy()
+	if err != nil {
+		return err
+	}
+	if strategy.RecreatePersistentVolumeClaims && new.Spec.HasUnreplicatedData() {
+		return fmt.Errorf("cannot upgrade from version %v to %v in-place as the data of each node is wiped and the cluster has a single node or a namespace with a replication factor of 1", sourceVersion, targetVersion)
+	}
+	return nil
+}
+
+// validateRacks makes sure that the racks in the spec of aerospikeCluster
+// have distinct ids and select kubernetes nodes, that every rack has at least
+// one node, and that every namespace is replicated across racks.
+func validateRacks(aerospikeCluster *aerospikev1alpha2.AerospikeCluster) error {
+	racks := aerospikeCluster.Spec.Racks
+	if len(racks) == 0 {
+		return nil
+	}
+	ids := make(map[int32]bool, len(racks))
+	for _, rack := range racks {
+		if ids[rack.ID] {
+			return fmt.Errorf("rack ids must be unique")
+		}
+		ids[rack.ID] = true
+		if rack.Zone == nil && len(rack.NodeLabels) == 0 {
+			return fmt.Errorf("either the zone or the node labels of rack %d must be specified", rack.ID)
+		}
+	}
+	if int32(len(racks)) > aerospikeCluster.Spec.NodeCount {
+		return fmt.Errorf("%d racks requested but the cluster has only %d nodes", len(racks), aerospikeCluster.Spec.NodeCount)
+	}
+	// a single rack only constrains where the nodes run
+	if len(racks) == 1 {
+		return nil
+	}
+	for _, ns := range aerospikeCluster.Spec.Namespaces {
+		replicationFactor := defaultNamespaceReplicationFactor
+		if ns.ReplicationFactor != nil {
+			replicationFactor = *ns.ReplicationFactor
+		}
+		if replicationFactor < 2 {
+			return fmt.Errorf("namespace %s must have a replication factor of at least 2 in order to be replicated across racks", ns.Name)
+		}
+	}
+	return nil
+}
+
+// isEnterprise returns whether aerospikeCluster runs Aerospike Enterprise, as
+// well as the version of Aerospike it runs.
+func isEnterprise(aerospikeCluster *aerospikev1alpha2.AerospikeCluster) (bool, versioning.Version, error) {
+	version, err := versioning.NewVersionFromString(aerospikeCluster.Spec.Version)
+	if err != nil {
+		return false, versioning.Version{}, err
+	}
+	return images.IsEnterprise(images.Server(version, aerospikeCluster.Spec.Images)), version, nil
+}
+
+// requireEnterprise makes sure that aerospikeCluster runs Aerospike
+// Enterprise, as required by the specified field of its spec.
+func requireEnterprise(aerospikeCluster *aerospikev1alpha2.AerospikeCluster, field string) error {
+	enterprise, _, err := isEnterprise(aerospikeCluster)
+	if err != nil {
+		return err
+	}
+	if !enterprise {
+		return fmt.Errorf("%s requires an aerospike enterprise image", field)
+	}
+	return nil
+}
+
+// validateFeatureKey makes sure that aerospikeCluster is given a feature key
+// file if it runs a version of Aerospike Enterprise that requires one, that
+// it is only given one when running Aerospike Enterprise, and that the secret
+// holding it exists.
+func (s *ValidatingAdmissionWebhook) validateFeatureKey(aerospikeCluster *aerospikev1alpha2.AerospikeCluster) error {
+	enterprise, version, err := isEnterprise(aerospikeCluster)
+	if err != nil {
+		return err
+	}
+	secretName := aerospikeCluster.Spec.FeatureKeySecret
+	if secretName == nil {
+		if enterprise && version.Compare(featureKeyVersion) >= 0 {
+			return fmt.Errorf(".spec.featureKeySecret is required by aerospike enterprise %s and later", featureKeyVersion)
+		}
+		return nil
+	}
+	if !enterprise {
+		return fmt.Errorf(".spec.featureKeySecret requires an aerospike enterprise image")
+	}
+	_, err = s.getSecretField(aerospikeCluster.Namespace, *secretName, common.FeatureKeyFilename)
+	return err
+}
+
+// validateSecurity makes sure that aerospikeCluster runs Aerospike Enterprise,
+// that the users and roles in its security configuration have unique names,
+// that users are only granted existing roles, that privileges are only
+// restricted to namespaces and sets when supported, and that the secrets
+// holding the credentials of the users exist.
+func (s *ValidatingAdmissionWebhook) validateSecurity(aerospikeCluster *aerospikev1alpha2.AerospikeCluster) error {
+	security := aerospikeCluster.Spec.Security
+	if security == nil {
+		return nil
+	}
+	if err := requireEnterprise(aerospikeCluster, ".spec.security"); err != nil {
+		return err
+	}
+	admin, err := s.getSecretField(aerospikeCluster.Namespace, security.AdminSecret, corev1.BasicAuthUsernameKey)
+	if err != nil {
+		return err
+	}
+	if _, err := s.getSecretField(aerospikeCluster.Namespace, security.AdminSecret, corev1.BasicAuthPasswordKey); err != nil {
+		return err
+	}
+
+	// predefined roles are named after the privileges they grant
+	roles := map[string]bool{
+		common.PrivilegeUserAdmin:    true,
+		common.PrivilegeSysAdmin:     true,
+		common.PrivilegeDataAdmin:    true,
+		common.PrivilegeRead:         true,
+		common.PrivilegeReadWrite:    true,
+		common.PrivilegeReadWriteUDF: true,
+	}
+	for _, role := range security.Roles {
+		if roles[role.Name] {
+			return fmt.Errorf("role names must be unique and different from the names of predefined roles")
+		}
+		roles[role.Name] = true
+		for _, privilege := range role.Privileges {
+			switch privilege.Code {
+			case common.PrivilegeRead, common.PrivilegeReadWrite, common.PrivilegeReadWriteUDF:
+			default:
+				if privilege.Namespace != nil {
+					return fmt.Errorf("the %s privilege granted by role %s cannot be restricted to a namespace", privilege.Code, role.Name)
+				}
+			}
+			if privilege.Set != nil && privilege.Namespace == nil {
+				return fmt.Errorf("the %s privilege granted by role %s cannot be restricted to a set without a namespace", privilege.Code, role.Name)
+			}
+		}
+	}
+
+	users := make(map[string]bool, len(security.Users))
+	for _, user := range security.Users {
+		if users[user.Name] || user.Name == string(admin) {
+			return fmt.Errorf("user names must be unique and different from the name of the admin user")
+		}
+		users[user.Name] = true
+		for _, role := range user.Roles {
+			if !roles[role] {
+				return fmt.Errorf("role %s granted to user %s does not exist", role, user.Name)
+			}
+		}
+		if _, err := s.getSecretField(aerospikeCluster.Namespace, user.PasswordSecret, corev1.BasicAuthPasswordKey); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateTLS makes sure that aerospikeCluster runs Aerospike Enterprise, that
+// the listeners in its tls configuration that share a tls name use the same
+// secret, and that the secrets exist and hold the certificates and keys
+// required by each listener.
+func (s *ValidatingAdmissionWebhook) validateTLS(aerospikeCluster *aerospikev1alpha2.AerospikeCluster) error {
+	tls := aerospikeCluster.Spec.TLS
+	if tls == nil {
+		return nil
+	}
+	if err := requireEnterprise(aerospikeCluster, ".spec.tls"); err != nil {
+		return err
+	}
+	// the fabric and heartbeat listeners verify the certificates of other
+	// nodes, which requires the ca certificate
+	listeners := []struct {
+		spec       *aerospikev1alpha2.AerospikeTLSListenerSpec
+		requiresCA bool
+	}{
+		{tls.Service, false},
+		{tls.Fabric, true},
+		{tls.Heartbeat, true},
+	}
+	secrets := make(map[string]string, len(listeners))
+	for _, listener := range listeners {
+		if listener.spec == nil {
+			continue
+		}
+		if secretName, ok := secrets[listener.spec.TLSName]; ok && secretName != listener.spec.SecretName {
+			return fmt.Errorf("listeners with the same tls name must use the same secret")
+		}
+		secrets[listener.spec.TLSName] = listener.spec.SecretName
+		fields := []string{corev1.TLSCertKey, corev1.TLSPrivateKeyKey}
+		if listener.requiresCA {
+			fields = append(fields, common.TLSCACertFilename)
+		}
+		for _, field := range fields {
+			if _, err := s.getSecretField(aerospikeCluster.Namespace, listener.spec.SecretName, field); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// getSecretField returns the value of the specified field of the secret with
+// the specified name, which must exist in the specified namespace.
+func (s *ValidatingAdmissionWebhook) getSecretField(namespace, name, field string) ([]byte, error) {
+	secret, err := s.kubeClient.CoreV1().Secrets(namespace).Get(name, metav1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil, fmt.Errorf("secret %q not found in namespace %q", name, namespace)
+		}
+		return nil, err
+	}
+	value, ok := secret.Data[field]
+	if !ok || len(value) == 0 {
+		return nil, fmt.Errorf("secret %q does not contain expected field %q", name, field)
+	}
+	return value, nil
+}
+
+// validateConfig makes sure that the overrides for the aerospike configuration
+// in the spec of aerospikeCluster are supported by the requested version of
+// aerospike and hold valid values.
+func validateConfig(aerospikeCluster *aerospikev1alpha2.AerospikeCluster) error {
+	version, err := versioning.NewVersionFromString(aerospikeCluster.Spec.Version)
+	if err != nil {
+		return err
+	}
+	if config := aerospikeCluster.Spec.Config; config != nil {
+		if err := asconfig.Validate(version, asconfig.ContextService, config.Service); err != nil {
+			return fmt.Errorf("invalid value for .spec.config.service: %v", err)
+		}
+		if err := asconfig.Validate(version, asconfig.ContextLogging, config.Logging); err != nil {
+			return fmt.Errorf("invalid value for .spec.config.logging: %v", err)
+		}
+		if config.Network != nil {
+			if err := asconfig.Validate(version, asconfig.ContextHeartbeat, config.Network.Heartbeat); err != nil {
+				return fmt.Errorf("invalid value for .spec.config.network.heartbeat: %v", err)
+			}
+			if err := asconfig.Validate(version, asconfig.ContextFabric, config.Network.Fabric); err != nil {
+				return fmt.Errorf("invalid value for .spec.config.network.fabric: %v", err)
+			}
+		}
+	}
+	for _, ns := range aerospikeCluster.Spec.Namespaces {
+		if err := asconfig.Validate(version, asconfig.ContextNamespace, ns.Config); err != nil {
+			return fmt.Errorf("invalid config for namespace %s: %v", ns.Name, err)
+		}
+		if err := asconfig.Validate(version, asconfig.ContextStorageEngine, ns.Storage.Config); err != nil {
+			return fmt.Errorf("invalid storage config for namespace %s: %v", ns.Name, err)
+		}
+	}
+	return nil
+}
+
+// validateUpgradePolicy makes sure that maxBackupAge is a positive duration
+// specified if and only if the backup policy is reuse.
+func validateUpgradePolicy(policy *aerospikev1alpha2.AerospikeClusterUpgradePolicy) error {
+	if policy == nil {
+		return nil
+	}
+	if policy.Backup != common.UpgradeBackupPolicyReuse {
+		if policy.MaxBackupAge != nil {
+			return fmt.Errorf(".spec.upgradePolicy.maxBackupAge can only be specified when the backup policy is %s", common.UpgradeBackupPolicyReuse)
+		}
+		return nil
+	}
+	if policy.MaxBackupAge == nil {
+		return fmt.Errorf(".spec.upgradePolicy.maxBackupAge must be specified when the backup policy is %s", common.UpgradeBackupPolicyReuse)
+	}
+	maxAge, err := astime.ParseDuration(*policy.MaxBackupAge)
+	if err != nil {
+		return fmt.Errorf("invalid value for .spec.upgradePolicy.maxBackupAge: %v", err)
+	}
+	if maxAge <= 0 {
+		return fmt.Errorf(".spec.upgradePolicy.maxBackupAge must be positive")
+	}
 	return nil
 }
 
@@ -224,10 +578,17 @@ func validateNamespaces(old, new *aerospikev1alpha2.AerospikeCluster) error {
 		if oldnss[name].ReplicationFactor != nil && newnss[name].ReplicationFactor != nil && *oldnss[name].ReplicationFactor != *newnss[name].ReplicationFactor {
 			return fmt.Errorf("cannot change the replication factor for namespace %s", name)
 		}
-		// make sure that the storage spec hasn't been changed
-		if !reflect.DeepEqual(oldnss[name].Storage, newnss[name].Storage) {
+		// make sure that the storage spec hasn't been changed, except for the
+		// overrides for the storage-engine subcontext (of which only the write
+		// block size, which determines the layout of the data, cannot change)
+		oldStorage, newStorage := oldnss[name].Storage, newnss[name].Storage
+		oldStorage.Config, newStorage.Config = nil, nil
+		if !reflect.DeepEqual(oldStorage, newStorage) {
 			return fmt.Errorf("cannot change the storage spec for namespace %s", name)
 		}
+		if oldnss[name].Storage.Config[writeBlockSizeParameter] != newnss[name].Storage.Config[writeBlockSizeParameter] {
+			return fmt.Errorf("cannot change %s for namespace %s", writeBlockSizeParameter, name)
+		}
 	}
 	return nil
 }
@@ -0,0 +1,167 @@
+/*
+Copyright 2019 The aerospike-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package admission
+
+import (
+	"fmt"
+	"time"
+
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/travelaudience/aerospike-operator/pkg/apis/aerospike"
+	"github.com/travelaudience/aerospike-operator/pkg/apis/aerospike/common"
+	aerospikev1alpha2 "github.com/travelaudience/aerospike-operator/pkg/apis/aerospike/v1alpha2"
+	"github.com/travelaudience/aerospike-operator/pkg/backuprestore"
+	"github.com/travelaudience/aerospike-operator/pkg/backuprestore/storage"
+	"github.com/travelaudience/aerospike-operator/pkg/crd"
+)
+
+// getRestoreSource returns the AerospikeNamespaceBackup resource restored by
+// obj, or nil if obj restores a backup from a path in storage or if the
+// resource does not exist.
+func (s *ValidatingAdmissionWebhook) getRestoreSource(obj *aerospikev1alpha2.AerospikeNamespaceRestore) (*aerospikev1alpha2.AerospikeNamespaceBackup, error) {
+	source := obj.Spec.Source
+	if source != nil && source.Path != nil {
+		return nil, nil
+	}
+	backups := s.aerospikeClient.AerospikeV1alpha2().AerospikeNamespaceBackups(obj.GetBackupNamespace())
+	// look for the latest successful backup created before the specified time
+	// unless the backup to restore has already been determined
+	if obj.Status.BackupName == "" && source != nil && source.Before != nil {
+		list, err := backups.List(v1.ListOptions{})
+		if err != nil {
+			return nil, err
+		}
+		return backuprestore.FindLatestBackup(list.Items, source.GetSourceTarget(obj.Spec.Target), source.Before.Time), nil
+	}
+	backup, err := backups.Get(obj.GetBackupName(), v1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return backup, nil
+}
+
+// validateRestoreSourceAccess makes sure that the backup restored by obj (as
+// returned by getRestoreSource) exists and that the user described by userInfo
+// is allowed to read it, as well as the credentials used to access it.
+func (s *ValidatingAdmissionWebhook) validateRestoreSourceAccess(obj *aerospikev1alpha2.AerospikeNamespaceRestore, backup *aerospikev1alpha2.AerospikeNamespaceBackup, userInfo authenticationv1.UserInfo) error {
+	source := obj.Spec.Source
+	backupNamespace := obj.GetBackupNamespace()
+
+	// backups in the namespace of the restore may have been created by another
+	// instance of aerospike-operator, so their resources are not required to
+	// exist. backups in other namespaces must exist and be readable by the user.
+	if backupNamespace != obj.Namespace {
+		if source != nil && source.Before != nil {
+			if err := s.checkAccess(userInfo, backupsResourceAttributes("list", backupNamespace, "")); err != nil {
+				return err
+			}
+			if backup == nil {
+				target := source.GetSourceTarget(obj.Spec.Target)
+				return fmt.Errorf("no successful backup of %s/%s created before %s was found in namespace %q",
+					target.Cluster, target.Namespace, source.Before.UTC().Format(time.RFC3339), backupNamespace)
+			}
+		} else {
+			if err := s.checkAccess(userInfo, backupsResourceAttributes("get", backupNamespace, obj.GetBackupName())); err != nil {
+				return err
+			}
+			if backup == nil {
+				return fmt.Errorf("aerospikenamespacebackup %q not found in namespace %q", obj.GetBackupName(), backupNamespace)
+			}
+		}
+	}
+
+	storageSpec := obj.Spec.Storage
+	if storageSpec == nil && backup != nil {
+		storageSpec = backuprestore.GetSourceStorage(backup, obj.Namespace)
+		// persistent volume claims can only be mounted by jobs running in
+		// their own namespace
+		if storageSpec != nil && storageSpec.Type == common.StorageTypePVC && backup.Namespace != obj.Namespace {
+			return fmt.Errorf("backup %q is stored in a persistentvolumeclaim in namespace %q and can only be restored from another namespace if .spec.storage is specified", backup.Name, backup.Namespace)
+		}
+	}
+	if storageSpec == nil || storageSpec.Type == common.StorageTypePVC {
+		return nil
+	}
+
+	// the secret containing the credentials to access cloud storage is copied
+	// to the namespace of the restore, so the user must be allowed to read it
+	if secretNamespace := storageSpec.GetSecretNamespace(obj.Namespace); secretNamespace != obj.Namespace {
+		if err := s.checkAccess(userInfo, authorizationv1.ResourceAttributes{
+			Namespace: secretNamespace,
+			Verb:      "get",
+			Resource:  "secrets",
+			Name:      storageSpec.GetSecret(),
+		}); err != nil {
+			return err
+		}
+	}
+
+	// make sure that a backup exists at the specified path
+	if source != nil && source.Path != nil {
+		return s.validateBackupPathExists(storageSpec, obj.Namespace, *source.Path)
+	}
+	return nil
+}
+
+// validateBackupPathExists makes sure that the metadata of a (possibly sharded)
+// backup exists at the specified path in cloud storage.
+func (s *ValidatingAdmissionWebhook) validateBackupPathExists(storageSpec *aerospikev1alpha2.BackupStorageSpec, namespace, path string) error {
+	secretNamespace := storageSpec.GetSecretNamespace(namespace)
+	secret, err := s.kubeClient.CoreV1().Secrets(secretNamespace).Get(storageSpec.GetSecret(), v1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return fmt.Errorf("secret %q not found in namespace %q", storageSpec.GetSecret(), secretNamespace)
+		}
+		return err
+	}
+	client, err := storage.NewClient(storageSpec, secret.Data[storageSpec.GetSecretKey()])
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	// the backup may have been split into shards, in which case the metadata
+	// of the first shard is looked for instead
+	var lastErr error
+	for _, name := range []string{path, backuprestore.GetShardName(path, 0)} {
+		r, err := client.NewReader(backuprestore.GetMetadataObjectName(name))
+		if err == nil {
+			return r.Close()
+		}
+		lastErr = err
+	}
+	return fmt.Errorf("no backup found at path %q in bucket %q: %v", path, storageSpec.Bucket, lastErr)
+}
+
+// backupsResourceAttributes returns the attributes describing the specified
+// action on AerospikeNamespaceBackup resources.
+func backupsResourceAttributes(verb, namespace, name string) authorizationv1.ResourceAttributes {
+	return authorizationv1.ResourceAttributes{
+		Namespace: namespace,
+		Verb:      verb,
+		Group:     aerospike.GroupName,
+		Resource:  crd.AerospikeNamespaceBackupPlural,
+		Name:      name,
+	}
+}
@@ -0,0 +1,94 @@
+/*
+Copyright 2019 The aerospike-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package admission
+
+import (
+	"fmt"
+
+	av1beta1 "k8s.io/api/admission/v1beta1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	aerospikev1alpha2 "github.com/travelaudience/aerospike-operator/pkg/apis/aerospike/v1alpha2"
+	"github.com/travelaudience/aerospike-operator/pkg/utils/cron"
+)
+
+func (s *ValidatingAdmissionWebhook) admitAerospikeNamespaceBackupSchedule(ar av1beta1.AdmissionReview) *av1beta1.AdmissionResponse {
+	// decode the new AerospikeNamespaceBackupSchedule object
+	obj, err := decodeAerospikeNamespaceBackupSchedule(ar.Request.Object.Raw)
+	if err != nil {
+		return admissionResponseFromError(err)
+	}
+
+	// validate the new AerospikeNamespaceBackupSchedule
+	if err = s.validateAerospikeNamespaceBackupSchedule(obj); err != nil {
+		return admissionResponseFromError(err)
+	}
+
+	// admit the AerospikeNamespaceBackupSchedule object
+	return &av1beta1.AdmissionResponse{Allowed: true}
+}
+
+func (s *ValidatingAdmissionWebhook) validateAerospikeNamespaceBackupSchedule(obj *aerospikev1alpha2.AerospikeNamespaceBackupSchedule) error {
+	// make sure that the schedule is a valid cron expression
+	if _, err := cron.Parse(obj.Spec.Schedule); err != nil {
+		return fmt.Errorf("invalid schedule: %v", err)
+	}
+
+	// make sure that the target cluster exists
+	aerospikeCluster, err := s.aerospikeClient.AerospikeV1alpha2().AerospikeClusters(obj.Namespace).Get(obj.Spec.Target.Cluster, v1.GetOptions{})
+	if err != nil {
+		return err
+	}
+
+	// make sure that the target namespace exists
+	if !namespaceExists(aerospikeCluster, &obj.Spec.Target) {
+		return fmt.Errorf("cluster %s does not contain a namespace named %s", aerospikeCluster.Name, obj.Spec.Target.Namespace)
+	}
+
+	// check if the schedule contains a BackupStorageSpec and use it. if not
+	// try to get it from the cluster. If the later does not contain it, return
+	// an error
+	var storageSpec *aerospikev1alpha2.BackupStorageSpec
+	switch {
+	case obj.Spec.Storage != nil:
+		storageSpec = obj.Spec.Storage
+	case aerospikeCluster.Spec.BackupSpec != nil:
+		storageSpec = &aerospikeCluster.Spec.BackupSpec.Storage
+	default:
+		return fmt.Errorf("must specify .spec.storage")
+	}
+
+	// make sure that backups can be split into the requested number of shards
+	if err := validateParallelism(obj.Spec.Parallelism, storageSpec, aerospikeCluster); err != nil {
+		return err
+	}
+
+	// make sure that the storage can be accessed from the schedule's namespace
+	return s.validateBackupStorage(storageSpec, obj.Namespace)
+}
+
+func decodeAerospikeNamespaceBackupSchedule(raw []byte) (*aerospikev1alpha2.AerospikeNamespaceBackupSchedule, error) {
+	obj := &aerospikev1alpha2.AerospikeNamespaceBackupSchedule{}
+	if len(raw) == 0 {
+		return obj, nil
+	}
+	_, _, err := codecs.UniversalDeserializer().Decode(raw, nil, obj)
+	if err != nil {
+		return nil, err
+	}
+	return obj, nil
+}
@@ -61,11 +61,12 @@ var (
 	scheme = runtime.NewScheme()
 	codecs = serializer.NewCodecFactory(scheme)
 
-	aerospikeOperatorWebhookName         = fmt.Sprintf("aerospike-operator.%s", aerospike.GroupName)
-	aerospikeClusterWebhookPath          = "/admission/reviews/aerospikeclusters"
-	aerospikeNamespaceBackupWebhookPath  = "/admission/reviews/aerospikenamespacebackups"
-	aerospikeNamespaceRestoreWebhookPath = "/admission/reviews/aerospikenamespacerestores"
-	healthzPath                          = "/healthz"
+	aerospikeOperatorWebhookName                = fmt.Sprintf("aerospike-operator.%s", aerospike.GroupName)
+	aerospikeClusterWebhookPath                 = "/admission/reviews/aerospikeclusters"
+	aerospikeNamespaceBackupWebhookPath         = "/admission/reviews/aerospikenamespacebackups"
+	aerospikeNamespaceRestoreWebhookPath        = "/admission/reviews/aerospikenamespacerestores"
+	aerospikeNamespaceBackupScheduleWebhookPath = "/admission/reviews/aerospikenamespacebackupschedules"
+	healthzPath                                 = "/healthz"
 
 	failurePolicy = admissionregistrationv1beta1.Fail
 )
@@ -137,6 +138,7 @@ func (s *ValidatingAdmissionWebhook) Run(stopCh chan struct{}) {
 	mux.HandleFunc(aerospikeClusterWebhookPath, s.handleAerospikeCluster)
 	mux.HandleFunc(aerospikeNamespaceBackupWebhookPath, s.handleAerospikeNamespaceBackup)
 	mux.HandleFunc(aerospikeNamespaceRestoreWebhookPath, s.handleAerospikeNamespaceRestore)
+	mux.HandleFunc(aerospikeNamespaceBackupScheduleWebhookPath, s.handleAerospikeNamespaceBackupSchedule)
 	mux.HandleFunc(healthzPath, handleHealthz)
 	srv := http.Server{
 		Addr:    fmt.Sprintf(":%d", 8443),
@@ -174,6 +176,10 @@ func (s *ValidatingAdmissionWebhook) handleAerospikeNamespaceRestore(res http.Re
 	handle(res, req, s.admitAerospikeNamespaceRestore)
 }
 
+func (s *ValidatingAdmissionWebhook) handleAerospikeNamespaceBackupSchedule(res http.ResponseWriter, req *http.Request) {
+	handle(res, req, s.admitAerospikeNamespaceBackupSchedule)
+}
+
 // ensureTLSSecret generates a certificate and private key to be used for registering and serving the webhook, and
 // creates a kubernetes secret containing them so they can be used by all running instances of aerospike-operator.
 // in case such secret already exists, it is read and returned.
@@ -339,6 +345,35 @@ func (s *ValidatingAdmissionWebhook) ensureWebhookConfig(caBundle []byte) error
 				},
 				FailurePolicy: &failurePolicy,
 			},
+			{
+				Name: crd.AerospikeNamespaceBackupScheduleCRDName,
+				Rules: []admissionregistrationv1beta1.RuleWithOperations{
+					{
+						Operations: []admissionregistrationv1beta1.OperationType{
+							admissionregistrationv1beta1.Create,
+							admissionregistrationv1beta1.Update,
+						},
+						Rule: admissionregistrationv1beta1.Rule{
+							APIGroups: []string{
+								aerospikev1alpha2.SchemeGroupVersion.Group,
+							},
+							APIVersions: []string{
+								aerospikev1alpha2.SchemeGroupVersion.Version,
+							},
+							Resources: []string{crd.AerospikeNamespaceBackupSchedulePlural},
+						},
+					},
+				},
+				ClientConfig: admissionregistrationv1beta1.WebhookClientConfig{
+					Service: &admissionregistrationv1beta1.ServiceReference{
+						Name:      serviceName,
+						Namespace: s.namespace,
+						Path:      &aerospikeNamespaceBackupScheduleWebhookPath,
+					},
+					CABundle: caBundle,
+				},
+				FailurePolicy: &failurePolicy,
+			},
 		},
 	}
 
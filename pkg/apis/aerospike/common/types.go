@@ -30,6 +30,55 @@ const (
 	// StorageTypeGCS defines the Google Cloud Storage type for a given Aerospike backup.
 	StorageTypeGCS = "gcs"
 
+	// StorageTypeS3 defines the S3-compatible storage type for a given Aerospike backup.
+	StorageTypeS3 = "s3"
+
+	// StorageTypeAzure defines the Azure Blob Storage type for a given Aerospike backup.
+	StorageTypeAzure = "azure"
+
+	// StorageTypePVC defines the persistent volume claim storage type for a given Aerospike backup.
+	StorageTypePVC = "pvc"
+
+	// CompressionCodecNone defines that Aerospike backups are not compressed.
+	CompressionCodecNone = "none"
+
+	// CompressionCodecGzip defines that Aerospike backups are compressed using gzip.
+	CompressionCodecGzip = "gzip"
+
+	// CompressionCodecZstd defines that Aerospike backups are compressed using zstd.
+	CompressionCodecZstd = "zstd"
+
+	// WritePolicyUpdate defines that restored records update the bins of the records that already exist.
+	WritePolicyUpdate = "update"
+
+	// WritePolicyUnique defines that restored records that already exist are skipped.
+	WritePolicyUnique = "unique"
+
+	// WritePolicyReplace defines that restored records fully replace the records that already exist.
+	WritePolicyReplace = "replace"
+
+	// UpgradeBackupPolicyRequired defines that every Aerospike namespace is backed up before upgrading an Aerospike cluster.
+	UpgradeBackupPolicyRequired = "required"
+
+	// UpgradeBackupPolicySkip defines that Aerospike clusters are upgraded without backing up their Aerospike namespaces.
+	UpgradeBackupPolicySkip = "skip"
+
+	// UpgradeBackupPolicyReuse defines that recent backups of the Aerospike namespaces of an Aerospike cluster are reused
+	// instead of backing them up before upgrading.
+	UpgradeBackupPolicyReuse = "reuse"
+
+	// ShardStatePending defines that the job backing up a shard of an Aerospike backup has not been created yet.
+	ShardStatePending = "Pending"
+
+	// ShardStateRunning defines that the job backing up a shard of an Aerospike backup is running.
+	ShardStateRunning = "Running"
+
+	// ShardStateFinished defines that the job backing up a shard of an Aerospike backup has finished.
+	ShardStateFinished = "Finished"
+
+	// ShardStateFailed defines that the job backing up a shard of an Aerospike backup has failed.
+	ShardStateFailed = "Failed"
+
 	// ConditionBackupFailed defines a status condition that indicates that a backup job has failed
 	ConditionBackupFailed apiextensions.CustomResourceDefinitionConditionType = "BackupFailed"
 
@@ -60,6 +109,10 @@ const (
 	// Aerospike cluster has failed
 	ConditionUpgradeFailed apiextensions.CustomResourceDefinitionConditionType = "UpgradeFailed"
 
+	// ConditionUpgradeRetried defines a status condition that indicates that a failed upgrade to an
+	// Aerospike cluster is being retried
+	ConditionUpgradeRetried apiextensions.CustomResourceDefinitionConditionType = "UpgradeRetried"
+
 	// ConditionAutoBackupStarted defines a status condition that indicates that a pre-upgrade
 	// backup for an Aerospike cluster has started
 	ConditionAutoBackupStarted apiextensions.CustomResourceDefinitionConditionType = "AutoBackupStarted"
@@ -75,6 +128,28 @@ const (
 	// DefaultSecretFilename represents the name of the file that is required to exist
 	// in the secret referenced in BackupStorageSpec objects.
 	DefaultSecretFilename = "key.json"
+	// DefaultEncryptionKeyFilename represents the name of the file that is required to exist
+	// in the secret referenced in EncryptionSpec objects.
+	DefaultEncryptionKeyFilename = "encryption.key"
+	// TLSCACertFilename represents the name of the file holding the CA certificate in the tls secrets referenced in
+	// AerospikeTLSListenerSpec objects, as used by cert-manager.
+	TLSCACertFilename = "ca.crt"
+	// FeatureKeyFilename represents the name of the file holding the feature key of Aerospike Enterprise in the secret
+	// referenced by the featureKeySecret field of AerospikeClusterSpec objects.
+	FeatureKeyFilename = "features.conf"
+
+	// PrivilegeUserAdmin defines the privilege to manage users and roles.
+	PrivilegeUserAdmin = "user-admin"
+	// PrivilegeSysAdmin defines the privilege to manage the server configuration, indexes and user defined functions.
+	PrivilegeSysAdmin = "sys-admin"
+	// PrivilegeDataAdmin defines the privilege to manage indexes and user defined functions.
+	PrivilegeDataAdmin = "data-admin"
+	// PrivilegeRead defines the privilege to read data.
+	PrivilegeRead = "read"
+	// PrivilegeReadWrite defines the privilege to read and write data.
+	PrivilegeReadWrite = "read-write"
+	// PrivilegeReadWriteUDF defines the privilege to read and write data, including through user defined functions.
+	PrivilegeReadWriteUDF = "read-write-udf"
 )
 
 // OperationType represents the type used to indicate whether a
@@ -85,7 +160,8 @@ const (
 	OperationTypeBackup  OperationType = "backup"
 	OperationTypeRestore OperationType = "restore"
 
-	AerospikeClusterKind          = "AerospikeCluster"
-	AerospikeNamespaceBackupKind  = "AerospikeNamespaceBackup"
-	AerospikeNamespaceRestoreKind = "AerospikeNamespaceRestore"
+	AerospikeClusterKind                 = "AerospikeCluster"
+	AerospikeNamespaceBackupKind         = "AerospikeNamespaceBackup"
+	AerospikeNamespaceRestoreKind        = "AerospikeNamespaceRestore"
+	AerospikeNamespaceBackupScheduleKind = "AerospikeNamespaceBackupSchedule"
 )
@@ -52,6 +52,43 @@ type AerospikeNamespaceBackupSpec struct {
 	// Defaults to 0d, meaning the backup data will be kept forever.
 	// +optional
 	TTL *string `json:"ttl,omitempty"`
+	// The number of shards into which to split the backup, each of which is backed up in parallel by a separate job.
+	// Shards are made by splitting the nodes of the Aerospike cluster, so this must not exceed the size of the cluster.
+	// Defaults to 1, meaning the backup is not split.
+	// +optional
+	Parallelism *int32 `json:"parallelism,omitempty"`
+	// The sets to backup.
+	// Defaults to all sets.
+	// +optional
+	Sets []string `json:"sets,omitempty"`
+	// The bins to backup.
+	// Defaults to all bins.
+	// +optional
+	Bins []string `json:"bins,omitempty"`
+	// Only backup the records last modified after the specified time.
+	// +optional
+	ModifiedAfter *metav1.Time `json:"modifiedAfter,omitempty"`
+	// Only backup the records last modified before the specified time.
+	// +optional
+	ModifiedBefore *metav1.Time `json:"modifiedBefore,omitempty"`
+	// Whether to only backup the records modified since the latest successful backup of the same Aerospike namespace
+	// (the base backup), which is restored together with this backup.
+	// A full backup is made if there is no such backup.
+	// +optional
+	Incremental *bool `json:"incremental,omitempty"`
+}
+
+// GetParallelism returns the number of shards into which the backup is split.
+func (s *AerospikeNamespaceBackupSpec) GetParallelism() int {
+	if s.Parallelism != nil && *s.Parallelism > 1 {
+		return int(*s.Parallelism)
+	}
+	return 1
+}
+
+// IsIncremental returns whether the backup only includes the records modified since the latest successful backup.
+func (s *AerospikeNamespaceBackupSpec) IsIncremental() bool {
+	return s.Incremental != nil && *s.Incremental
 }
 
 // TargetNamespace specifies the Aerospike cluster and namespace a single backup or restore operation will target.
@@ -64,18 +101,113 @@ type TargetNamespace struct {
 
 // BackupStorageSpec specifies the configuration for the storage of a backup.
 type BackupStorageSpec struct {
-	// The type of cloud storage to use for the backup (e.g., gcs).
+	// The type of storage to use for the backup (e.g., gcs, s3, azure, pvc).
 	Type string `json:"type"`
-	// The name of the bucket where the backup is stored.
-	Bucket string `json:"bucket"`
+	// The name of the bucket (or container, in the case of azure) where the backup is stored.
+	// In the case of pvc, the (optional) name of the directory in the volume where the backup is stored.
+	Bucket string `json:"bucket,omitempty"`
 	// The name of the secret containing credentials to access the bucket.
-	Secret string `json:"secret"`
+	// Not used when type is pvc.
+	Secret string `json:"secret,omitempty"`
 	// The namespace to which the secret containing the credentials belongs to.
 	// +optional
 	SecretNamespace *string `json:"secretNamespace,omitempty"`
 	// The name of the file in which the credentials are stored.
 	// +optional
 	SecretKey *string `json:"secretKey,omitempty"`
+	// The configuration specific to S3-compatible storage.
+	// Only used when type is s3.
+	// +optional
+	S3 *S3StorageSpec `json:"s3,omitempty"`
+	// The configuration specific to Azure Blob Storage.
+	// Required when type is azure.
+	// +optional
+	Azure *AzureStorageSpec `json:"azure,omitempty"`
+	// The configuration specific to persistent volume claims.
+	// Required when type is pvc.
+	// +optional
+	PVC *PVCStorageSpec `json:"pvc,omitempty"`
+	// The configuration for the client-side encryption of the backup data.
+	// +optional
+	Encryption *EncryptionSpec `json:"encryption,omitempty"`
+	// The configuration for the compression of the backup data.
+	// Ignored when restoring, in which case the codec used to create the backup is used.
+	// +optional
+	Compression *CompressionSpec `json:"compression,omitempty"`
+}
+
+// S3StorageSpec specifies the configuration for the storage of a backup in an S3-compatible service.
+type S3StorageSpec struct {
+	// The URL of the S3-compatible service (e.g., http://minio.minio:9000).
+	// Defaults to the AWS S3 endpoint for the specified region.
+	// +optional
+	Endpoint *string `json:"endpoint,omitempty"`
+	// The region in which the bucket is located.
+	// Defaults to us-east-1.
+	// +optional
+	Region *string `json:"region,omitempty"`
+	// Whether to use path-style requests (i.e. <endpoint>/<bucket>/<object>) instead of virtual-hosted-style ones.
+	// Path-style requests are usually required by on-premises services such as MinIO.
+	// +optional
+	ForcePathStyle *bool `json:"forcePathStyle,omitempty"`
+}
+
+// AzureStorageSpec specifies the configuration for the storage of a backup in Azure Blob Storage.
+type AzureStorageSpec struct {
+	// The name of the storage account containing the container.
+	StorageAccount string `json:"storageAccount"`
+	// The URL of the Blob service (e.g., http://azurite.azurite:10000/devstoreaccount1).
+	// Defaults to the public Azure endpoint for the storage account.
+	// +optional
+	Endpoint *string `json:"endpoint,omitempty"`
+}
+
+// PVCStorageSpec specifies the configuration for the storage of a backup in a persistent volume claim.
+type PVCStorageSpec struct {
+	// The name of the persistent volume claim where the backup is stored.
+	// The persistent volume claim must exist in the same namespace as the backup/restore resource.
+	ClaimName string `json:"claimName"`
+}
+
+// EncryptionSpec specifies the configuration for the client-side encryption of backup data.
+type EncryptionSpec struct {
+	// The name of the secret containing the 256-bit AES key used to encrypt the backup data.
+	// The secret must exist in the same namespace as the backup/restore resource.
+	Secret string `json:"secret"`
+	// The name of the field in the secret in which the key is stored.
+	// +optional
+	SecretKey *string `json:"secretKey,omitempty"`
+}
+
+// CompressionSpec specifies the configuration for the compression of backup data.
+type CompressionSpec struct {
+	// The codec used to compress the backup data (e.g., none, gzip, zstd).
+	Codec string `json:"codec"`
+	// The compression level.
+	// Defaults to the default level of the codec.
+	// +optional
+	Level *int32 `json:"level,omitempty"`
+}
+
+func (e *EncryptionSpec) GetSecretKey() string {
+	if e.SecretKey != nil {
+		return *e.SecretKey
+	}
+	return common.DefaultEncryptionKeyFilename
+}
+
+func (b *BackupStorageSpec) GetCompressionCodec() string {
+	if b.Compression != nil {
+		return b.Compression.Codec
+	}
+	return common.CompressionCodecGzip
+}
+
+func (b *BackupStorageSpec) GetCompressionLevel() int {
+	if b.Compression != nil && b.Compression.Level != nil {
+		return int(*b.Compression.Level)
+	}
+	return 0
 }
 
 func (b *BackupStorageSpec) GetSecret() string {
@@ -104,11 +236,42 @@ func (b *BackupStorageSpec) GetSecretNamespace(fallbackNamespace string) string
 type AerospikeNamespaceBackupStatus struct {
 	// The configuration for the backup operation.
 	AerospikeNamespaceBackupSpec
+	// The progress of the backup operation.
+	// +optional
+	Progress *BackupRestoreProgress `json:"progress,omitempty"`
+	// The status of each shard of the backup operation.
+	// Only reported when the backup is split into more than one shard.
+	// +optional
+	Shards []BackupShardStatus `json:"shards,omitempty"`
+	// The name of the backup on top of which the (incremental) backup is made.
+	// Empty if the backup is not incremental or no base backup was found, in which case a full backup is made.
+	// +optional
+	BaseBackup string `json:"baseBackup,omitempty"`
 	// Details about the current condition of the AerospikeNamespaceBackup resource.
 	// +k8s:openapi-gen=false
 	Conditions []apiextensions.CustomResourceDefinitionCondition `json="conditions"`
 }
 
+// BackupShardStatus is the status of a single shard of a backup operation.
+type BackupShardStatus struct {
+	// The index of the shard.
+	Index int32 `json:"index"`
+	// The name of the job backing up the shard.
+	// +optional
+	Job string `json:"job,omitempty"`
+	// The state of the job backing up the shard (i.e., Pending, Running, Finished or Failed).
+	State string `json:"state"`
+	// The addresses (in host:port format) of the nodes backed up by the shard.
+	// +optional
+	Nodes []string `json:"nodes,omitempty"`
+	// The reason for the failure of the job backing up the shard, if any.
+	// +optional
+	Message string `json:"message,omitempty"`
+	// The progress of the job backing up the shard.
+	// +optional
+	Progress *BackupRestoreProgress `json:"progress,omitempty"`
+}
+
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
 
 // AerospikeNamespaceBackupList represents a list of AerospikeNamespaceBackup resources.
@@ -137,6 +300,19 @@ func (b *AerospikeNamespaceBackup) GetNamespace() string {
 	return b.Namespace
 }
 
+// GetBackupName returns the name of the backup.
+func (b *AerospikeNamespaceBackup) GetBackupName() string {
+	return b.Name
+}
+
+func (b *AerospikeNamespaceBackup) GetProgress() *BackupRestoreProgress {
+	return b.Status.Progress
+}
+
+func (b *AerospikeNamespaceBackup) SetProgress(progress *BackupRestoreProgress) {
+	b.Status.Progress = progress
+}
+
 func (b *AerospikeNamespaceBackup) GetObjectMeta() *metav1.ObjectMeta {
 	return &b.ObjectMeta
 }
@@ -187,5 +363,29 @@ func (b *AerospikeNamespaceBackup) SyncStatusWithSpec() bool {
 		b.Status.TTL = b.Spec.TTL
 		mustUpdate = true
 	}
+	if !reflect.DeepEqual(b.Status.Parallelism, b.Spec.Parallelism) {
+		b.Status.Parallelism = b.Spec.Parallelism
+		mustUpdate = true
+	}
+	if !reflect.DeepEqual(b.Status.Sets, b.Spec.Sets) {
+		b.Status.Sets = b.Spec.Sets
+		mustUpdate = true
+	}
+	if !reflect.DeepEqual(b.Status.Bins, b.Spec.Bins) {
+		b.Status.Bins = b.Spec.Bins
+		mustUpdate = true
+	}
+	if !reflect.DeepEqual(b.Status.ModifiedAfter, b.Spec.ModifiedAfter) {
+		b.Status.ModifiedAfter = b.Spec.ModifiedAfter
+		mustUpdate = true
+	}
+	if !reflect.DeepEqual(b.Status.ModifiedBefore, b.Spec.ModifiedBefore) {
+		b.Status.ModifiedBefore = b.Spec.ModifiedBefore
+		mustUpdate = true
+	}
+	if !reflect.DeepEqual(b.Status.Incremental, b.Spec.Incremental) {
+		b.Status.Incremental = b.Spec.Incremental
+		mustUpdate = true
+	}
 	return mustUpdate
 }
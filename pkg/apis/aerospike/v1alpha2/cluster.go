@@ -20,6 +20,8 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apiextensions-apiserver/pkg/apis/apiextensions"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/travelaudience/aerospike-operator/pkg/apis/aerospike/common"
 )
 
 // +genclient
@@ -45,12 +47,40 @@ type AerospikeClusterSpec struct {
 	// The version of Aerospike to be deployed.
 	Version string `json:"version"`
 	// The specification of the Aerospike namespaces in the cluster.
-	// Must have exactly one element.
+	// Must have at least one element.
 	Namespaces []AerospikeNamespaceSpec `json:"namespaces"`
 	// The specification of how Aerospike namespace backups made by aerospike-operator should be performed and stored.
 	// It is only required to be present if one wants to perform version upgrades on the Aerospike cluster.
 	// +optional
 	BackupSpec *AerospikeClusterBackupSpec `json:"backupSpec,omitempty"`
+	// The policy to follow when upgrading the version of the Aerospike cluster.
+	// Defaults to requiring a backup of every Aerospike namespace before upgrading.
+	// +optional
+	UpgradePolicy *AerospikeClusterUpgradePolicy `json:"upgradePolicy,omitempty"`
+	// The container images used by the Aerospike cluster and how to pull them.
+	// Defaults to the images configured in aerospike-operator.
+	// +optional
+	Images *AerospikeClusterImagesSpec `json:"images,omitempty"`
+	// Overrides for the parameters of the service, network and logging contexts of the Aerospike configuration.
+	// Defaults to the configuration used by aerospike-operator.
+	// +optional
+	Config *AerospikeConfigSpec `json:"config,omitempty"`
+	// The racks across which the nodes of the Aerospike cluster are spread, the node with index i belonging to the
+	// rack with index i modulo the number of racks. Cannot be changed after the Aerospike cluster has been created.
+	// +optional
+	Racks []AerospikeRackSpec `json:"racks,omitempty"`
+	// The security configuration of the Aerospike cluster, as well as its users and roles. Requires Aerospike
+	// Enterprise. Cannot be added or removed after the Aerospike cluster has been created.
+	// +optional
+	Security *AerospikeClusterSecuritySpec `json:"security,omitempty"`
+	// The TLS configuration of the service, fabric and heartbeat listeners of the Aerospike cluster. Requires Aerospike
+	// Enterprise. Cannot be changed after the Aerospike cluster has been created.
+	// +optional
+	TLS *AerospikeClusterTLSSpec `json:"tls,omitempty"`
+	// The name of the secret holding the feature key file of Aerospike Enterprise, under the features.conf key.
+	// Requires Aerospike Enterprise, and is required by Aerospike Enterprise 4.6 and later.
+	// +optional
+	FeatureKeySecret *string `json:"featureKeySecret,omitempty"`
 	// Define resources requests and limits for Aerospike Server Container.
 	Resources *corev1.ResourceRequirements `json:"resources,omitempty"`
 	// Define which Nodes the Pods are scheduled on.
@@ -66,6 +96,25 @@ type AerospikeClusterStatus struct {
 	// Details about the current condition of the AerospikeCluster resource.
 	// +k8s:openapi-gen=false
 	Conditions []apiextensions.CustomResourceDefinitionCondition `json:"conditions"`
+	// The values of the parameters of the Aerospike configuration that can be overridden, as applied to every node.
+	// +optional
+	AppliedConfig []AerospikeConfigParameterStatus `json:"appliedConfig,omitempty"`
+}
+
+// AerospikeConfigParameterStatus represents the value of a parameter of the Aerospike configuration applied to
+// every node of an Aerospike cluster.
+type AerospikeConfigParameterStatus struct {
+	// The context of the Aerospike configuration the parameter belongs to (e.g. service or namespace.storage-engine).
+	Context string `json:"context"`
+	// The name of the Aerospike namespace the parameter applies to, if it belongs to a namespace context.
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+	// The name of the parameter.
+	Name string `json:"name"`
+	// The value of the parameter.
+	Value string `json:"value"`
+	// Whether the parameter can be changed without restarting the nodes.
+	Dynamic bool `json:"dynamic"`
 }
 
 // AerospikeNamespaceSpec specifies the configuration for an Aerospike namespace.
@@ -88,6 +137,9 @@ type AerospikeNamespaceSpec struct {
 	DefaultTTL *string `json:"defaultTTL,omitempty"`
 	// Specifies how data for the Aerospike namespace will be stored.
 	Storage StorageSpec `json:"storage"`
+	// Overrides for the parameters of the Aerospike namespace (e.g. high-water-memory-pct), indexed by name.
+	// +optional
+	Config map[string]string `json:"config,omitempty"`
 }
 
 // AerospikeClusterBackupSpec specifies how Aerospike namespace backups made by aerospike-operator before a version upgrade should be stored.
@@ -100,6 +152,171 @@ type AerospikeClusterBackupSpec struct {
 	Storage BackupStorageSpec `json:"storage"`
 }
 
+// AerospikeClusterUpgradePolicy specifies the policy to follow when upgrading the version of an Aerospike cluster.
+type AerospikeClusterUpgradePolicy struct {
+	// Whether to backup every Aerospike namespace before upgrading (required), to upgrade without backing up (skip),
+	// or to reuse existing backups made more recently than maxBackupAge, backing up otherwise (reuse).
+	Backup string `json:"backup"`
+	// The maximum age of the backups that can be reused, suffixed with s, m, h or d (e.g. 12h).
+	// Required when backup is reuse.
+	// +optional
+	MaxBackupAge *string `json:"maxBackupAge,omitempty"`
+}
+
+// AerospikeClusterImagesSpec specifies the container images used by an Aerospike cluster and how to pull them.
+type AerospikeClusterImagesSpec struct {
+	// The repository of the images used to run Aerospike (e.g. registry.example.com/aerospike/aerospike-server-enterprise),
+	// which must be tagged with the version of Aerospike.
+	// +optional
+	ServerRepository *string `json:"serverRepository,omitempty"`
+	// The image used to run the init container of each pod, as well as the backup and restore jobs targeting the
+	// Aerospike cluster.
+	// +optional
+	Tools *string `json:"tools,omitempty"`
+	// The image used to run the Prometheus exporter (asprom) in each pod.
+	// Defaults to the tools image.
+	// +optional
+	Exporter *string `json:"exporter,omitempty"`
+	// The pull policy for every image (Always, IfNotPresent or Never).
+	// +optional
+	PullPolicy *corev1.PullPolicy `json:"pullPolicy,omitempty"`
+	// The secrets used to pull the images, which must exist in the Kubernetes namespace of the Aerospike cluster.
+	// +optional
+	PullSecrets []corev1.LocalObjectReference `json:"pullSecrets,omitempty"`
+}
+
+// AerospikeRackSpec specifies a rack of an Aerospike cluster and the Kubernetes nodes its Aerospike nodes run on.
+type AerospikeRackSpec struct {
+	// The id of the rack (rack-id), between 1 and 1000000.
+	ID int32 `json:"id"`
+	// The zone of the Kubernetes nodes the Aerospike nodes of the rack run on.
+	// +optional
+	Zone *string `json:"zone,omitempty"`
+	// The labels the Kubernetes nodes the Aerospike nodes of the rack run on must have.
+	// +optional
+	NodeLabels map[string]string `json:"nodeLabels,omitempty"`
+}
+
+// AerospikeClusterSecuritySpec specifies the security configuration of an Aerospike cluster, as well as its users and
+// roles.
+type AerospikeClusterSecuritySpec struct {
+	// The name of the secret holding the credentials (in the username and password keys) of the user used by
+	// aerospike-operator, the backup and restore jobs and the Prometheus exporter to access the Aerospike cluster.
+	// Must exist in the Kubernetes namespace of the Aerospike cluster.
+	AdminSecret string `json:"adminSecret"`
+	// The users of the Aerospike cluster other than the admin user. Users not listed here are removed.
+	// +optional
+	Users []AerospikeUserSpec `json:"users,omitempty"`
+	// The user-defined roles of the Aerospike cluster. User-defined roles not listed here are removed.
+	// +optional
+	Roles []AerospikeRoleSpec `json:"roles,omitempty"`
+}
+
+// AerospikeUserSpec specifies a user of an Aerospike cluster.
+type AerospikeUserSpec struct {
+	// The name of the user.
+	Name string `json:"name"`
+	// The name of the secret holding the password of the user (in the password key).
+	// Must exist in the Kubernetes namespace of the Aerospike cluster.
+	PasswordSecret string `json:"passwordSecret"`
+	// The roles granted to the user, either predefined (e.g. read-write) or user-defined.
+	// +optional
+	Roles []string `json:"roles,omitempty"`
+}
+
+// AerospikeRoleSpec specifies a user-defined role of an Aerospike cluster.
+type AerospikeRoleSpec struct {
+	// The name of the role.
+	Name string `json:"name"`
+	// The privileges granted by the role.
+	Privileges []AerospikePrivilegeSpec `json:"privileges"`
+}
+
+// AerospikePrivilegeSpec specifies a privilege granted by a user-defined role of an Aerospike cluster.
+type AerospikePrivilegeSpec struct {
+	// The code of the privilege (user-admin, sys-admin, data-admin, read, read-write or read-write-udf).
+	Code string `json:"code"`
+	// The Aerospike namespace the privilege is restricted to. Only supported by read, read-write and read-write-udf.
+	// +optional
+	Namespace *string `json:"namespace,omitempty"`
+	// The set the privilege is restricted to. Requires namespace to be specified.
+	// +optional
+	Set *string `json:"set,omitempty"`
+}
+
+// AerospikeClusterTLSSpec specifies the TLS configuration of the listeners of an Aerospike cluster.
+type AerospikeClusterTLSSpec struct {
+	// The TLS configuration of the service listener, used by clients. Clients can still use the plaintext port.
+	// +optional
+	Service *AerospikeTLSListenerSpec `json:"service,omitempty"`
+	// The TLS configuration of the fabric listener, used for replication and migrations between nodes.
+	// +optional
+	Fabric *AerospikeTLSListenerSpec `json:"fabric,omitempty"`
+	// The TLS configuration of the heartbeat listener, used for cluster membership.
+	// +optional
+	Heartbeat *AerospikeTLSListenerSpec `json:"heartbeat,omitempty"`
+}
+
+// AerospikeTLSListenerSpec specifies the TLS configuration of a listener of an Aerospike cluster.
+type AerospikeTLSListenerSpec struct {
+	// The name of the secret of type kubernetes.io/tls holding the certificate (tls.crt), private key (tls.key) and CA
+	// certificate (ca.crt) of the listener, such as the ones created by cert-manager. The CA certificate is only
+	// required by the fabric and heartbeat listeners. Must exist in the Kubernetes namespace of the Aerospike cluster.
+	SecretName string `json:"secretName"`
+	// The name the certificate is valid for (tls-name), which nodes verify when connecting to each other.
+	TLSName string `json:"tlsName"`
+}
+
+// AerospikeConfigSpec specifies overrides for the parameters of the Aerospike configuration, indexed by name.
+type AerospikeConfigSpec struct {
+	// Overrides for the parameters of the service context (e.g. service-threads).
+	// +optional
+	Service map[string]string `json:"service,omitempty"`
+	// Overrides for the parameters of the network context.
+	// +optional
+	Network *AerospikeNetworkConfigSpec `json:"network,omitempty"`
+	// Overrides for the logging levels (e.g. warning) of the logging contexts (e.g. migrate).
+	// +optional
+	Logging map[string]string `json:"logging,omitempty"`
+}
+
+// AerospikeNetworkConfigSpec specifies overrides for the parameters of the network context of the Aerospike
+// configuration, indexed by name.
+type AerospikeNetworkConfigSpec struct {
+	// Overrides for the parameters of the heartbeat subcontext (e.g. interval).
+	// +optional
+	Heartbeat map[string]string `json:"heartbeat,omitempty"`
+	// Overrides for the parameters of the fabric subcontext (e.g. send-threads).
+	// +optional
+	Fabric map[string]string `json:"fabric,omitempty"`
+}
+
+// GetBackupPolicy returns the backup policy to follow when upgrading the
+// version of the Aerospike cluster, defaulting to
+// common.UpgradeBackupPolicyRequired.
+func (s *AerospikeClusterSpec) GetBackupPolicy() string {
+	if s.UpgradePolicy == nil || s.UpgradePolicy.Backup == "" {
+		return common.UpgradeBackupPolicyRequired
+	}
+	return s.UpgradePolicy.Backup
+}
+
+// HasUnreplicatedData returns whether some of the data stored by the Aerospike
+// cluster is held by a single node (i.e. whether the cluster has a single node
+// or some namespace has a replication factor of 1), in which case it cannot be
+// re-populated through migrations after the node's data is wiped.
+func (s *AerospikeClusterSpec) HasUnreplicatedData() bool {
+	if s.NodeCount <= 1 {
+		return true
+	}
+	for _, ns := range s.Namespaces {
+		if ns.ReplicationFactor != nil && *ns.ReplicationFactor <= 1 {
+			return true
+		}
+	}
+	return false
+}
+
 // StorageSpec specifies how data in a given Aerospike namespace will be stored.
 type StorageSpec struct {
 	// The storage engine to be used for the namespace (file or device).
@@ -118,6 +335,9 @@ type StorageSpec struct {
 	// namespace.
 	// +optional
 	DataInMemory *bool `json:"dataInMemory,omitempty"`
+	// Overrides for the parameters of the storage-engine subcontext (e.g. write-block-size), indexed by name.
+	// +optional
+	Config map[string]string `json:"config,omitempty"`
 }
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
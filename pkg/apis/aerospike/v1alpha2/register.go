@@ -51,6 +51,8 @@ func addKnownTypes(scheme *runtime.Scheme) error {
 		&AerospikeNamespaceBackupList{},
 		&AerospikeNamespaceRestore{},
 		&AerospikeNamespaceRestoreList{},
+		&AerospikeNamespaceBackupSchedule{},
+		&AerospikeNamespaceBackupScheduleList{},
 	)
 	metav1.AddToGroupVersion(scheme, SchemeGroupVersion)
 	return nil
@@ -48,12 +48,81 @@ type AerospikeNamespaceRestoreSpec struct {
 	// The specification of how the backup should be retrieved.
 	// +optional
 	Storage *BackupStorageSpec `json:"storage,omitempty"`
+	// The specification of the backup to restore.
+	// Defaults to the backup with the same name as the AerospikeNamespaceRestore resource.
+	// +optional
+	Source *RestoreSource `json:"source,omitempty"`
+	// Whether to only verify the integrity of the backup instead of restoring it.
+	// +optional
+	VerifyOnly *bool `json:"verifyOnly,omitempty"`
+	// The options controlling how the data of the backup is written to the target namespace.
+	// Ignored when verifyOnly is true.
+	// +optional
+	Options *RestoreOptions `json:"options,omitempty"`
+}
+
+// RestoreOptions specifies how the data of a backup is written to the target namespace.
+type RestoreOptions struct {
+	// The policy used to write records that already exist in the target namespace (i.e. update, unique or replace).
+	// Defaults to update.
+	// +optional
+	WritePolicy *string `json:"writePolicy,omitempty"`
+	// Whether to write records regardless of the generation of the records that already exist.
+	// +optional
+	NoGeneration *bool `json:"noGeneration,omitempty"`
+	// The sets to restore.
+	// Defaults to all sets.
+	// +optional
+	Sets []string `json:"sets,omitempty"`
+	// The bins to restore.
+	// Defaults to all bins.
+	// +optional
+	Bins []string `json:"bins,omitempty"`
+	// Whether to ignore permanent errors affecting single records (e.g., records that are too big) instead of failing.
+	// +optional
+	IgnoreRecordErrors *bool `json:"ignoreRecordErrors,omitempty"`
+	// The number of seconds to add to the time-to-live of restored records that expire.
+	// +optional
+	TTLExtension *int64 `json:"ttlExtension,omitempty"`
+}
+
+// RestoreSource specifies the backup a restore operation will restore, either by name, as the latest successful
+// backup of a given Aerospike cluster and namespace created before a given time, or as the path of its data in storage.
+type RestoreSource struct {
+	// The name of the backup to restore.
+	// +optional
+	Name *string `json:"name,omitempty"`
+	// The Kubernetes namespace of the AerospikeNamespaceBackup resources considered when name or before is specified.
+	// Defaults to the namespace of the AerospikeNamespaceRestore resource.
+	// +optional
+	KubernetesNamespace *string `json:"kubernetesNamespace,omitempty"`
+	// The path of the backup in storage (i.e. the name of its objects without extension), as an alternative to
+	// referencing an AerospikeNamespaceBackup resource. Requires .spec.storage to be specified.
+	// +optional
+	Path *string `json:"path,omitempty"`
+	// Restore the latest successful AerospikeNamespaceBackup created before the specified time.
+	// +optional
+	Before *metav1.Time `json:"before,omitempty"`
+	// The name of the Aerospike cluster whose backups are considered when before is specified.
+	// Defaults to the target cluster.
+	// +optional
+	Cluster *string `json:"cluster,omitempty"`
+	// The name of the Aerospike namespace whose backups are considered when before is specified.
+	// Defaults to the target namespace.
+	// +optional
+	Namespace *string `json:"namespace,omitempty"`
 }
 
 // AerospikeNamespaceRestoreStatus is the status for an AerospikeNamespaceRestore resource
 type AerospikeNamespaceRestoreStatus struct {
 	// The configuration for the restore operation.
 	AerospikeNamespaceRestoreSpec
+	// The name of the backup being restored.
+	// +optional
+	BackupName string `json:"backupName,omitempty"`
+	// The progress of the restore operation.
+	// +optional
+	Progress *BackupRestoreProgress `json:"progress,omitempty"`
 	// Details about the current condition of the AerospikeNamespaceRestore resource.
 	// +k8s:openapi-gen=false
 	Conditions []apiextensions.CustomResourceDefinitionCondition `json="conditions"`
@@ -87,6 +156,14 @@ func (r *AerospikeNamespaceRestore) GetNamespace() string {
 	return r.Namespace
 }
 
+func (r *AerospikeNamespaceRestore) GetProgress() *BackupRestoreProgress {
+	return r.Status.Progress
+}
+
+func (r *AerospikeNamespaceRestore) SetProgress(progress *BackupRestoreProgress) {
+	r.Status.Progress = progress
+}
+
 func (r *AerospikeNamespaceRestore) GetObjectMeta() *metav1.ObjectMeta {
 	return &r.ObjectMeta
 }
@@ -99,6 +176,25 @@ func (r *AerospikeNamespaceRestore) SetStorage(storage *BackupStorageSpec) {
 	r.Spec.Storage = storage
 }
 
+// GetBackupName returns the name of the backup to restore.
+func (r *AerospikeNamespaceRestore) GetBackupName() string {
+	if r.Status.BackupName != "" {
+		return r.Status.BackupName
+	}
+	if r.Spec.Source != nil && r.Spec.Source.Path != nil {
+		return *r.Spec.Source.Path
+	}
+	if r.Spec.Source != nil && r.Spec.Source.Name != nil {
+		return *r.Spec.Source.Name
+	}
+	return r.Name
+}
+
+// IsVerifyOnly returns whether the integrity of the backup should be verified instead of restoring it.
+func (r *AerospikeNamespaceRestore) IsVerifyOnly() bool {
+	return r.Spec.VerifyOnly != nil && *r.Spec.VerifyOnly
+}
+
 func (r *AerospikeNamespaceRestore) GetTarget() *TargetNamespace {
 	return &r.Spec.Target
 }
@@ -133,5 +229,45 @@ func (b *AerospikeNamespaceRestore) SyncStatusWithSpec() bool {
 		b.Status.Target = b.Spec.Target
 		mustUpdate = true
 	}
+	if !reflect.DeepEqual(b.Status.Source, b.Spec.Source) {
+		b.Status.Source = b.Spec.Source
+		mustUpdate = true
+	}
+	if !reflect.DeepEqual(b.Status.VerifyOnly, b.Spec.VerifyOnly) {
+		b.Status.VerifyOnly = b.Spec.VerifyOnly
+		mustUpdate = true
+	}
+	if !reflect.DeepEqual(b.Status.Options, b.Spec.Options) {
+		b.Status.Options = b.Spec.Options
+		mustUpdate = true
+	}
 	return mustUpdate
 }
+
+// GetBackupNamespace returns the Kubernetes namespace of the backup to restore.
+func (r *AerospikeNamespaceRestore) GetBackupNamespace() string {
+	if r.Spec.Source != nil && r.Spec.Source.KubernetesNamespace != nil {
+		return *r.Spec.Source.KubernetesNamespace
+	}
+	return r.Namespace
+}
+
+// GetSourceTarget returns the Aerospike cluster and namespace whose backups are
+// considered when restoring the latest backup before a given time.
+func (s *RestoreSource) GetSourceTarget(fallback TargetNamespace) TargetNamespace {
+	if s.Cluster != nil {
+		fallback.Cluster = *s.Cluster
+	}
+	if s.Namespace != nil {
+		fallback.Namespace = *s.Namespace
+	}
+	return fallback
+}
+
+// GetWritePolicy returns the policy used to write records that already exist in the target namespace.
+func (o *RestoreOptions) GetWritePolicy() string {
+	if o != nil && o.WritePolicy != nil {
+		return *o.WritePolicy
+	}
+	return common.WritePolicyUpdate
+}
@@ -0,0 +1,110 @@
+/*
+Copyright 2019 The aerospike-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha2
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +k8s:openapi-gen=true
+
+// AerospikeNamespaceBackupSchedule represents a schedule for periodically backing up a single Aerospike namespace.
+type AerospikeNamespaceBackupSchedule struct {
+	metav1.TypeMeta `json:",inline"`
+	// Standard object metadata.
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	// The specification of the backup schedule.
+	Spec AerospikeNamespaceBackupScheduleSpec `json:"spec"`
+	// The status of the backup schedule.
+	Status AerospikeNamespaceBackupScheduleStatus `json:"status"`
+}
+
+// AerospikeNamespaceBackupScheduleSpec specifies the configuration for a backup schedule.
+type AerospikeNamespaceBackupScheduleSpec struct {
+	// The schedule in cron format (e.g., "0 3 * * *"), evaluated in UTC.
+	Schedule string `json:"schedule"`
+	// The specification of the Aerospike cluster and Aerospike namespace to backup.
+	Target TargetNamespace `json:"target"`
+	// The specification of how the backups will be stored.
+	// +optional
+	Storage *BackupStorageSpec `json:"storage,omitempty"`
+	// The retention period (days) during which to keep the data of each backup in cloud storage, suffixed with d.
+	// Defaults to 0d, meaning the backup data will be kept forever.
+	// +optional
+	TTL *string `json:"ttl,omitempty"`
+	// The number of shards into which to split each backup, each of which is backed up in parallel by a separate job.
+	// Defaults to 1, meaning backups are not split.
+	// +optional
+	Parallelism *int32 `json:"parallelism,omitempty"`
+	// The number of successful backups to keep. Failed backups are subject to the same limit, counted separately.
+	// Older backups (and their data) are deleted by the garbage collector.
+	// Defaults to 0, meaning backups will not be deleted based on their number.
+	// +optional
+	RetentionCount *int32 `json:"retentionCount,omitempty"`
+	// Whether to suspend the creation of new backups.
+	// Defaults to false.
+	// +optional
+	Suspend *bool `json:"suspend,omitempty"`
+}
+
+// AerospikeNamespaceBackupScheduleStatus is the status for an AerospikeNamespaceBackupSchedule resource.
+type AerospikeNamespaceBackupScheduleStatus struct {
+	// The time at which the latest backup was scheduled.
+	// +optional
+	LastScheduleTime *metav1.Time `json:"lastScheduleTime,omitempty"`
+	// The name of the latest AerospikeNamespaceBackup resource created by the schedule.
+	// +optional
+	LastBackup string `json:"lastBackup,omitempty"`
+	// The time at which the latest successful backup finished.
+	// +optional
+	LastSuccessfulTime *metav1.Time `json:"lastSuccessfulTime,omitempty"`
+	// The name of the latest successful AerospikeNamespaceBackup resource created by the schedule.
+	// +optional
+	LastSuccessfulBackup string `json:"lastSuccessfulBackup,omitempty"`
+	// The time at which the latest failed backup failed.
+	// +optional
+	LastFailureTime *metav1.Time `json:"lastFailureTime,omitempty"`
+	// The name of the latest failed AerospikeNamespaceBackup resource created by the schedule.
+	// +optional
+	LastFailedBackup string `json:"lastFailedBackup,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// AerospikeNamespaceBackupScheduleList represents a list of AerospikeNamespaceBackupSchedule resources.
+type AerospikeNamespaceBackupScheduleList struct {
+	metav1.TypeMeta `json:",inline"`
+	// Standard list metadata.
+	metav1.ListMeta `json:"metadata"`
+
+	// The list of AerospikeNamespaceBackupSchedule resources.
+	Items []AerospikeNamespaceBackupSchedule `json:"items"`
+}
+
+func (s *AerospikeNamespaceBackupSchedule) IsSuspended() bool {
+	return s.Spec.Suspend != nil && *s.Spec.Suspend
+}
+
+func (s *AerospikeNamespaceBackupSchedule) GetRetentionCount() int32 {
+	if s.Spec.RetentionCount != nil {
+		return *s.Spec.RetentionCount
+	}
+	return 0
+}
@@ -28,6 +28,7 @@ type BackupRestoreObject interface {
 	GetKind() string
 	GetName() string
 	GetNamespace() string
+	GetBackupName() string
 	GetObjectMeta() *v1.ObjectMeta
 	GetStorage() *BackupStorageSpec
 	SetStorage(*BackupStorageSpec)
@@ -37,5 +38,33 @@ type BackupRestoreObject interface {
 	GetFailedConditionType() apiextensions.CustomResourceDefinitionConditionType
 	GetFinishedConditionType() apiextensions.CustomResourceDefinitionConditionType
 	GetStartedConditionType() apiextensions.CustomResourceDefinitionConditionType
+	GetProgress() *BackupRestoreProgress
+	SetProgress(*BackupRestoreProgress)
 	SyncStatusWithSpec() bool
 }
+
+// BackupRestoreProgress describes the progress of a backup or restore operation.
+type BackupRestoreProgress struct {
+	// The number of (uncompressed) bytes transferred so far.
+	// +optional
+	Bytes *int64 `json:"bytes,omitempty"`
+	// The number of records backed up or restored.
+	// Only known once the operation has finished, or (for restores) if recorded in the backup metadata.
+	// +optional
+	Records *int64 `json:"records,omitempty"`
+	// The estimated percentage of completion of the operation.
+	// +optional
+	Percentage *int32 `json:"percentage,omitempty"`
+	// The time at which the operation started.
+	// +optional
+	StartTime *v1.Time `json:"startTime,omitempty"`
+	// The time at which the operation finished.
+	// +optional
+	CompletionTime *v1.Time `json:"completionTime,omitempty"`
+	// The time elapsed between the start of the operation and its completion (or the last update, if still running).
+	// +optional
+	Duration string `json:"duration,omitempty"`
+	// The time at which the progress was last updated.
+	// +optional
+	LastUpdateTime *v1.Time `json:"lastUpdateTime,omitempty"`
+}
@@ -0,0 +1,334 @@
+/*
+Copyright 2019 The aerospike-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package asconfig holds the schema of the Aerospike configuration parameters
+// that can be overridden in an Aerospike cluster, and renders the overrides
+// together with the defaults used by aerospike-operator. Parameters managed
+// by aerospike-operator (e.g. addresses, ports, paths and the parameters
+// exposed as fields of the AerospikeCluster resource) cannot be overridden.
+// The schema also tells which parameters can be changed on running nodes.
+package asconfig
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+
+	"github.com/travelaudience/aerospike-operator/pkg/versioning"
+)
+
+// Context identifies a context (or subcontext) of the Aerospike configuration
+// file.
+type Context string
+
+const (
+	// ContextService is the service context.
+	ContextService Context = "service"
+	// ContextLogging is the logging context, whose parameters are logging
+	// contexts (e.g. migrate) and whose values are logging levels.
+	ContextLogging Context = "logging"
+	// ContextHeartbeat is the heartbeat subcontext of the network context.
+	ContextHeartbeat Context = "network.heartbeat"
+	// ContextFabric is the fabric subcontext of the network context.
+	ContextFabric Context = "network.fabric"
+	// ContextNamespace is the namespace context.
+	ContextNamespace Context = "namespace"
+	// ContextStorageEngine is the storage-engine subcontext of the namespace
+	// context.
+	ContextStorageEngine Context = "namespace.storage-engine"
+	// ContextSecurity is the security context (Aerospike Enterprise only).
+	ContextSecurity Context = "security"
+)
+
+// kind represents the kind of value accepted by a parameter.
+type kind int
+
+const (
+	// kindInteger represents a non-negative integer.
+	kindInteger kind = iota
+	// kindBoolean represents either true or false.
+	kindBoolean
+	// kindSize represents a non-negative integer, optionally suffixed with K,
+	// M or G.
+	kindSize
+	// kindEnum represents one of a set of strings.
+	kindEnum
+)
+
+// parameter describes a configuration parameter and the versions of Aerospike
+// that support it.
+type parameter struct {
+	// kind is the kind of value accepted by the parameter.
+	kind kind
+	// values holds the values accepted by parameters of kind kindEnum.
+	values []string
+	// since is the first version supporting the parameter. The zero value
+	// means every version.
+	since versioning.Version
+	// until is the first version no longer supporting the parameter. The zero
+	// value means every version.
+	until versioning.Version
+	// dynamic indicates whether the parameter can be changed on a running node
+	// (e.g. using set-config), as opposed to requiring the node to restart.
+	dynamic bool
+	// managed indicates whether the parameter is set from a field of the
+	// AerospikeCluster resource, in which case it cannot be overridden.
+	managed bool
+}
+
+var (
+	// sizeRegex matches values of kind kindSize.
+	sizeRegex = regexp.MustCompile(`^[0-9]+[KMG]?$`)
+
+	// v4_5_1_5 is the version in which nsup-period and nsup-hist-period have
+	// been moved from the service context to the namespace context.
+	v4_5_1_5 = versioning.Version{Major: 4, Minor: 5, Patch: 1, Revision: 5}
+	// v4_7_0_2 is the version in which the transaction queues and the scan
+	// threads have been removed.
+	v4_7_0_2 = versioning.Version{Major: 4, Minor: 7, Patch: 0, Revision: 2}
+	// v5_6_0_0 is the version in which enable-security has been removed (the
+	// presence of the security context enabling security).
+	v5_6_0_0 = versioning.Version{Major: 5, Minor: 6, Patch: 0, Revision: 0}
+
+	// logLevels holds the logging levels accepted as values of the logging
+	// context.
+	logLevels = []string{"critical", "warning", "info", "debug", "detail"}
+)
+
+// schema holds the parameters that can be overridden in each context.
+var schema = map[Context]map[string]parameter{
+	ContextService: {
+		"batch-index-threads":           {kind: kindInteger, dynamic: true},
+		"batch-max-buffers-per-queue":   {kind: kindInteger, dynamic: true},
+		"batch-max-requests":            {kind: kindInteger, dynamic: true},
+		"batch-max-unused-buffers":      {kind: kindInteger, dynamic: true},
+		"info-threads":                  {kind: kindInteger},
+		"migrate-max-num-incoming":      {kind: kindInteger, dynamic: true},
+		"migrate-threads":               {kind: kindInteger, dynamic: true},
+		"min-cluster-size":              {kind: kindInteger, dynamic: true},
+		"nsup-hist-period":              {kind: kindInteger, until: v4_5_1_5, dynamic: true},
+		"nsup-period":                   {kind: kindInteger, until: v4_5_1_5, dynamic: true},
+		"proto-fd-idle-ms":              {kind: kindInteger, dynamic: true},
+		"proto-fd-max":                  {kind: kindInteger, dynamic: true},
+		"query-batch-size":              {kind: kindInteger, dynamic: true},
+		"query-in-transaction-thread":   {kind: kindBoolean, dynamic: true},
+		"query-threads":                 {kind: kindInteger, dynamic: true},
+		"query-worker-threads":          {kind: kindInteger, dynamic: true},
+		"scan-threads":                  {kind: kindInteger, until: v4_7_0_2, dynamic: true},
+		"service-threads":               {kind: kindInteger},
+		"ticker-interval":               {kind: kindInteger, dynamic: true},
+		"transaction-max-ms":            {kind: kindInteger, dynamic: true},
+		"transaction-pending-limit":     {kind: kindInteger, dynamic: true},
+		"transaction-queues":            {kind: kindInteger, until: v4_7_0_2},
+		"transaction-retry-ms":          {kind: kindInteger, dynamic: true},
+		"transaction-threads-per-queue": {kind: kindInteger, until: v4_7_0_2, dynamic: true},
+	},
+	ContextLogging: {
+		"aggr":       {kind: kindEnum, values: logLevels, dynamic: true},
+		"any":        {kind: kindEnum, values: logLevels, dynamic: true},
+		"as":         {kind: kindEnum, values: logLevels, dynamic: true},
+		"batch":      {kind: kindEnum, values: logLevels, dynamic: true},
+		"clustering": {kind: kindEnum, values: logLevels, dynamic: true},
+		"drv_ssd":    {kind: kindEnum, values: logLevels, dynamic: true},
+		"exchange":   {kind: kindEnum, values: logLevels, dynamic: true},
+		"fabric":     {kind: kindEnum, values: logLevels, dynamic: true},
+		"hb":         {kind: kindEnum, values: logLevels, dynamic: true},
+		"index":      {kind: kindEnum, values: logLevels, dynamic: true},
+		"info":       {kind: kindEnum, values: logLevels, dynamic: true},
+		"migrate":    {kind: kindEnum, values: logLevels, dynamic: true},
+		"namespace":  {kind: kindEnum, values: logLevels, dynamic: true},
+		"nsup":       {kind: kindEnum, values: logLevels, dynamic: true},
+		"partition":  {kind: kindEnum, values: logLevels, dynamic: true},
+		"proto":      {kind: kindEnum, values: logLevels, dynamic: true},
+		"proxy":      {kind: kindEnum, values: logLevels, dynamic: true},
+		"query":      {kind: kindEnum, values: logLevels, dynamic: true},
+		"record":     {kind: kindEnum, values: logLevels, dynamic: true},
+		"rw":         {kind: kindEnum, values: logLevels, dynamic: true},
+		"scan":       {kind: kindEnum, values: logLevels, dynamic: true},
+		"sindex":     {kind: kindEnum, values: logLevels, dynamic: true},
+		"smd":        {kind: kindEnum, values: logLevels, dynamic: true},
+		"socket":     {kind: kindEnum, values: logLevels, dynamic: true},
+		"storage":    {kind: kindEnum, values: logLevels, dynamic: true},
+		"truncate":   {kind: kindEnum, values: logLevels, dynamic: true},
+		"tsvc":       {kind: kindEnum, values: logLevels, dynamic: true},
+		"udf":        {kind: kindEnum, values: logLevels, dynamic: true},
+	},
+	ContextHeartbeat: {
+		"interval": {kind: kindInteger, dynamic: true},
+		"mtu":      {kind: kindInteger},
+		"timeout":  {kind: kindInteger, dynamic: true},
+	},
+	ContextFabric: {
+		"channel-bulk-fds":          {kind: kindInteger},
+		"channel-bulk-recv-threads": {kind: kindInteger},
+		"channel-ctrl-fds":          {kind: kindInteger},
+		"channel-ctrl-recv-threads": {kind: kindInteger},
+		"channel-meta-fds":          {kind: kindInteger},
+		"channel-meta-recv-threads": {kind: kindInteger},
+		"channel-rw-fds":            {kind: kindInteger},
+		"channel-rw-recv-threads":   {kind: kindInteger},
+		"keepalive-enabled":         {kind: kindBoolean},
+		"keepalive-intvl":           {kind: kindInteger},
+		"keepalive-probes":          {kind: kindInteger},
+		"keepalive-time":            {kind: kindInteger},
+		"latency-max-ms":            {kind: kindInteger},
+		"send-threads":              {kind: kindInteger},
+	},
+	ContextNamespace: {
+		"default-ttl":                     {kind: kindInteger, dynamic: true, managed: true},
+		"conflict-resolution-policy":      {kind: kindEnum, values: []string{"generation", "last-update-time"}},
+		"disable-write-dup-res":           {kind: kindBoolean, dynamic: true},
+		"evict-hist-buckets":              {kind: kindInteger, dynamic: true},
+		"evict-tenths-pct":                {kind: kindInteger, dynamic: true},
+		"high-water-disk-pct":             {kind: kindInteger, dynamic: true},
+		"high-water-memory-pct":           {kind: kindInteger, dynamic: true},
+		"max-ttl":                         {kind: kindInteger},
+		"migrate-order":                   {kind: kindInteger, dynamic: true},
+		"migrate-sleep":                   {kind: kindInteger, dynamic: true},
+		"nsup-hist-period":                {kind: kindInteger, since: v4_5_1_5, dynamic: true},
+		"nsup-period":                     {kind: kindInteger, since: v4_5_1_5, dynamic: true},
+		"partition-tree-sprigs":           {kind: kindInteger},
+		"read-consistency-level-override": {kind: kindEnum, values: []string{"all", "off", "one"}, dynamic: true},
+		"single-bin":                      {kind: kindBoolean},
+		"stop-writes-pct":                 {kind: kindInteger, dynamic: true},
+		"write-commit-level-override":     {kind: kindEnum, values: []string{"all", "master", "off"}, dynamic: true},
+	},
+	ContextStorageEngine: {
+		"cold-start-empty": {kind: kindBoolean},
+		"defrag-lwm-pct":   {kind: kindInteger, dynamic: true},
+		"defrag-sleep":     {kind: kindInteger, dynamic: true},
+		"flush-max-ms":     {kind: kindInteger, dynamic: true},
+		"max-write-cache":  {kind: kindSize, dynamic: true},
+		"min-avail-pct":    {kind: kindInteger, dynamic: true},
+		"post-write-queue": {kind: kindInteger, dynamic: true},
+		"write-block-size": {kind: kindSize},
+	},
+	ContextSecurity: {
+		"enable-security": {kind: kindBoolean, until: v5_6_0_0, managed: true},
+	},
+}
+
+// IsSupported returns whether the parameter with the specified name can be
+// set in the specified context of the configuration of the specified version
+// of Aerospike.
+func IsSupported(version versioning.Version, ctx Context, name string) bool {
+	p, ok := schema[ctx][name]
+	return ok && p.supports(version)
+}
+
+// IsDynamic returns whether the parameter with the specified name can be
+// changed without restarting a node running the specified version of
+// Aerospike.
+func IsDynamic(version versioning.Version, ctx Context, name string) bool {
+	p, ok := schema[ctx][name]
+	return ok && p.supports(version) && p.dynamic
+}
+
+// supports returns whether the parameter is supported by the specified version
+// of Aerospike.
+func (p parameter) supports(version versioning.Version) bool {
+	zero := versioning.Version{}
+	if p.since != zero && version.Compare(p.since) < 0 {
+		return false
+	}
+	if p.until != zero && version.Compare(p.until) >= 0 {
+		return false
+	}
+	return true
+}
+
+// validate makes sure that value is accepted by the parameter.
+func (p parameter) validate(value string) error {
+	switch p.kind {
+	case kindInteger:
+		if _, err := strconv.ParseUint(value, 10, 64); err != nil {
+			return fmt.Errorf("must be a non-negative integer")
+		}
+	case kindBoolean:
+		if value != "true" && value != "false" {
+			return fmt.Errorf("must be either true or false")
+		}
+	case kindSize:
+		if !sizeRegex.MatchString(value) {
+			return fmt.Errorf("must be a non-negative integer, optionally suffixed with K, M or G")
+		}
+	case kindEnum:
+		for _, v := range p.values {
+			if value == v {
+				return nil
+			}
+		}
+		return fmt.Errorf("must be one of %v", p.values)
+	}
+	return nil
+}
+
+// Validate makes sure that every parameter in overrides can be set in the
+// specified context of the configuration of the specified version of
+// Aerospike, and that its value is valid.
+func Validate(version versioning.Version, ctx Context, overrides map[string]string) error {
+	for _, name := range sortedKeys(overrides) {
+		p, ok := schema[ctx][name]
+		if !ok || p.managed {
+			return fmt.Errorf("%s is not a known parameter of the %s context or is managed by aerospike-operator", name, ctx)
+		}
+		if !p.supports(version) {
+			return fmt.Errorf("%s is not supported in the %s context by aerospike version %v", name, ctx, version)
+		}
+		if err := p.validate(overrides[name]); err != nil {
+			return fmt.Errorf("invalid value %q for %s in the %s context: %v", overrides[name], name, ctx, err)
+		}
+	}
+	return nil
+}
+
+// Merge returns the values of the parameters in defaults and overrides in the
+// specified context of the configuration of the specified version of
+// Aerospike. Overrides take precedence over defaults, and defaults that are
+// not supported by version are left out.
+func Merge(version versioning.Version, ctx Context, defaults, overrides map[string]string) map[string]string {
+	res := make(map[string]string, len(defaults)+len(overrides))
+	for name, value := range defaults {
+		if IsSupported(version, ctx, name) {
+			res[name] = value
+		}
+	}
+	for name, value := range overrides {
+		res[name] = value
+	}
+	return res
+}
+
+// Lines returns the lines (of the form "<name> <value>", sorted by name) that
+// set the parameters in params.
+func Lines(params map[string]string) []string {
+	res := make([]string, 0, len(params))
+	for _, name := range sortedKeys(params) {
+		res = append(res, fmt.Sprintf("%s %s", name, params[name]))
+	}
+	return res
+}
+
+// sortedKeys returns the keys of m in ascending order.
+func sortedKeys(m map[string]string) []string {
+	res := make([]string, 0, len(m))
+	for key := range m {
+		res = append(res, key)
+	}
+	sort.Strings(res)
+	return res
+}
@@ -0,0 +1,99 @@
+/*
+Copyright 2019 The aerospike-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package asconfig
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/travelaudience/aerospike-operator/pkg/versioning"
+)
+
+var (
+	v4_3_0_10 = versioning.Version{Major: 4, Minor: 3, Patch: 0, Revision: 10}
+	v4_7_0_5  = versioning.Version{Major: 4, Minor: 7, Patch: 0, Revision: 5}
+	v5_6_0_3  = versioning.Version{Major: 5, Minor: 6, Patch: 0, Revision: 3}
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		version   versioning.Version
+		ctx       Context
+		overrides map[string]string
+		valid     bool
+	}{
+		{v4_3_0_10, ContextService, nil, true},
+		{v4_3_0_10, ContextService, map[string]string{"service-threads": "8", "nsup-period": "120"}, true},
+		{v4_3_0_10, ContextLogging, map[string]string{"any": "warning", "migrate": "debug"}, true},
+		{v4_3_0_10, ContextHeartbeat, map[string]string{"interval": "150", "timeout": "20"}, true},
+		{v4_3_0_10, ContextNamespace, map[string]string{"high-water-memory-pct": "60", "stop-writes-pct": "90"}, true},
+		{v4_3_0_10, ContextStorageEngine, map[string]string{"write-block-size": "1M"}, true},
+		{v4_7_0_5, ContextNamespace, map[string]string{"nsup-period": "120"}, true},
+		// unknown parameter
+		{v4_3_0_10, ContextService, map[string]string{"service-thread": "8"}, false},
+		// parameter managed by aerospike-operator
+		{v4_3_0_10, ContextService, map[string]string{"node-id": "a1"}, false},
+		{v4_3_0_10, ContextNamespace, map[string]string{"replication-factor": "2"}, false},
+		{v4_3_0_10, ContextNamespace, map[string]string{"default-ttl": "0"}, false},
+		// parameter of another context
+		{v4_3_0_10, ContextNamespace, map[string]string{"write-block-size": "1M"}, false},
+		// parameter not supported by the version
+		{v4_3_0_10, ContextNamespace, map[string]string{"nsup-period": "120"}, false},
+		{v4_7_0_5, ContextService, map[string]string{"nsup-period": "120"}, false},
+		{v4_7_0_5, ContextService, map[string]string{"transaction-queues": "4"}, false},
+		// invalid values
+		{v4_3_0_10, ContextService, map[string]string{"service-threads": "-1"}, false},
+		{v4_3_0_10, ContextService, map[string]string{"query-in-transaction-thread": "yes"}, false},
+		{v4_3_0_10, ContextStorageEngine, map[string]string{"write-block-size": "1MB"}, false},
+		{v4_3_0_10, ContextLogging, map[string]string{"any": "verbose"}, false},
+	}
+	for _, test := range tests {
+		err := Validate(test.version, test.ctx, test.overrides)
+		assert.Equal(t, test.valid, err == nil, "%v %s %v: %v", test.version, test.ctx, test.overrides, err)
+	}
+}
+
+func TestMerge(t *testing.T) {
+	defaults := map[string]string{
+		"service-threads":    "4",
+		"transaction-queues": "4",
+	}
+	overrides := map[string]string{
+		"service-threads": "8",
+		"proto-fd-max":    "20000",
+	}
+	assert.Equal(t, map[string]string{"proto-fd-max": "20000", "service-threads": "8", "transaction-queues": "4"}, Merge(v4_3_0_10, ContextService, defaults, overrides))
+	// defaults not supported by the version are left out
+	assert.Equal(t, map[string]string{"proto-fd-max": "20000", "service-threads": "8"}, Merge(v4_7_0_5, ContextService, defaults, overrides))
+	assert.Equal(t, []string{"service-threads 4", "transaction-queues 4"}, Lines(Merge(v4_3_0_10, ContextService, defaults, nil)))
+	// security is enabled by the presence of the security context since 5.6
+	security := map[string]string{"enable-security": "true"}
+	assert.Equal(t, []string{"enable-security true"}, Lines(Merge(v4_3_0_10, ContextSecurity, security, nil)))
+	assert.Equal(t, []string{}, Lines(Merge(v5_6_0_3, ContextSecurity, security, nil)))
+}
+
+func TestIsDynamic(t *testing.T) {
+	assert.True(t, IsDynamic(v4_7_0_5, ContextNamespace, "high-water-memory-pct"))
+	assert.True(t, IsDynamic(v4_7_0_5, ContextNamespace, "nsup-period"))
+	assert.False(t, IsDynamic(v4_7_0_5, ContextNamespace, "single-bin"))
+	// parameters not supported by the version are never dynamic
+	assert.False(t, IsDynamic(v4_3_0_10, ContextNamespace, "nsup-period"))
+	assert.True(t, IsDynamic(v4_3_0_10, ContextLogging, "migrate"))
+	assert.False(t, IsDynamic(v4_3_0_10, ContextStorageEngine, "write-block-size"))
+	assert.True(t, IsDynamic(v4_3_0_10, ContextNamespace, "default-ttl"))
+}
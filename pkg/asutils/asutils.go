@@ -23,15 +23,68 @@ import (
 	"time"
 
 	as "github.com/aerospike/aerospike-client-go"
+	"github.com/aerospike/aerospike-client-go/types"
 )
 
 const timeout = 10 * time.Second
 
-func GetClusterSize(host string, port int) (int, error) {
-	c, err := as.NewConnection(fmt.Sprintf("%s:%d", host, port), timeout)
+// Credentials holds the credentials used to authenticate against an Aerospike cluster with security enabled.
+type Credentials struct {
+	User     string
+	Password string
+}
+
+// NewConnection opens a connection to the Aerospike server at the specified address, authenticating with creds unless
+// it is nil or security is not enabled on the server.
+func NewConnection(address string, timeout time.Duration, creds *Credentials) (*as.Connection, error) {
+	c, err := as.NewConnection(address, timeout)
+	if err != nil {
+		return nil, err
+	}
+	if creds == nil {
+		return c, nil
+	}
+	if err := c.Authenticate(creds.User, creds.Password); err != nil && !HasResultCode(err, types.SECURITY_NOT_ENABLED) {
+		c.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+// NewClientPolicy returns a client policy that authenticates with creds unless it is nil.
+func NewClientPolicy(creds *Credentials) *as.ClientPolicy {
+	policy := as.NewClientPolicy()
+	policy.Timeout = timeout
+	if creds != nil {
+		policy.User = creds.User
+		policy.Password = creds.Password
+	}
+	return policy
+}
+
+// HasResultCode returns whether err is an error returned by the Aerospike server with the specified result code.
+func HasResultCode(err error, code types.ResultCode) bool {
+	ae, ok := err.(types.AerospikeError)
+	return ok && ae.ResultCode() == code
+}
+
+// IsAuthenticationError returns whether err is an error returned by the Aerospike server when the credentials used
+// to authenticate are not valid.
+func IsAuthenticationError(err error) bool {
+	return HasResultCode(err, types.INVALID_USER) ||
+		HasResultCode(err, types.INVALID_PASSWORD) ||
+		HasResultCode(err, types.INVALID_CREDENTIAL) ||
+		HasResultCode(err, types.NOT_AUTHENTICATED)
+}
+
+// GetClusterSize returns the size of the Aerospike cluster as seen by the server running at the specified host and
+// port, authenticating with creds unless it is nil.
+func GetClusterSize(host string, port int, creds *Credentials) (int, error) {
+	c, err := NewConnection(fmt.Sprintf("%s:%d", host, port), timeout, creds)
 	if err != nil {
 		return 0, err
 	}
+	defer c.Close()
 	r, err := as.RequestInfo(c, "statistics")
 	if err != nil {
 		return 0, err
@@ -43,6 +96,25 @@ func GetClusterSize(host string, port int) (int, error) {
 	}
 }
 
+// GetVersion returns the version of the Aerospike server running at the specified host and port, authenticating with
+// creds unless it is nil.
+func GetVersion(host string, port int, creds *Credentials) (string, error) {
+	c, err := NewConnection(fmt.Sprintf("%s:%d", host, port), timeout, creds)
+	if err != nil {
+		return "", err
+	}
+	defer c.Close()
+	r, err := as.RequestInfo(c, "build")
+	if err != nil {
+		return "", err
+	}
+	if version, ok := r["build"]; !ok || version == "" {
+		return "", fmt.Errorf("build is not present")
+	} else {
+		return version, nil
+	}
+}
+
 // ParseStatistics parses a string in the form a=b;c=d; into a map[string]string, trimming whitespace in the process.
 func ParseStatistics(stats string) map[string]string {
 	res := make(map[string]string)
@@ -0,0 +1,384 @@
+/*
+Copyright 2019 The aerospike-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azure
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/travelaudience/aerospike-operator/pkg/backuprestore/objectstorage"
+)
+
+const (
+	// apiVersion is the version of the Blob service REST API used by AzureClient.
+	apiVersion = "2018-11-09"
+	// defaultBlockSize is the size of each block of a block blob uploaded in
+	// multiple requests.
+	defaultBlockSize = 16 * 1024 * 1024
+	// requestTimeout is the timeout applied to requests that do not stream data.
+	requestTimeout = 1 * time.Minute
+)
+
+// Credentials holds the credentials used to access an Azure storage account.
+// Exactly one of AccountKey and SASToken must be specified.
+type Credentials struct {
+	// AccountKey is the (base64-encoded) shared key of the storage account.
+	AccountKey string `json:"accountKey,omitempty"`
+	// SASToken is a shared access signature granting access to the container.
+	SASToken string `json:"sasToken,omitempty"`
+}
+
+// Options holds the configuration of an AzureClient.
+type Options struct {
+	// StorageAccount is the name of the storage account containing the container.
+	StorageAccount string
+	// Endpoint is the URL of the Blob service. Defaults to the public Azure
+	// endpoint for StorageAccount.
+	Endpoint string
+}
+
+type AzureClient struct {
+	httpClient    *http.Client
+	endpoint      *url.URL
+	account       string
+	containerName string
+	accountKey    []byte
+	sasToken      url.Values
+	blockSize     int
+	now           func() time.Time
+}
+
+// ParseCredentials parses the JSON representation of a Credentials object and checks that it is valid.
+func ParseCredentials(jsonBytes []byte) (*Credentials, error) {
+	creds := &Credentials{}
+	if err := json.Unmarshal(jsonBytes, creds); err != nil {
+		return nil, fmt.Errorf("failed to parse azure credentials: %v", err)
+	}
+	switch {
+	case creds.AccountKey == "" && creds.SASToken == "":
+		return nil, fmt.Errorf("azure credentials must contain either an account key or a sas token")
+	case creds.AccountKey != "" && creds.SASToken != "":
+		return nil, fmt.Errorf("azure credentials must not contain both an account key and a sas token")
+	case creds.AccountKey != "":
+		if _, err := base64.StdEncoding.DecodeString(creds.AccountKey); err != nil {
+			return nil, fmt.Errorf("azure account key is not valid base64: %v", err)
+		}
+	default:
+		if _, err := parseSASToken(creds.SASToken); err != nil {
+			return nil, err
+		}
+	}
+	return creds, nil
+}
+
+// ParseEndpoint returns the URL of the Blob service described by the specified options.
+func ParseEndpoint(opts Options) (*url.URL, error) {
+	if opts.StorageAccount == "" {
+		return nil, fmt.Errorf("azure storage account must be specified")
+	}
+	endpoint := opts.Endpoint
+	if endpoint == "" {
+		endpoint = fmt.Sprintf("https://%s.blob.core.windows.net", opts.StorageAccount)
+	}
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid azure endpoint %q: %v", endpoint, err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("invalid azure endpoint %q: scheme must be http or https", endpoint)
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("invalid azure endpoint %q: missing host", endpoint)
+	}
+	if u.RawQuery != "" {
+		return nil, fmt.Errorf("invalid azure endpoint %q: must not contain a query", endpoint)
+	}
+	u.Path = strings.TrimSuffix(u.Path, "/")
+	u.RawPath = ""
+	return u, nil
+}
+
+// NewAzureClientFromJSON returns a new AzureClient for the specified container,
+// loading the credentials from the given JSON string
+func NewAzureClientFromJSON(jsonBytes []byte, containerName string, opts Options) (*AzureClient, error) {
+	creds, err := ParseCredentials(jsonBytes)
+	if err != nil {
+		return nil, err
+	}
+	endpoint, err := ParseEndpoint(opts)
+	if err != nil {
+		return nil, err
+	}
+	client := &AzureClient{
+		httpClient:    &http.Client{},
+		endpoint:      endpoint,
+		account:       opts.StorageAccount,
+		containerName: containerName,
+		blockSize:     defaultBlockSize,
+		now:           time.Now,
+	}
+	if creds.AccountKey != "" {
+		// the account key has already been validated by ParseCredentials
+		client.accountKey, _ = base64.StdEncoding.DecodeString(creds.AccountKey)
+	} else {
+		client.sasToken, _ = parseSASToken(creds.SASToken)
+	}
+	return client, nil
+}
+
+// Close closes the AzureClient. It exists so that AzureClient can be used
+// interchangeably with clients for other storage backends.
+func (c *AzureClient) Close() error {
+	return nil
+}
+
+// NewReader returns a reader for the contents of the specified object.
+func (c *AzureClient) NewReader(objectName string) (io.ReadCloser, error) {
+	res, err := c.do(http.MethodGet, objectName, nil, nil, nil, 0)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode != http.StatusOK {
+		defer res.Body.Close()
+		return nil, errorFromResponse(res)
+	}
+	return res.Body, nil
+}
+
+// NewWriter returns a writer that uploads the data written to it to the
+// specified object. The upload is only complete when Close returns.
+func (c *AzureClient) NewWriter(objectName string) (objectstorage.Writer, error) {
+	return &writer{
+		client:     c,
+		objectName: objectName,
+	}, nil
+}
+
+// DeleteObject deletes the specified object.
+func (c *AzureClient) DeleteObject(objectName string) error {
+	res, err := c.do(http.MethodDelete, objectName, nil, nil, nil, requestTimeout)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusAccepted {
+		return errorFromResponse(res)
+	}
+	return nil
+}
+
+// blobURL returns the URL of the specified blob.
+func (c *AzureClient) blobURL(objectName string, query url.Values) *url.URL {
+	u := *c.endpoint
+	u.Path = u.Path + "/" + c.containerName + "/" + objectName
+	u.RawPath = ""
+	q := url.Values{}
+	for k, v := range query {
+		q[k] = v
+	}
+	for k, v := range c.sasToken {
+		q[k] = v
+	}
+	u.RawQuery = q.Encode()
+	return &u
+}
+
+// do builds, authorizes and performs a request against the specified blob.
+func (c *AzureClient) do(method, objectName string, query url.Values, headers map[string]string, body []byte, timeout time.Duration) (*http.Response, error) {
+	req, err := http.NewRequest(method, c.blobURL(objectName, query).String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.ContentLength = int64(len(body))
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	req.Header.Set("X-Ms-Version", apiVersion)
+	req.Header.Set("X-Ms-Date", c.now().UTC().Format(http.TimeFormat))
+	if c.accountKey != nil {
+		signSharedKey(req, c.account, c.accountKey)
+	}
+	client := c.httpClient
+	if timeout > 0 {
+		client = &http.Client{Transport: c.httpClient.Transport, Timeout: timeout}
+	}
+	return client.Do(req)
+}
+
+// writer buffers the data written to it and uploads it as a block blob, using
+// multiple blocks whenever the data exceeds the block size.
+type writer struct {
+	client     *AzureClient
+	objectName string
+	buf        bytes.Buffer
+	blockIDs   []string
+	err        error
+	closed     bool
+}
+
+func (w *writer) Write(p []byte) (int, error) {
+	if w.err != nil {
+		return 0, w.err
+	}
+	if w.closed {
+		return 0, fmt.Errorf("write on closed writer")
+	}
+	n, _ := w.buf.Write(p)
+	for w.buf.Len() >= w.client.blockSize {
+		if w.err = w.putBlock(w.buf.Next(w.client.blockSize)); w.err != nil {
+			return n, w.err
+		}
+	}
+	return n, nil
+}
+
+func (w *writer) Close() error {
+	if w.closed {
+		return w.err
+	}
+	w.closed = true
+	if w.err != nil {
+		return w.err
+	}
+	// if no blocks have been uploaded yet, upload the data in a single request
+	if len(w.blockIDs) == 0 {
+		w.err = w.putBlob(w.buf.Bytes())
+		return w.err
+	}
+	// upload the remaining data as the last block and commit the block list
+	if w.buf.Len() > 0 {
+		if w.err = w.putBlock(w.buf.Bytes()); w.err != nil {
+			return w.err
+		}
+	}
+	w.err = w.putBlockList()
+	return w.err
+}
+
+// Abort aborts the upload, so that the blob is neither created nor replaced.
+// Blocks that have already been uploaded are never committed, and are
+// discarded by the storage service.
+func (w *writer) Abort() error {
+	if w.closed {
+		return nil
+	}
+	w.closed = true
+	if w.err == nil {
+		w.err = fmt.Errorf("upload aborted")
+	}
+	return nil
+}
+
+func (w *writer) putBlob(data []byte) error {
+	res, err := w.client.do(http.MethodPut, w.objectName, nil, map[string]string{
+		"X-Ms-Blob-Type": "BlockBlob",
+	}, data, 0)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusCreated {
+		return errorFromResponse(res)
+	}
+	return nil
+}
+
+func (w *writer) putBlock(data []byte) error {
+	// block ids must have the same length for every block in the blob
+	blockID := base64.StdEncoding.EncodeToString([]byte(fmt.Sprintf("%08d", len(w.blockIDs))))
+	res, err := w.client.do(http.MethodPut, w.objectName, url.Values{
+		"comp":    {"block"},
+		"blockid": {blockID},
+	}, nil, data, 0)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusCreated {
+		return errorFromResponse(res)
+	}
+	w.blockIDs = append(w.blockIDs, blockID)
+	return nil
+}
+
+func (w *writer) putBlockList() error {
+	body, err := xml.Marshal(blockList{Latest: w.blockIDs})
+	if err != nil {
+		return err
+	}
+	res, err := w.client.do(http.MethodPut, w.objectName, url.Values{
+		"comp": {"blocklist"},
+	}, nil, append([]byte(xml.Header), body...), requestTimeout)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusCreated {
+		return errorFromResponse(res)
+	}
+	return nil
+}
+
+type blockList struct {
+	XMLName xml.Name `xml:"BlockList"`
+	Latest  []string `xml:"Latest"`
+}
+
+type errorResponse struct {
+	Code    string `xml:"Code"`
+	Message string `xml:"Message"`
+}
+
+func errorFromResponse(res *http.Response) error {
+	b, _ := ioutil.ReadAll(res.Body)
+	e := &errorResponse{}
+	if err := xml.Unmarshal(b, e); err != nil || e.Code == "" {
+		// the error code is also reported in a header (e.g. for HEAD requests)
+		if code := res.Header.Get("X-Ms-Error-Code"); code != "" {
+			if code == "BlobNotFound" {
+				return objectstorage.ErrNotFound
+			}
+			return fmt.Errorf("azure request failed with status %d: %s", res.StatusCode, code)
+		}
+		return fmt.Errorf("azure request failed with status %d", res.StatusCode)
+	}
+	if e.Code == "BlobNotFound" {
+		return objectstorage.ErrNotFound
+	}
+	return fmt.Errorf("azure request failed with status %d: %s: %s", res.StatusCode, e.Code, strings.TrimSpace(strings.SplitN(e.Message, "\n", 2)[0]))
+}
+
+// parseSASToken parses the specified shared access signature.
+func parseSASToken(token string) (url.Values, error) {
+	v, err := url.ParseQuery(strings.TrimPrefix(token, "?"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse azure sas token: %v", err)
+	}
+	if v.Get("sig") == "" {
+		return nil, fmt.Errorf("azure sas token does not contain a signature")
+	}
+	return v, nil
+}
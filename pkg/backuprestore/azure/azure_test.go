@@ -0,0 +1,326 @@
+/*
+Copyright 2019 The aerospike-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azure
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/travelaudience/aerospike-operator/pkg/backuprestore/objectstorage"
+)
+
+const (
+	testAccount    = "devstoreaccount1"
+	testAccountKey = "Eby8vdM02xNOcqFlqUwJPLlmEtlCDXJ1OUzFT50uSRZ6IFsuFq2UVErCz4I6tq/K1SZFPTOtr/KBHBeksoGMGw=="
+)
+
+var (
+	testSharedKeyJSON = []byte(`{"accountKey":"` + testAccountKey + `"}`)
+	testSASTokenJSON  = []byte(`{"sasToken":"?sv=2018-11-09&sr=c&sp=rwd&sig=c2lnbmF0dXJl"}`)
+)
+
+// fakeBlobService is a minimal, in-memory implementation of the subset of the
+// Blob service API used by AzureClient (similar to what a local Azurite server
+// would provide).
+type fakeBlobService struct {
+	sync.Mutex
+	container string
+	blobs     map[string][]byte
+	blocks    map[string][]byte
+	requests  []*http.Request
+}
+
+func newFakeBlobService(container string) *fakeBlobService {
+	return &fakeBlobService{
+		container: container,
+		blobs:     make(map[string][]byte),
+		blocks:    make(map[string][]byte),
+	}
+}
+
+func (f *fakeBlobService) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.Lock()
+	defer f.Unlock()
+	f.requests = append(f.requests, r)
+
+	if !f.authorized(r) {
+		f.error(w, http.StatusForbidden, "AuthenticationFailed")
+		return
+	}
+	parts := strings.SplitN(strings.TrimPrefix(r.URL.Path, "/"), "/", 2)
+	if parts[0] != f.container {
+		f.error(w, http.StatusNotFound, "ContainerNotFound")
+		return
+	}
+	name := parts[1]
+	body, _ := ioutil.ReadAll(r.Body)
+	query := r.URL.Query()
+
+	switch {
+	case r.Method == http.MethodPut && query.Get("comp") == "block":
+		f.blocks[name+"/"+query.Get("blockid")] = body
+		w.WriteHeader(http.StatusCreated)
+	case r.Method == http.MethodPut && query.Get("comp") == "blocklist":
+		l := &blockList{}
+		if err := xml.Unmarshal(body, l); err != nil {
+			f.error(w, http.StatusBadRequest, "InvalidXmlDocument")
+			return
+		}
+		var data []byte
+		for _, id := range l.Latest {
+			block, ok := f.blocks[name+"/"+id]
+			if !ok {
+				f.error(w, http.StatusBadRequest, "InvalidBlockList")
+				return
+			}
+			data = append(data, block...)
+		}
+		f.blobs[name] = data
+		w.WriteHeader(http.StatusCreated)
+	case r.Method == http.MethodPut:
+		if r.Header.Get("X-Ms-Blob-Type") != "BlockBlob" {
+			f.error(w, http.StatusBadRequest, "MissingRequiredHeader")
+			return
+		}
+		f.blobs[name] = body
+		w.WriteHeader(http.StatusCreated)
+	case r.Method == http.MethodGet:
+		data, ok := f.blobs[name]
+		if !ok {
+			f.error(w, http.StatusNotFound, "BlobNotFound")
+			return
+		}
+		w.Write(data)
+	case r.Method == http.MethodDelete:
+		if _, ok := f.blobs[name]; !ok {
+			f.error(w, http.StatusNotFound, "BlobNotFound")
+			return
+		}
+		delete(f.blobs, name)
+		w.WriteHeader(http.StatusAccepted)
+	default:
+		f.error(w, http.StatusMethodNotAllowed, "UnsupportedHttpVerb")
+	}
+}
+
+// authorized checks whether r carries either a sas token or a valid shared
+// key signature.
+func (f *fakeBlobService) authorized(r *http.Request) bool {
+	if r.Header.Get("X-Ms-Version") == "" || r.Header.Get("X-Ms-Date") == "" {
+		return false
+	}
+	if r.URL.Query().Get("sig") != "" {
+		return true
+	}
+	key, _ := base64.StdEncoding.DecodeString(testAccountKey)
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(stringToSign(r, testAccount)))
+	return r.Header.Get("Authorization") == "SharedKey "+testAccount+":"+base64.StdEncoding.EncodeToString(h.Sum(nil))
+}
+
+func (f *fakeBlobService) error(w http.ResponseWriter, status int, code string) {
+	w.Header().Set("X-Ms-Error-Code", code)
+	w.WriteHeader(status)
+	fmt.Fprintf(w, "<?xml version=\"1.0\" encoding=\"utf-8\"?><Error><Code>%s</Code><Message>%s\nRequestId:0</Message></Error>", code, strings.ToLower(code))
+}
+
+func newTestClient(t *testing.T, container string, creds []byte) (*AzureClient, *fakeBlobService, func()) {
+	fake := newFakeBlobService(container)
+	server := httptest.NewServer(fake)
+	client, err := NewAzureClientFromJSON(creds, container, Options{
+		StorageAccount: testAccount,
+		Endpoint:       server.URL,
+	})
+	assert.NoError(t, err)
+	return client, fake, server.Close
+}
+
+func TestStringToSign(t *testing.T) {
+	req, err := http.NewRequest(http.MethodPut, "https://myaccount.blob.core.windows.net/mycontainer/a%20b.json?comp=block&blockid=MDAwMDAwMDA%3D", bytes.NewReader([]byte("data")))
+	assert.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Ms-Version", apiVersion)
+	req.Header.Set("X-Ms-Date", "Fri, 26 Jun 2015 23:39:12 GMT")
+	assert.Equal(t, strings.Join([]string{
+		"PUT",
+		"",
+		"",
+		"4",
+		"",
+		"application/json",
+		"",
+		"",
+		"",
+		"",
+		"",
+		"",
+		"x-ms-date:Fri, 26 Jun 2015 23:39:12 GMT",
+		"x-ms-version:2018-11-09",
+		"/myaccount/mycontainer/a%20b.json",
+		"blockid:MDAwMDAwMDA=",
+		"comp:block",
+	}, "\n"), stringToSign(req, "myaccount"))
+}
+
+func TestParseCredentials(t *testing.T) {
+	tests := []struct {
+		json  string
+		valid bool
+	}{
+		{string(testSharedKeyJSON), true},
+		{string(testSASTokenJSON), true},
+		{`{"sasToken":"sv=2018-11-09&sig=abc"}`, true},
+		{`{"sasToken":"sv=2018-11-09"}`, false},
+		{`{"accountKey":"not base64!"}`, false},
+		{`{"accountKey":"` + testAccountKey + `","sasToken":"sig=abc"}`, false},
+		{`{}`, false},
+		{`not-json`, false},
+	}
+	for _, test := range tests {
+		_, err := ParseCredentials([]byte(test.json))
+		if test.valid {
+			assert.NoError(t, err, test.json)
+		} else {
+			assert.Error(t, err, test.json)
+		}
+	}
+}
+
+func TestParseEndpoint(t *testing.T) {
+	tests := []struct {
+		opts     Options
+		expected string
+		valid    bool
+	}{
+		{Options{StorageAccount: "myaccount"}, "https://myaccount.blob.core.windows.net", true},
+		{Options{StorageAccount: "devstoreaccount1", Endpoint: "http://azurite:10000/devstoreaccount1/"}, "http://azurite:10000/devstoreaccount1", true},
+		{Options{}, "", false},
+		{Options{StorageAccount: "myaccount", Endpoint: "ftp://azurite:10000"}, "", false},
+		{Options{StorageAccount: "myaccount", Endpoint: "azurite:10000"}, "", false},
+		{Options{StorageAccount: "myaccount", Endpoint: "https://azurite:10000?a=b"}, "", false},
+	}
+	for _, test := range tests {
+		u, err := ParseEndpoint(test.opts)
+		if test.valid {
+			assert.NoError(t, err)
+			assert.Equal(t, test.expected, u.String())
+		} else {
+			assert.Error(t, err)
+		}
+	}
+}
+
+func TestReadWriteDelete(t *testing.T) {
+	for _, creds := range [][]byte{testSharedKeyJSON, testSASTokenJSON} {
+		for _, size := range []int{0, 10, 1024, 1024*3 + 7} {
+			client, fake, closeFn := newTestClient(t, "backups", creds)
+			client.blockSize = 1024
+			client.now = func() time.Time { return time.Date(2019, time.January, 1, 0, 0, 0, 0, time.UTC) }
+
+			data := bytes.Repeat([]byte("aerospike"), size)[:size]
+			w, err := client.NewWriter("backup.asb.gz")
+			assert.NoError(t, err)
+			// write in small chunks so that blocks are assembled across writes
+			for i := 0; i < len(data); i += 100 {
+				end := i + 100
+				if end > len(data) {
+					end = len(data)
+				}
+				_, err := w.Write(data[i:end])
+				assert.NoError(t, err)
+			}
+			assert.NoError(t, w.Close())
+			assert.Equal(t, data, fake.blobs["backup.asb.gz"])
+
+			r, err := client.NewReader("backup.asb.gz")
+			assert.NoError(t, err)
+			read, err := ioutil.ReadAll(r)
+			assert.NoError(t, err)
+			assert.NoError(t, r.Close())
+			assert.Equal(t, data, read)
+
+			assert.NoError(t, client.DeleteObject("backup.asb.gz"))
+			_, ok := fake.blobs["backup.asb.gz"]
+			assert.False(t, ok)
+			closeFn()
+		}
+	}
+}
+
+func TestBlockUpload(t *testing.T) {
+	client, fake, closeFn := newTestClient(t, "backups", testSharedKeyJSON)
+	defer closeFn()
+	client.blockSize = 10
+
+	w, err := client.NewWriter("backup.asb.gz")
+	assert.NoError(t, err)
+	_, err = w.Write(bytes.Repeat([]byte("a"), 25))
+	assert.NoError(t, err)
+	assert.NoError(t, w.Close())
+
+	var comps []string
+	for _, r := range fake.requests {
+		comps = append(comps, fmt.Sprintf("%s %s", r.Method, r.URL.Query().Get("comp")))
+	}
+	assert.Equal(t, []string{
+		"PUT block",
+		"PUT block",
+		"PUT block",
+		"PUT blocklist",
+	}, comps)
+}
+
+func TestErrors(t *testing.T) {
+	client, _, closeFn := newTestClient(t, "backups", testSharedKeyJSON)
+	defer closeFn()
+
+	// reading and deleting a missing blob must fail
+	_, err := client.NewReader("missing.json")
+	assert.Equal(t, objectstorage.ErrNotFound, err)
+	err = client.DeleteObject("missing.json")
+	assert.Equal(t, objectstorage.ErrNotFound, err)
+
+	// writing to a missing container must fail
+	client.containerName = "missing"
+	w, err := client.NewWriter("backup.json")
+	assert.NoError(t, err)
+	_, err = w.Write([]byte("{}"))
+	assert.NoError(t, err)
+	err = w.Close()
+	assert.Error(t, err)
+	assert.True(t, strings.Contains(err.Error(), "ContainerNotFound"), err.Error())
+
+	// requests signed with the wrong key must fail
+	client.containerName = "backups"
+	client.accountKey = []byte("wrong")
+	_, err = client.NewReader("backup.json")
+	assert.Error(t, err)
+	assert.True(t, strings.Contains(err.Error(), "AuthenticationFailed"), err.Error())
+}
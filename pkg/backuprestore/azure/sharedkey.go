@@ -0,0 +1,116 @@
+/*
+Copyright 2019 The aerospike-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azure
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// signSharedKey authorizes req using the Shared Key scheme, as described in
+//
+// https://docs.microsoft.com/en-us/rest/api/storageservices/authorize-with-shared-key
+func signSharedKey(req *http.Request, account string, key []byte) {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(stringToSign(req, account)))
+	signature := base64.StdEncoding.EncodeToString(h.Sum(nil))
+	req.Header.Set("Authorization", fmt.Sprintf("SharedKey %s:%s", account, signature))
+}
+
+// stringToSign returns the string that must be signed in order to authorize
+// req using the Shared Key scheme.
+func stringToSign(req *http.Request, account string) string {
+	// the content length must be empty when there is no content
+	contentLength := ""
+	if req.ContentLength > 0 {
+		contentLength = strconv.FormatInt(req.ContentLength, 10)
+	}
+	return strings.Join([]string{
+		req.Method,
+		req.Header.Get("Content-Encoding"),
+		req.Header.Get("Content-Language"),
+		contentLength,
+		req.Header.Get("Content-MD5"),
+		req.Header.Get("Content-Type"),
+		req.Header.Get("Date"),
+		req.Header.Get("If-Modified-Since"),
+		req.Header.Get("If-Match"),
+		req.Header.Get("If-None-Match"),
+		req.Header.Get("If-Unmodified-Since"),
+		req.Header.Get("Range"),
+		canonicalizedHeaders(req) + canonicalizedResource(req, account),
+	}, "\n")
+}
+
+// canonicalizedHeaders returns the x-ms-* headers of req sorted by name, one
+// per line.
+func canonicalizedHeaders(req *http.Request) string {
+	headers := make(map[string]string)
+	for name, values := range req.Header {
+		name = strings.ToLower(name)
+		if strings.HasPrefix(name, "x-ms-") {
+			headers[name] = strings.TrimSpace(strings.Join(values, ","))
+		}
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var b strings.Builder
+	for _, name := range names {
+		b.WriteString(name)
+		b.WriteString(":")
+		b.WriteString(headers[name])
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// canonicalizedResource returns the path of the resource targeted by req,
+// followed by its query parameters sorted by name.
+func canonicalizedResource(req *http.Request, account string) string {
+	var b strings.Builder
+	b.WriteString("/")
+	b.WriteString(account)
+	b.WriteString(req.URL.EscapedPath())
+	params := make(map[string][]string)
+	for k, v := range req.URL.Query() {
+		k = strings.ToLower(k)
+		params[k] = append(params[k], v...)
+	}
+	keys := make([]string, 0, len(params))
+	for k := range params {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		values := params[k]
+		sort.Strings(values)
+		b.WriteString("\n")
+		b.WriteString(k)
+		b.WriteString(":")
+		b.WriteString(strings.Join(values, ","))
+	}
+	return b.String()
+}
@@ -0,0 +1,257 @@
+/*
+Copyright 2019 The aerospike-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package compression implements the compression of backup data using the
+// supported codecs. zstd is implemented by running the zstd binary, which must
+// be present in the PATH.
+package compression
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"github.com/travelaudience/aerospike-operator/pkg/apis/aerospike/common"
+)
+
+const (
+	// DefaultCodec is the codec used when none is specified, as well as the
+	// codec used by backups that do not record which codec was used.
+	DefaultCodec = common.CompressionCodecGzip
+
+	// zstdBinary is the name of the binary used to compress and decompress
+	// data using zstd.
+	zstdBinary = "zstd"
+	// zstdMinLevel and zstdMaxLevel are the compression levels supported by
+	// zstd (not considering the --ultra levels).
+	zstdMinLevel = 1
+	zstdMaxLevel = 19
+)
+
+var (
+	// extensions holds the file extension used for each supported codec.
+	extensions = map[string]string{
+		common.CompressionCodecNone: "",
+		common.CompressionCodecGzip: ".gz",
+		common.CompressionCodecZstd: ".zst",
+	}
+)
+
+// Codecs returns the supported codecs.
+func Codecs() []string {
+	return []string{common.CompressionCodecNone, common.CompressionCodecGzip, common.CompressionCodecZstd}
+}
+
+// Extension returns the file extension used for data compressed with codec.
+func Extension(codec string) string {
+	return extensions[codec]
+}
+
+// Validate checks whether codec is supported and level is a valid compression
+// level for it. A level of 0 stands for the codec's default level.
+func Validate(codec string, level int) error {
+	switch codec {
+	case common.CompressionCodecNone:
+		if level != 0 {
+			return fmt.Errorf("a compression level cannot be specified when compression is disabled")
+		}
+	case common.CompressionCodecGzip:
+		if level != 0 && (level < gzip.BestSpeed || level > gzip.BestCompression) {
+			return fmt.Errorf("gzip compression level must be between %d and %d", gzip.BestSpeed, gzip.BestCompression)
+		}
+	case common.CompressionCodecZstd:
+		if level != 0 && (level < zstdMinLevel || level > zstdMaxLevel) {
+			return fmt.Errorf("zstd compression level must be between %d and %d", zstdMinLevel, zstdMaxLevel)
+		}
+	default:
+		return fmt.Errorf("unsupported compression codec %q", codec)
+	}
+	return nil
+}
+
+// NewWriter returns a writer that compresses the data written to it using
+// codec at the specified level, and writes it to w. Close must be called in
+// order to flush any pending data. Closing the returned writer does not close
+// w.
+func NewWriter(w io.Writer, codec string, level int) (io.WriteCloser, error) {
+	if err := Validate(codec, level); err != nil {
+		return nil, err
+	}
+	switch codec {
+	case common.CompressionCodecGzip:
+		if level == 0 {
+			level = gzip.DefaultCompression
+		}
+		return gzip.NewWriterLevel(w, level)
+	case common.CompressionCodecZstd:
+		args := []string{"-q", "-c"}
+		if level != 0 {
+			args = append(args, "-"+strconv.Itoa(level))
+		}
+		return newCommandWriter(w, zstdBinary, args...)
+	default:
+		return nopWriteCloser{w}, nil
+	}
+}
+
+// NewReader returns a reader that decompresses the data read from r using
+// codec. Close must be called in order to release any resources held by the
+// returned reader. Closing the returned reader does not close r.
+func NewReader(r io.Reader, codec string) (io.ReadCloser, error) {
+	if err := Validate(codec, 0); err != nil {
+		return nil, err
+	}
+	switch codec {
+	case common.CompressionCodecGzip:
+		return gzip.NewReader(r)
+	case common.CompressionCodecZstd:
+		return newCommandReader(r, zstdBinary, "-q", "-d", "-c")
+	default:
+		return nopReadCloser{r}, nil
+	}
+}
+
+// nopWriteCloser wraps an io.Writer, adding a no-op Close method.
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error {
+	return nil
+}
+
+// nopReadCloser wraps an io.Reader, adding a no-op Close method.
+type nopReadCloser struct {
+	io.Reader
+}
+
+func (nopReadCloser) Close() error {
+	return nil
+}
+
+// commandWriter is an io.WriteCloser that pipes the data written to it
+// through a command.
+type commandWriter struct {
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	stderr *bytes.Buffer
+}
+
+// newCommandWriter starts the specified command, writing its output to w.
+func newCommandWriter(w io.Writer, name string, args ...string) (*commandWriter, error) {
+	cmd := exec.Command(name, args...)
+	cmd.Stdout = w
+	stderr := &bytes.Buffer{}
+	cmd.Stderr = stderr
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	return &commandWriter{cmd: cmd, stdin: stdin, stderr: stderr}, nil
+}
+
+func (w *commandWriter) Write(p []byte) (int, error) {
+	return w.stdin.Write(p)
+}
+
+// Close closes the command's input and waits for it to terminate.
+func (w *commandWriter) Close() error {
+	if err := w.stdin.Close(); err != nil {
+		w.cmd.Wait()
+		return err
+	}
+	return commandError(w.cmd, w.cmd.Wait(), w.stderr)
+}
+
+// commandReader is an io.ReadCloser that reads the output of a command whose
+// input is read from another reader.
+type commandReader struct {
+	cmd    *exec.Cmd
+	stdout io.ReadCloser
+	stderr *bytes.Buffer
+	waited bool
+	err    error
+}
+
+// newCommandReader starts the specified command, reading its input from r.
+func newCommandReader(r io.Reader, name string, args ...string) (*commandReader, error) {
+	cmd := exec.Command(name, args...)
+	cmd.Stdin = r
+	stderr := &bytes.Buffer{}
+	cmd.Stderr = stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	return &commandReader{cmd: cmd, stdout: stdout, stderr: stderr}, nil
+}
+
+func (r *commandReader) Read(p []byte) (int, error) {
+	if r.waited {
+		if r.err != nil {
+			return 0, r.err
+		}
+		return 0, io.EOF
+	}
+	n, err := r.stdout.Read(p)
+	if err == io.EOF {
+		// make sure that the command has succeeded before reporting the end
+		// of the data
+		if werr := r.wait(); werr != nil {
+			return n, werr
+		}
+	}
+	return n, err
+}
+
+// Close waits for the command to terminate.
+func (r *commandReader) Close() error {
+	if r.waited {
+		return nil
+	}
+	// stop the command if not all of its output was read
+	r.cmd.Process.Kill()
+	r.wait()
+	return nil
+}
+
+func (r *commandReader) wait() error {
+	r.waited = true
+	r.err = commandError(r.cmd, r.cmd.Wait(), r.stderr)
+	return r.err
+}
+
+// commandError returns an error describing the failure of cmd, including its
+// standard error, or nil if err is nil.
+func commandError(cmd *exec.Cmd, err error, stderr *bytes.Buffer) error {
+	if err == nil {
+		return nil
+	}
+	if msg := strings.TrimSpace(stderr.String()); msg != "" {
+		return fmt.Errorf("%s failed: %v: %s", cmd.Path, err, msg)
+	}
+	return fmt.Errorf("%s failed: %v", cmd.Path, err)
+}
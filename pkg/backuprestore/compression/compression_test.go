@@ -0,0 +1,83 @@
+/*
+Copyright 2019 The aerospike-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package compression
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os/exec"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/travelaudience/aerospike-operator/pkg/apis/aerospike/common"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		codec string
+		level int
+		valid bool
+	}{
+		{common.CompressionCodecNone, 0, true},
+		{common.CompressionCodecNone, 1, false},
+		{common.CompressionCodecGzip, 0, true},
+		{common.CompressionCodecGzip, 9, true},
+		{common.CompressionCodecGzip, 10, false},
+		{common.CompressionCodecZstd, 0, true},
+		{common.CompressionCodecZstd, 19, true},
+		{common.CompressionCodecZstd, 20, false},
+		{common.CompressionCodecZstd, -1, false},
+		{"lz4", 0, false},
+	}
+	for _, test := range tests {
+		err := Validate(test.codec, test.level)
+		if test.valid {
+			assert.NoError(t, err)
+		} else {
+			assert.Error(t, err)
+		}
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	data := bytes.Repeat([]byte("aerospike"), 100000)
+	for _, codec := range Codecs() {
+		if codec == common.CompressionCodecZstd {
+			if _, err := exec.LookPath(zstdBinary); err != nil {
+				t.Logf("skipping %s: %v", codec, err)
+				continue
+			}
+		}
+		var buf bytes.Buffer
+		w, err := NewWriter(&buf, codec, 0)
+		assert.NoError(t, err)
+		_, err = w.Write(data)
+		assert.NoError(t, err)
+		assert.NoError(t, w.Close())
+		if codec != common.CompressionCodecNone {
+			assert.True(t, buf.Len() < len(data))
+		}
+
+		r, err := NewReader(&buf, codec)
+		assert.NoError(t, err)
+		res, err := ioutil.ReadAll(r)
+		assert.NoError(t, err)
+		assert.NoError(t, r.Close())
+		assert.Equal(t, data, res)
+	}
+}
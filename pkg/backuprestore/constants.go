@@ -19,4 +19,35 @@ package backuprestore
 const (
 	secretVolumeName      = "secret"
 	secretVolumeMountPath = "/secret"
+	pvcVolumeName         = "backup"
+
+	progressPortName = "progress"
+
+	encryptionVolumeName      = "encryption"
+	encryptionVolumeMountPath = "/encryption"
+
+	// deleteOperation is the operation performed by jobs that delete the data
+	// of a backup from a persistent volume claim.
+	deleteOperation = "delete"
+	// verifyOperation is the operation performed by jobs that verify the
+	// integrity of a backup without restoring it.
+	verifyOperation = "verify"
+
+	// terminationMessageMaxLength is the maximum length of the termination
+	// message of a failed job's pod to be included in the resource's
+	// conditions.
+	terminationMessageMaxLength = 512
+
+	// jobControllerUIDLabel is the label set by the job controller on the pods
+	// it creates, holding the uid of the job.
+	jobControllerUIDLabel = "controller-uid"
+
+	// UserEnvVar is the name of the environment variable holding the name of
+	// the user with which to authenticate against an aerospike cluster with
+	// security enabled.
+	UserEnvVar = "AEROSPIKE_USER"
+	// PasswordEnvVar is the name of the environment variable holding the
+	// password of the user with which to authenticate against an aerospike
+	// cluster with security enabled.
+	PasswordEnvVar = "AEROSPIKE_PASSWORD"
 )
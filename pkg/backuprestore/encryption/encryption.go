@@ -0,0 +1,238 @@
+/*
+Copyright 2019 The aerospike-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package encryption implements the client-side encryption of backup data.
+//
+// Data is encrypted using AES-256-GCM in chunks, so that it can be streamed.
+// The encrypted stream starts with a header made of a magic string and a
+// random salt, which is used to derive a key specific to the stream from the
+// user-provided key. The header is followed by a sequence of chunks, each
+// made of the length of its ciphertext, a flag indicating whether it is the
+// last chunk, and the ciphertext itself. Chunks are encrypted using their
+// index as the nonce and the flag as additional data, so that reordered,
+// truncated or otherwise tampered streams are detected.
+package encryption
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"io"
+)
+
+const (
+	// KeySize is the size in bytes of the keys used to encrypt backup data.
+	KeySize = 32
+
+	// chunkSize is the maximum size of the plaintext of each chunk.
+	chunkSize = 64 * 1024
+	// saltSize is the size of the random salt used to derive the key of each
+	// stream.
+	saltSize = 32
+
+	// flagLastChunk is the flag indicating that a chunk is the last one.
+	flagLastChunk byte = 1
+)
+
+var (
+	// magic identifies (version 1 of) the format of an encrypted stream.
+	magic = []byte("ASOENC01")
+)
+
+// ValidateKey checks whether key can be used to encrypt backup data.
+func ValidateKey(key []byte) error {
+	if len(key) != KeySize {
+		return fmt.Errorf("the encryption key must be %d bytes long (got %d)", KeySize, len(key))
+	}
+	return nil
+}
+
+// Fingerprint returns a fingerprint of key that can be safely stored alongside
+// the data encrypted with it in order to identify the key.
+func Fingerprint(key []byte) string {
+	m := hmac.New(sha256.New, key)
+	m.Write([]byte("fingerprint"))
+	return hex.EncodeToString(m.Sum(nil)[:16])
+}
+
+// newAEAD returns the AEAD used to encrypt the stream with the specified salt.
+func newAEAD(key, salt []byte) (cipher.AEAD, error) {
+	if err := ValidateKey(key); err != nil {
+		return nil, err
+	}
+	m := hmac.New(sha256.New, key)
+	m.Write(salt)
+	block, err := aes.NewCipher(m.Sum(nil))
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// nonce returns the nonce used to encrypt the chunk with the specified index.
+func nonce(aead cipher.AEAD, index uint64) []byte {
+	n := make([]byte, aead.NonceSize())
+	binary.BigEndian.PutUint64(n[len(n)-8:], index)
+	return n
+}
+
+// writer encrypts the data written to it and writes it to the underlying
+// writer.
+type writer struct {
+	w     io.Writer
+	aead  cipher.AEAD
+	buf   []byte
+	index uint64
+}
+
+// NewWriter returns a writer that encrypts the data written to it using key
+// and writes it to w. Close must be called in order to write the last chunk.
+// Closing the returned writer does not close w.
+func NewWriter(w io.Writer, key []byte) (io.WriteCloser, error) {
+	salt := make([]byte, saltSize)
+	if _, err := io.ReadFull(rand.Reader, salt); err != nil {
+		return nil, err
+	}
+	aead, err := newAEAD(key, salt)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(append(append([]byte{}, magic...), salt...)); err != nil {
+		return nil, err
+	}
+	return &writer{w: w, aead: aead, buf: make([]byte, 0, chunkSize)}, nil
+}
+
+func (w *writer) Write(p []byte) (int, error) {
+	n := 0
+	for len(p) > 0 {
+		// only write a chunk when more data follows it, as the last chunk is
+		// written by Close
+		if len(w.buf) == chunkSize {
+			if err := w.writeChunk(0); err != nil {
+				return n, err
+			}
+		}
+		c := copy(w.buf[len(w.buf):chunkSize], p)
+		w.buf = w.buf[:len(w.buf)+c]
+		p = p[c:]
+		n += c
+	}
+	return n, nil
+}
+
+// Close writes the last chunk to the underlying writer.
+func (w *writer) Close() error {
+	return w.writeChunk(flagLastChunk)
+}
+
+// writeChunk encrypts the buffered data and writes it as a chunk.
+func (w *writer) writeChunk(flag byte) error {
+	ciphertext := w.aead.Seal(nil, nonce(w.aead, w.index), w.buf, []byte{flag})
+	header := make([]byte, 5)
+	binary.BigEndian.PutUint32(header, uint32(len(ciphertext)))
+	header[4] = flag
+	if _, err := w.w.Write(header); err != nil {
+		return err
+	}
+	if _, err := w.w.Write(ciphertext); err != nil {
+		return err
+	}
+	w.buf = w.buf[:0]
+	w.index++
+	return nil
+}
+
+// reader decrypts the data read from the underlying reader.
+type reader struct {
+	r     io.Reader
+	aead  cipher.AEAD
+	buf   []byte
+	index uint64
+	done  bool
+}
+
+// NewReader returns a reader that decrypts the data read from r using key.
+func NewReader(r io.Reader, key []byte) (io.Reader, error) {
+	header := make([]byte, len(magic)+saltSize)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, fmt.Errorf("failed to read encryption header: %v", err)
+	}
+	if !bytes.Equal(header[:len(magic)], magic) {
+		return nil, fmt.Errorf("data is not encrypted or uses an unsupported format")
+	}
+	aead, err := newAEAD(key, header[len(magic):])
+	if err != nil {
+		return nil, err
+	}
+	return &reader{r: r, aead: aead}, nil
+}
+
+func (r *reader) Read(p []byte) (int, error) {
+	for len(r.buf) == 0 {
+		if r.done {
+			return 0, io.EOF
+		}
+		if err := r.readChunk(); err != nil {
+			return 0, err
+		}
+	}
+	n := copy(p, r.buf)
+	r.buf = r.buf[n:]
+	return n, nil
+}
+
+// readChunk reads and decrypts the next chunk.
+func (r *reader) readChunk() error {
+	header := make([]byte, 5)
+	if _, err := io.ReadFull(r.r, header); err != nil {
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return fmt.Errorf("encrypted data is truncated")
+		}
+		return err
+	}
+	length, flag := binary.BigEndian.Uint32(header), header[4]
+	if length > chunkSize+uint32(r.aead.Overhead()) {
+		return fmt.Errorf("encrypted data is corrupted")
+	}
+	ciphertext := make([]byte, length)
+	if _, err := io.ReadFull(r.r, ciphertext); err != nil {
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return fmt.Errorf("encrypted data is truncated")
+		}
+		return err
+	}
+	plaintext, err := r.aead.Open(nil, nonce(r.aead, r.index), ciphertext, []byte{flag})
+	if err != nil {
+		return fmt.Errorf("failed to decrypt data: wrong key or corrupted data")
+	}
+	r.buf = plaintext
+	r.index++
+	if flag == flagLastChunk {
+		r.done = true
+		// make sure that no data follows the last chunk
+		if n, _ := r.r.Read(make([]byte, 1)); n > 0 {
+			return fmt.Errorf("encrypted data is corrupted")
+		}
+	}
+	return nil
+}
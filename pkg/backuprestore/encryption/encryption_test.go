@@ -0,0 +1,91 @@
+/*
+Copyright 2019 The aerospike-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package encryption
+
+import (
+	"bytes"
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func encrypt(t *testing.T, data, key []byte) []byte {
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, key)
+	assert.NoError(t, err)
+	_, err = w.Write(data)
+	assert.NoError(t, err)
+	assert.NoError(t, w.Close())
+	return buf.Bytes()
+}
+
+func decrypt(data, key []byte) ([]byte, error) {
+	r, err := NewReader(bytes.NewReader(data), key)
+	if err != nil {
+		return nil, err
+	}
+	return ioutil.ReadAll(r)
+}
+
+func TestRoundTrip(t *testing.T) {
+	key := bytes.Repeat([]byte{1}, KeySize)
+	for _, size := range []int{0, 1, chunkSize - 1, chunkSize, chunkSize + 1, 3*chunkSize + 42} {
+		data := bytes.Repeat([]byte{'a'}, size)
+		res, err := decrypt(encrypt(t, data, key), key)
+		assert.NoError(t, err)
+		assert.Equal(t, data, res)
+	}
+}
+
+func TestDecryptInvalid(t *testing.T) {
+	key := bytes.Repeat([]byte{1}, KeySize)
+	encrypted := encrypt(t, bytes.Repeat([]byte{'a'}, 2*chunkSize+42), key)
+
+	tampered := append([]byte{}, encrypted...)
+	tampered[len(tampered)-1] ^= 1
+
+	tests := []struct {
+		data []byte
+		key  []byte
+	}{
+		// wrong key
+		{encrypted, bytes.Repeat([]byte{2}, KeySize)},
+		// invalid key
+		{encrypted, []byte("short")},
+		// unencrypted data
+		{bytes.Repeat([]byte{'a'}, 100), key},
+		// truncated data
+		{encrypted[:len(encrypted)-1], key},
+		{encrypted[:len(magic)+saltSize+5+chunkSize+16], key},
+		// tampered data
+		{tampered, key},
+		// trailing data
+		{append(append([]byte{}, encrypted...), 'a'), key},
+	}
+	for _, test := range tests {
+		_, err := decrypt(test.data, test.key)
+		assert.Error(t, err)
+	}
+}
+
+func TestFingerprint(t *testing.T) {
+	key1 := bytes.Repeat([]byte{1}, KeySize)
+	key2 := bytes.Repeat([]byte{2}, KeySize)
+	assert.Equal(t, Fingerprint(key1), Fingerprint(key1))
+	assert.NotEqual(t, Fingerprint(key1), Fingerprint(key2))
+}
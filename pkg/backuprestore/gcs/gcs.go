@@ -17,46 +17,41 @@ limitations under the License.
 package gcs
 
 import (
-	"compress/gzip"
 	"io"
 
 	"cloud.google.com/go/storage"
-	log "github.com/sirupsen/logrus"
 	"golang.org/x/net/context"
 	"golang.org/x/oauth2/google"
 	"google.golang.org/api/option"
+
+	"github.com/travelaudience/aerospike-operator/pkg/backuprestore/objectstorage"
 )
 
 type GCSClient struct {
-	client *storage.Client
+	client     *storage.Client
+	bucketName string
 }
 
-// NewGCSClientFromCredentials returns a new GCSClient loading the credentials
-// from the file present in the specified path
-func NewGCSClientFromCredentials(credentialsFilePath string) (*GCSClient, error) {
-	client, err := storage.NewClient(context.Background(), option.WithCredentialsFile(credentialsFilePath))
-	if err != nil {
-		return nil, err
-	}
-	return &GCSClient{
-		client: client,
-	}, nil
+// ParseCredentials parses the given service account credentials in JSON
+// format.
+func ParseCredentials(jsonBytes []byte) (*google.Credentials, error) {
+	return google.CredentialsFromJSON(context.Background(), jsonBytes, storage.ScopeReadWrite)
 }
 
-// NewGCSClientFromJSON returns a new GCSClient loading the
-// credentials from the given JSON string
-func NewGCSClientFromJSON(jsonBytes []byte) (*GCSClient, error) {
-	ctx := context.Background()
-	creds, err := google.CredentialsFromJSON(ctx, jsonBytes, storage.ScopeReadWrite)
+// NewGCSClientFromJSON returns a new GCSClient for the specified bucket,
+// loading the credentials from the given JSON string
+func NewGCSClientFromJSON(jsonBytes []byte, bucketName string) (*GCSClient, error) {
+	creds, err := ParseCredentials(jsonBytes)
 	if err != nil {
 		return nil, err
 	}
-	client, err := storage.NewClient(ctx, option.WithCredentials(creds))
+	client, err := storage.NewClient(context.Background(), option.WithCredentials(creds))
 	if err != nil {
 		return nil, err
 	}
 	return &GCSClient{
-		client: client,
+		client:     client,
+		bucketName: bucketName,
 	}, nil
 }
 
@@ -66,9 +61,9 @@ func (h *GCSClient) Close() error {
 }
 
 // GetObject returns a object handle
-func (h *GCSClient) GetObject(bucketName, objectName string) (*storage.ObjectHandle, error) {
+func (h *GCSClient) GetObject(objectName string) (*storage.ObjectHandle, error) {
 	// get a handle to the target bucket
-	bucket := h.client.Bucket(bucketName)
+	bucket := h.client.Bucket(h.bucketName)
 
 	// attempt to read the bucket metadata in
 	// order to know the bucket exists
@@ -76,67 +71,69 @@ func (h *GCSClient) GetObject(bucketName, objectName string) (*storage.ObjectHan
 		return nil, err
 	}
 
-	// delete the object
+	// return a handle to the object
 	return bucket.Object(objectName), nil
 }
 
-// DeleteObject deletes the content of the specified object
-func (h *GCSClient) DeleteObject(bucketName, objectName string) error {
-	// get the object
-	obj, err := h.GetObject(bucketName, objectName)
+// NewReader returns a reader for the contents of the specified object.
+func (h *GCSClient) NewReader(objectName string) (io.ReadCloser, error) {
+	obj, err := h.GetObject(objectName)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	// delete the object
-	return obj.Delete(context.Background())
+	r, err := obj.NewReader(context.Background())
+	if err != nil {
+		if err == storage.ErrObjectNotExist {
+			return nil, objectstorage.ErrNotFound
+		}
+		return nil, err
+	}
+	return r, nil
 }
 
-// TransferToGCS streams backup data to GCS.
-func (h *GCSClient) TransferToGCS(r io.Reader, bucketName, objectName string) error {
-	// get the object
-	obj, err := h.GetObject(bucketName, objectName)
+// NewWriter returns a writer that uploads the data written to it to the
+// specified object. The upload is only complete when Close returns.
+func (h *GCSClient) NewWriter(objectName string) (objectstorage.Writer, error) {
+	obj, err := h.GetObject(objectName)
 	if err != nil {
-		return err
-	}
-	// create a writer that writes to the target object
-	w := obj.NewWriter(context.Background())
-	defer w.Close()
-	// create a writer that gzips the backup data
-	gz := gzip.NewWriter(w)
-	defer gz.Close()
-	// copy the gziped backup data to the bucket
-	if s, err := io.Copy(gz, r); err != nil {
-		return err
-	} else {
-		log.Infof("%d bytes written", s)
-		return nil
+		return nil, err
 	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &writer{
+		Writer: obj.NewWriter(ctx),
+		cancel: cancel,
+	}, nil
 }
 
-// TransferFromGCS streams backup data from GCS.
-func (h *GCSClient) TransferFromGCS(w io.Writer, bucketName, objectName string) error {
+// DeleteObject deletes the content of the specified object
+func (h *GCSClient) DeleteObject(objectName string) error {
 	// get the object
-	obj, err := h.GetObject(bucketName, objectName)
-	if err != nil {
-		return err
-	}
-	// create a reader that reads from the source object
-	r, err := obj.NewReader(context.Background())
-	if err != nil {
-		return err
-	}
-	defer r.Close()
-	// create a reader that ungzips the backup data
-	gz, err := gzip.NewReader(r)
+	obj, err := h.GetObject(objectName)
 	if err != nil {
 		return err
 	}
-	defer gz.Close()
-	// read the gziped backup data from the bucket
-	if r, err := io.Copy(w, gz); err != nil {
+	// delete the object
+	if err := obj.Delete(context.Background()); err != storage.ErrObjectNotExist {
 		return err
-	} else {
-		log.Infof("%d bytes read", r)
-		return nil
 	}
+	return objectstorage.ErrNotFound
+}
+
+// writer wraps a storage.Writer so that the upload can be aborted.
+type writer struct {
+	*storage.Writer
+	cancel context.CancelFunc
+}
+
+func (w *writer) Close() error {
+	defer w.cancel()
+	return w.Writer.Close()
+}
+
+// Abort aborts the upload by cancelling its context, so that the object is
+// neither created nor replaced.
+func (w *writer) Abort() error {
+	w.cancel()
+	w.Writer.Close()
+	return nil
 }
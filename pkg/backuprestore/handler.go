@@ -31,6 +31,7 @@ import (
 	batchlistersv1 "k8s.io/client-go/listers/batch/v1"
 	"k8s.io/client-go/tools/record"
 
+	"github.com/travelaudience/aerospike-operator/pkg/apis/aerospike/common"
 	aerospikev1alpha2 "github.com/travelaudience/aerospike-operator/pkg/apis/aerospike/v1alpha2"
 	aerospikeclientset "github.com/travelaudience/aerospike-operator/pkg/client/clientset/versioned"
 	aerospikelisters "github.com/travelaudience/aerospike-operator/pkg/client/listers/aerospike/v1alpha2"
@@ -88,6 +89,18 @@ func (h *AerospikeBackupRestoreHandler) Handle(obj aerospikev1alpha2.BackupResto
 		logfields.Key:  meta.Key(obj),
 	}).Infof("processing %s", obj.GetOperationType())
 
+	// determine the backup to restore, failing if it cannot be found
+	if restore, ok := obj.(*aerospikev1alpha2.AerospikeNamespaceRestore); ok {
+		found, err := h.resolveSource(restore)
+		if err != nil {
+			return err
+		}
+		if !found {
+			obj.SyncStatusWithSpec()
+			return h.updateStatus(obj)
+		}
+	}
+
 	// get backupstoragespec from the "parent" aerospikecluster resource in case
 	// this field is not specified in the current resource
 	if obj.GetStorage() == nil {
@@ -98,26 +111,60 @@ func (h *AerospikeBackupRestoreHandler) Handle(obj aerospikev1alpha2.BackupResto
 		obj.SetStorage(&aerospikeCluster.Spec.BackupSpec.Storage)
 	}
 
-	// check whether the associated job exists, and create it if it doesn't
-	job, err := h.jobsLister.Jobs(obj.GetObjectMeta().Namespace).Get(h.getJobName(obj))
-	if err != nil {
-		if errors.IsNotFound(err) {
-			// get the secret containing the credentials to access cloud storage
-			secret, err := h.getSecret(obj)
-			if err != nil {
-				return err
-			}
-			// the job doesn't exist yet, so create it
-			if err := h.launchJob(obj, secret); err != nil {
+	// determine the backup on top of which an incremental backup is made
+	if backup, ok := obj.(*aerospikev1alpha2.AerospikeNamespaceBackup); ok {
+		if err := h.resolveBase(backup); err != nil {
+			return err
+		}
+	}
+
+	// assign the nodes of the target cluster to the shards of the backup
+	// before creating any job, and record the assignment so that it is used
+	// by every job
+	if backup, ok := obj.(*aerospikev1alpha2.AerospikeNamespaceBackup); ok && getShardCount(obj) > 1 && !hasShardNodes(backup) {
+		if err := h.assignShardNodes(backup); err != nil {
+			return err
+		}
+		// the jobs are created once the updated status is observed
+		obj.SyncStatusWithSpec()
+		return h.updateStatus(obj)
+	}
+
+	// check whether the associated jobs exist, and create them if they don't
+	var (
+		shards = getShardCount(obj)
+		jobs   = make([]*batch.Job, 0, shards)
+		secret *v1.Secret
+	)
+	for shard := 0; shard < shards; shard++ {
+		job, err := h.jobsLister.Jobs(obj.GetObjectMeta().Namespace).Get(h.getJobName(obj, shard))
+		if err == nil {
+			jobs = append(jobs, job)
+			continue
+		}
+		if !errors.IsNotFound(err) {
+			return err
+		}
+		// get the secret containing the credentials to access cloud storage
+		// (backups stored in a persistent volume claim require no credentials)
+		if secret == nil && obj.GetStorage().Type != common.StorageTypePVC {
+			if secret, err = h.getSecret(obj); err != nil {
 				return err
 			}
-		} else {
+		}
+		// the job doesn't exist yet, so create it
+		if err := h.launchJob(obj, shard, secret); err != nil {
 			return err
 		}
-	} else {
-		// at this point there is already an associated job, so we must check its
-		// status and report accordingly
-		h.maybeSetConditions(obj, job)
+	}
+	if len(jobs) == shards {
+		// at this point all the associated jobs exist, so we must check their
+		// status and progress and report accordingly
+		if backup, ok := obj.(*aerospikev1alpha2.AerospikeNamespaceBackup); ok {
+			h.updateShardStatus(backup, jobs)
+		}
+		h.updateProgress(obj, jobs)
+		h.maybeSetConditions(obj, jobs)
 	}
 	// sync .status with .spec
 	obj.SyncStatusWithSpec()
@@ -125,10 +172,10 @@ func (h *AerospikeBackupRestoreHandler) Handle(obj aerospikev1alpha2.BackupResto
 }
 
 // launchJob performs a number of checks and launches the job associated with
-// obj.
-func (h *AerospikeBackupRestoreHandler) launchJob(obj aerospikev1alpha2.BackupRestoreObject, secret *v1.Secret) error {
+// the specified shard of obj.
+func (h *AerospikeBackupRestoreHandler) launchJob(obj aerospikev1alpha2.BackupRestoreObject, shard int, secret *v1.Secret) error {
 	// create the backup/restore job
-	job, err := h.createJob(obj, secret)
+	job, err := h.createJob(obj, shard, secret)
 	if err != nil {
 		return err
 	}
@@ -142,37 +189,72 @@ func (h *AerospikeBackupRestoreHandler) launchJob(obj aerospikev1alpha2.BackupRe
 	h.recorder.Eventf(obj.(runtime.Object),
 		v1.EventTypeNormal, events.ReasonJobCreated,
 		"%s job created as %s", obj.GetOperationType(), meta.Key(job))
-	// append a condition to the resource indicating the current status
+	// append a condition to the resource indicating the current status. when
+	// the operation is split into more than one shard, a single condition is
+	// appended for all of them.
+	if shard > 0 {
+		return nil
+	}
+	msg := fmt.Sprintf("%s job created as %s", obj.GetOperationType(), meta.Key(job))
+	if shards := getShardCount(obj); shards > 1 {
+		msg = fmt.Sprintf("%s jobs created for %d shards", obj.GetOperationType(), shards)
+	}
 	condition := apiextensions.CustomResourceDefinitionCondition{
 		LastTransitionTime: metav1.NewTime(time.Now()),
 		Type:               obj.GetStartedConditionType(),
 		Status:             apiextensions.ConditionTrue,
-		Message:            fmt.Sprintf("%s job created as %s", obj.GetOperationType(), meta.Key(job)),
+		Message:            msg,
 	}
 	obj.SetConditions(append(obj.GetConditions(), condition))
 	return nil
 }
 
-// maybeSetConditions checks the status of the job associated with obj and updates the
-// resource's conditions.
-func (h *AerospikeBackupRestoreHandler) maybeSetConditions(obj aerospikev1alpha2.BackupRestoreObject, job *batch.Job) {
-	var jobCondition batch.JobConditionType
-
-	// look for the complete or failed condition in the associated job
-	for _, c := range job.Status.Conditions {
-		if c.Type == batch.JobComplete && c.Status == v1.ConditionTrue {
-			jobCondition = batch.JobComplete
-			break
-		}
-		if c.Type == batch.JobFailed && c.Status == v1.ConditionTrue {
-			jobCondition = batch.JobFailed
-			break
+// maybeSetConditions checks the status of the jobs associated with obj and
+// updates the resource's conditions. The operation has failed as soon as one
+// of the jobs fails, and has finished when all of them finish.
+func (h *AerospikeBackupRestoreHandler) maybeSetConditions(obj aerospikev1alpha2.BackupRestoreObject, jobs []*batch.Job) {
+	var (
+		finished    int
+		failedJob   *batch.Job
+		failedShard int
+	)
+	for shard, job := range jobs {
+		switch getJobCondition(job) {
+		case batch.JobComplete:
+			finished++
+		case batch.JobFailed:
+			if failedJob == nil {
+				failedJob, failedShard = job, shard
+			}
 		}
 	}
 
-	// update the resource's status based on the job condition
-	switch jobCondition {
-	case batch.JobComplete:
+	// update the resource's status based on the job conditions
+	switch {
+	case failedJob != nil:
+		// log that the job failed
+		log.WithFields(log.Fields{
+			logfields.Kind: obj.GetKind(),
+			logfields.Key:  meta.Key(obj),
+		}).Debugf("%s job failed %d times", obj.GetOperationType(), failedJob.Status.Failed)
+		// include the reason for the failure as reported by the job, if any
+		msg := fmt.Sprintf("%s job failed %d times", obj.GetOperationType(), failedJob.Status.Failed)
+		if len(jobs) > 1 {
+			msg = fmt.Sprintf("%s job for shard %d failed %d times", obj.GetOperationType(), failedShard, failedJob.Status.Failed)
+		}
+		if reason := h.getTerminationMessage(failedJob); reason != "" {
+			msg = fmt.Sprintf("%s: %s", msg, reason)
+		}
+		// record an event indicating failure
+		h.recorder.Event(obj.(runtime.Object), v1.EventTypeWarning, events.ReasonJobFailed, msg)
+		// append a jobCondition to the resource's status indicating failure
+		obj.SetConditions(append(obj.GetConditions(), apiextensions.CustomResourceDefinitionCondition{
+			LastTransitionTime: metav1.NewTime(time.Now()),
+			Type:               obj.GetFailedConditionType(),
+			Status:             apiextensions.ConditionTrue,
+			Message:            msg,
+		}))
+	case finished == len(jobs):
 		// log that the job was successful
 		log.WithFields(log.Fields{
 			logfields.Kind: obj.GetKind(),
@@ -188,21 +270,19 @@ func (h *AerospikeBackupRestoreHandler) maybeSetConditions(obj aerospikev1alpha2
 			Status:             apiextensions.ConditionTrue,
 			Message:            fmt.Sprintf("%s job has finished", obj.GetOperationType()),
 		}))
-	case batch.JobFailed:
-		// log that the job failed
-		log.WithFields(log.Fields{
-			logfields.Kind: obj.GetKind(),
-			logfields.Key:  meta.Key(obj),
-		}).Debugf("%s job failed %d times", obj.GetOperationType(), job.Status.Failed)
-		// record an event indicating failure
-		h.recorder.Eventf(obj.(runtime.Object), v1.EventTypeWarning, events.ReasonJobFailed,
-			"%s job failed %d times", obj.GetOperationType(), job.Status.Failed)
-		// append a jobCondition to the resource's status indicating failure
-		obj.SetConditions(append(obj.GetConditions(), apiextensions.CustomResourceDefinitionCondition{
-			LastTransitionTime: metav1.NewTime(time.Now()),
-			Type:               obj.GetFailedConditionType(),
-			Status:             apiextensions.ConditionTrue,
-			Message:            fmt.Sprintf("%s job failed %d times", obj.GetOperationType(), job.Status.Failed),
-		}))
 	}
 }
+
+// getJobCondition returns the type of the condition indicating that job has
+// completed or failed, or an empty string if it is still running.
+func getJobCondition(job *batch.Job) batch.JobConditionType {
+	for _, c := range job.Status.Conditions {
+		if c.Type == batch.JobComplete && c.Status == v1.ConditionTrue {
+			return batch.JobComplete
+		}
+		if c.Type == batch.JobFailed && c.Status == v1.ConditionTrue {
+			return batch.JobFailed
+		}
+	}
+	return ""
+}
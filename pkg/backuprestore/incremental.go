@@ -0,0 +1,115 @@
+/*
+Copyright 2019 The aerospike-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package backuprestore
+
+import (
+	"sort"
+
+	log "github.com/sirupsen/logrus"
+	"k8s.io/apiextensions-apiserver/pkg/apis/apiextensions"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	aerospikev1alpha2 "github.com/travelaudience/aerospike-operator/pkg/apis/aerospike/v1alpha2"
+	"github.com/travelaudience/aerospike-operator/pkg/logfields"
+	"github.com/travelaudience/aerospike-operator/pkg/meta"
+)
+
+// resolveBase determines the backup on top of which the incremental backup
+// described by backup is made, and records its name in the resource's status.
+// The base backup is determined only once, before the backup jobs are created.
+func (h *AerospikeBackupRestoreHandler) resolveBase(backup *aerospikev1alpha2.AerospikeNamespaceBackup) error {
+	if !backup.Spec.IsIncremental() || backup.Status.BaseBackup != "" || hasStarted(backup) {
+		return nil
+	}
+	backups, err := h.aerospikeclientset.AerospikeV1alpha2().AerospikeNamespaceBackups(backup.Namespace).List(metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+	if base := FindBaseBackup(backups.Items, backup); base != nil {
+		backup.Status.BaseBackup = base.Name
+		log.WithFields(log.Fields{
+			logfields.Kind: backup.GetKind(),
+			logfields.Key:  meta.Key(backup),
+		}).Debugf("backing up the records modified since backup %s", base.Name)
+	}
+	return nil
+}
+
+// FindBaseBackup returns the latest successful backup of the same Aerospike
+// cluster and namespace as backup, created before it, stored in the same
+// location and including the same records and bins, or nil if there is no
+// such backup.
+func FindBaseBackup(backups []aerospikev1alpha2.AerospikeNamespaceBackup, backup *aerospikev1alpha2.AerospikeNamespaceBackup) *aerospikev1alpha2.AerospikeNamespaceBackup {
+	candidates := make([]aerospikev1alpha2.AerospikeNamespaceBackup, 0, len(backups))
+	for _, b := range backups {
+		if b.Name != backup.Name && isSameLocation(b.Status.Storage, backup.Spec.Storage) && hasSameFilters(&b.Status.AerospikeNamespaceBackupSpec, &backup.Spec) {
+			candidates = append(candidates, b)
+		}
+	}
+	return FindLatestBackup(candidates, backup.Spec.Target, backup.CreationTimestamp.Time)
+}
+
+// isSameLocation returns whether backups stored as specified in a and b are
+// stored in the same bucket (or persistent volume claim directory), and can
+// thus read each other's metadata.
+func isSameLocation(a, b *aerospikev1alpha2.BackupStorageSpec) bool {
+	if a == nil || b == nil || a.Type != b.Type || a.Bucket != b.Bucket {
+		return false
+	}
+	if a.PVC != nil && b.PVC != nil {
+		return a.PVC.ClaimName == b.PVC.ClaimName
+	}
+	return true
+}
+
+// hasSameFilters returns whether backups made as specified in a and b include
+// the same sets, bins and records, so that one can be used as the base of the
+// other.
+func hasSameFilters(a, b *aerospikev1alpha2.AerospikeNamespaceBackupSpec) bool {
+	return hasSameElements(a.Sets, b.Sets) &&
+		hasSameElements(a.Bins, b.Bins) &&
+		a.ModifiedAfter.Equal(b.ModifiedAfter) &&
+		a.ModifiedBefore.Equal(b.ModifiedBefore)
+}
+
+// hasSameElements returns whether a and b contain the same elements,
+// regardless of their order.
+func hasSameElements(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	sortedA := append([]string(nil), a...)
+	sortedB := append([]string(nil), b...)
+	sort.Strings(sortedA)
+	sort.Strings(sortedB)
+	for i := range sortedA {
+		if sortedA[i] != sortedB[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// hasStarted returns whether the jobs associated with obj have been created.
+func hasStarted(obj aerospikev1alpha2.BackupRestoreObject) bool {
+	for _, c := range obj.GetConditions() {
+		if c.Type == obj.GetStartedConditionType() && c.Status == apiextensions.ConditionTrue {
+			return true
+		}
+	}
+	return false
+}
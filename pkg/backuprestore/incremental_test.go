@@ -0,0 +1,98 @@
+/*
+Copyright 2019 The aerospike-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package backuprestore
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apiextensions-apiserver/pkg/apis/apiextensions"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/travelaudience/aerospike-operator/pkg/apis/aerospike/common"
+	aerospikev1alpha2 "github.com/travelaudience/aerospike-operator/pkg/apis/aerospike/v1alpha2"
+)
+
+func TestFindBaseBackup(t *testing.T) {
+	target := aerospikev1alpha2.TargetNamespace{Cluster: "as-cluster-0", Namespace: "as-namespace-0"}
+	newBackup := func(name string, hour int, bucket string, finished bool) aerospikev1alpha2.AerospikeNamespaceBackup {
+		backup := aerospikev1alpha2.AerospikeNamespaceBackup{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              name,
+				CreationTimestamp: metav1.NewTime(time.Date(2019, time.January, 1, hour, 0, 0, 0, time.UTC)),
+			},
+		}
+		backup.Spec.Target = target
+		backup.Spec.Storage = &aerospikev1alpha2.BackupStorageSpec{Type: common.StorageTypeGCS, Bucket: bucket}
+		backup.Status.AerospikeNamespaceBackupSpec = backup.Spec
+		if finished {
+			backup.Status.Conditions = []apiextensions.CustomResourceDefinitionCondition{
+				{
+					Type:   common.ConditionBackupFinished,
+					Status: apiextensions.ConditionTrue,
+				},
+			}
+		}
+		return backup
+	}
+
+	withFilters := func(backup aerospikev1alpha2.AerospikeNamespaceBackup, sets, bins []string, modifiedBefore *metav1.Time) aerospikev1alpha2.AerospikeNamespaceBackup {
+		backup.Spec.Sets = sets
+		backup.Spec.Bins = bins
+		backup.Spec.ModifiedBefore = modifiedBefore
+		backup.Status.AerospikeNamespaceBackupSpec = backup.Spec
+		return backup
+	}
+	before := metav1.NewTime(time.Date(2019, time.February, 1, 0, 0, 0, 0, time.UTC))
+
+	backups := []aerospikev1alpha2.AerospikeNamespaceBackup{
+		newBackup("a", 1, "bucket-0", true),
+		newBackup("b", 2, "bucket-1", true),
+		newBackup("c", 3, "bucket-0", false),
+		newBackup("d", 5, "bucket-0", true),
+		withFilters(newBackup("f", 7, "bucket-0", true), []string{"set-0", "set-1"}, nil, nil),
+		withFilters(newBackup("g", 8, "bucket-0", true), nil, []string{"bin-0"}, nil),
+		withFilters(newBackup("h", 9, "bucket-0", true), nil, nil, &before),
+	}
+
+	tests := []struct {
+		backup   aerospikev1alpha2.AerospikeNamespaceBackup
+		expected string
+	}{
+		// backups stored in other buckets and unfinished backups are ignored
+		{newBackup("e", 4, "bucket-0", false), "a"},
+		{newBackup("e", 4, "bucket-1", false), "b"},
+		// backups created afterwards are ignored
+		{newBackup("e", 0, "bucket-0", false), ""},
+		{newBackup("e", 6, "bucket-0", false), "d"},
+		{newBackup("e", 6, "bucket-2", false), ""},
+		// backups including other sets, bins or records are ignored
+		{newBackup("e", 10, "bucket-0", false), "d"},
+		{withFilters(newBackup("e", 10, "bucket-0", false), []string{"set-1", "set-0"}, nil, nil), "f"},
+		{withFilters(newBackup("e", 10, "bucket-0", false), []string{"set-0"}, nil, nil), ""},
+		{withFilters(newBackup("e", 10, "bucket-0", false), nil, []string{"bin-0"}, nil), "g"},
+		{withFilters(newBackup("e", 10, "bucket-0", false), nil, nil, &before), "h"},
+	}
+	for _, test := range tests {
+		var name string
+		if base := FindBaseBackup(backups, &test.backup); base != nil {
+			name = base.Name
+		}
+		assert.Equal(t, test.expected, name)
+	}
+}
@@ -18,19 +18,25 @@ package backuprestore
 
 import (
 	"fmt"
+	"strings"
+	"time"
 
 	log "github.com/sirupsen/logrus"
 	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 
+	"github.com/travelaudience/aerospike-operator/pkg/apis/aerospike/common"
 	aerospikev1alpha2 "github.com/travelaudience/aerospike-operator/pkg/apis/aerospike/v1alpha2"
+	"github.com/travelaudience/aerospike-operator/pkg/backuprestore/pvc"
 	"github.com/travelaudience/aerospike-operator/pkg/debug"
+	"github.com/travelaudience/aerospike-operator/pkg/images"
 	"github.com/travelaudience/aerospike-operator/pkg/logfields"
 	"github.com/travelaudience/aerospike-operator/pkg/meta"
 	"github.com/travelaudience/aerospike-operator/pkg/pointers"
 	"github.com/travelaudience/aerospike-operator/pkg/utils/selectors"
-	"github.com/travelaudience/aerospike-operator/pkg/versioning"
 )
 
 const (
@@ -39,15 +45,170 @@ const (
 	jobBackoffLimit = 3
 )
 
-// createJob creates the job associated with obj.
-func (h *AerospikeBackupRestoreHandler) createJob(obj aerospikev1alpha2.BackupRestoreObject, secret *corev1.Secret) (*batchv1.Job, error) {
-	secretKey := obj.GetStorage().GetSecretKey()
-	if _, ok := secret.Data[secretKey]; !ok {
-		return nil, fmt.Errorf("secret does not contain expected field %q", secretKey)
+// createJob creates the job associated with the specified shard of obj.
+func (h *AerospikeBackupRestoreHandler) createJob(obj aerospikev1alpha2.BackupRestoreObject, shard int, secret *corev1.Secret) (*batchv1.Job, error) {
+	// use the images requested for the target cluster, if it exists, as well
+	// as its credentials if security is enabled
+	var (
+		imagesSpec *aerospikev1alpha2.AerospikeClusterImagesSpec
+		security   *aerospikev1alpha2.AerospikeClusterSecuritySpec
+	)
+	aerospikeCluster, err := h.aerospikeClustersLister.AerospikeClusters(obj.GetNamespace()).Get(obj.GetTarget().Cluster)
+	if err == nil {
+		imagesSpec = aerospikeCluster.Spec.Images
+		security = aerospikeCluster.Spec.Security
+	} else if !errors.IsNotFound(err) {
+		return nil, err
+	}
+
+	job, err := newJob(obj, getJobOperation(obj), shard, secret, imagesSpec, security)
+	if err != nil {
+		return nil, err
 	}
-	job := batchv1.Job{
+
+	res, err := h.kubeclientset.BatchV1().Jobs(obj.GetObjectMeta().Namespace).Create(job)
+	if err != nil {
+		return nil, err
+	}
+
+	log.WithFields(log.Fields{
+		logfields.Job: meta.Key(res),
+	}).Debugf("%s job created", obj.GetOperationType())
+	return res, nil
+}
+
+// NewDeleteJob returns a job that deletes the data of asBackup from the
+// persistent volume claim where it is stored.
+func NewDeleteJob(asBackup *aerospikev1alpha2.AerospikeNamespaceBackup) (*batchv1.Job, error) {
+	if asBackup.Spec.Storage == nil || asBackup.Spec.Storage.Type != common.StorageTypePVC {
+		return nil, fmt.Errorf("delete jobs are only supported for backups stored in a persistent volume claim")
+	}
+	return newJob(asBackup, deleteOperation, 0, nil, nil, nil)
+}
+
+// GetDeleteJobName returns the name of the job that deletes the data of
+// asBackup.
+func GetDeleteJobName(asBackup *aerospikev1alpha2.AerospikeNamespaceBackup) string {
+	return fmt.Sprintf("%s-%s", asBackup.Name, deleteOperation)
+}
+
+// newJob returns a job that performs the specified operation on the specified
+// shard of obj. secret must be nil when the backup is stored in a persistent
+// volume claim. imagesSpec holds the images requested for the target cluster,
+// if any, and security its security configuration, if security is enabled.
+func newJob(obj aerospikev1alpha2.BackupRestoreObject, operation string, shard int, secret *corev1.Secret, imagesSpec *aerospikev1alpha2.AerospikeClusterImagesSpec, security *aerospikev1alpha2.AerospikeClusterSecuritySpec) (*batchv1.Job, error) {
+	storage := obj.GetStorage()
+
+	args := []string{
+		"backup",
+		operation,
+		fmt.Sprintf("-debug=%t", debug.DebugEnabled),
+		fmt.Sprintf("-storage-type=%s", storage.Type),
+		fmt.Sprintf("-bucket-name=%s", storage.Bucket),
+		fmt.Sprintf("-name=%s", obj.GetBackupName()),
+	}
+	if operation != deleteOperation && operation != verifyOperation {
+		args = append(args,
+			fmt.Sprintf("-host=%s.%s", obj.GetTarget().Cluster, obj.GetNamespace()),
+			fmt.Sprintf("-namespace=%s", obj.GetTarget().Namespace),
+		)
+	}
+	if shards := getShardCount(obj); shards > 1 {
+		switch operation {
+		case string(common.OperationTypeBackup):
+			args = append(args, fmt.Sprintf("-shard=%d", shard), fmt.Sprintf("-shards=%d", shards))
+			// back up the nodes assigned to the shard when the backup started
+			backup := obj.(*aerospikev1alpha2.AerospikeNamespaceBackup)
+			if shard >= len(backup.Status.Shards) || len(backup.Status.Shards[shard].Nodes) == 0 {
+				return nil, fmt.Errorf("no nodes have been assigned to shard %d", shard)
+			}
+			args = append(args, fmt.Sprintf("-nodes=%s", strings.Join(backup.Status.Shards[shard].Nodes, ",")))
+		case deleteOperation:
+			// restores find the shards of the backup in its metadata
+			args = append(args, fmt.Sprintf("-shards=%d", shards))
+		}
+	}
+	args = append(args, getStorageArgs(storage)...)
+	if backup, ok := obj.(*aerospikev1alpha2.AerospikeNamespaceBackup); ok && operation == string(common.OperationTypeBackup) {
+		args = append(args, getBackupFilterArgs(backup)...)
+	}
+	if restore, ok := obj.(*aerospikev1alpha2.AerospikeNamespaceRestore); ok && operation == string(common.OperationTypeRestore) {
+		args = append(args, getRestoreOptionsArgs(restore.Spec.Options)...)
+	}
+	if operation == string(common.OperationTypeBackup) {
+		// restores read the codec from the backup metadata
+		args = append(args, fmt.Sprintf("-compression=%s", storage.GetCompressionCodec()))
+		if level := storage.GetCompressionLevel(); level != 0 {
+			args = append(args, fmt.Sprintf("-compression-level=%d", level))
+		}
+	}
+
+	var (
+		env          []corev1.EnvVar
+		volumes      []corev1.Volume
+		volumeMounts []corev1.VolumeMount
+	)
+	if operation != deleteOperation && operation != verifyOperation {
+		// authenticate against the target cluster using its admin credentials
+		env = CredentialsEnv(security)
+	}
+	if storage.Type == common.StorageTypePVC {
+		// mount the persistent volume claim where backups are stored
+		volumes = append(volumes, corev1.Volume{
+			Name: pvcVolumeName,
+			VolumeSource: corev1.VolumeSource{
+				PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+					ClaimName: storage.PVC.ClaimName,
+				},
+			},
+		})
+		volumeMounts = append(volumeMounts, corev1.VolumeMount{
+			Name:      pvcVolumeName,
+			MountPath: pvc.MountPath,
+		})
+	} else {
+		// mount the secret containing the credentials to access cloud storage
+		secretKey := storage.GetSecretKey()
+		if _, ok := secret.Data[secretKey]; !ok {
+			return nil, fmt.Errorf("secret does not contain expected field %q", secretKey)
+		}
+		args = append(args, fmt.Sprintf("-secret-path=%s/%s", secretVolumeMountPath, secretKey))
+		volumes = append(volumes, corev1.Volume{
+			Name: secretVolumeName,
+			VolumeSource: corev1.VolumeSource{
+				Secret: &corev1.SecretVolumeSource{
+					SecretName: secret.Name,
+				},
+			},
+		})
+		volumeMounts = append(volumeMounts, corev1.VolumeMount{
+			Name:      secretVolumeName,
+			ReadOnly:  true,
+			MountPath: secretVolumeMountPath,
+		})
+	}
+
+	if storage.Encryption != nil && operation != deleteOperation {
+		// mount the secret containing the key used to encrypt the backup data
+		args = append(args, fmt.Sprintf("-encryption-key-path=%s/%s", encryptionVolumeMountPath, storage.Encryption.GetSecretKey()))
+		volumes = append(volumes, corev1.Volume{
+			Name: encryptionVolumeName,
+			VolumeSource: corev1.VolumeSource{
+				Secret: &corev1.SecretVolumeSource{
+					SecretName: storage.Encryption.Secret,
+				},
+			},
+		})
+		volumeMounts = append(volumeMounts, corev1.VolumeMount{
+			Name:      encryptionVolumeName,
+			ReadOnly:  true,
+			MountPath: encryptionVolumeMountPath,
+		})
+	}
+
+	return &batchv1.Job{
 		ObjectMeta: metav1.ObjectMeta{
-			Name: h.getJobName(obj),
+			Name: getJobNameForOperation(obj, operation, shard),
 			Labels: map[string]string{
 				selectors.LabelAppKey:       selectors.LabelAppVal,
 				selectors.LabelClusterKey:   obj.GetTarget().Cluster,
@@ -68,63 +229,172 @@ func (h *AerospikeBackupRestoreHandler) createJob(obj aerospikev1alpha2.BackupRe
 		Spec: batchv1.JobSpec{
 			Template: corev1.PodTemplateSpec{
 				ObjectMeta: metav1.ObjectMeta{
-					Name:      string(obj.GetOperationType()),
+					Name:      operation,
 					Namespace: obj.GetObjectMeta().Namespace,
 				},
 				Spec: corev1.PodSpec{
 					Containers: []corev1.Container{
 						{
 							Name:            "aerospike-operator-tools",
-							Image:           fmt.Sprintf("%s:%s", "quay.io/travelaudience/aerospike-operator-tools", versioning.OperatorVersion),
-							ImagePullPolicy: corev1.PullAlways,
-							Command: []string{
-								"backup",
-								string(obj.GetOperationType()),
-								fmt.Sprintf("-debug=%t", debug.DebugEnabled),
-								fmt.Sprintf("-bucket-name=%s", obj.GetStorage().Bucket),
-								fmt.Sprintf("-name=%s", obj.GetObjectMeta().Name),
-								fmt.Sprintf("-secret-path=%s/%s", secretVolumeMountPath, secretKey),
-								fmt.Sprintf("-host=%s.%s", obj.GetTarget().Cluster, obj.GetNamespace()),
-								fmt.Sprintf("-namespace=%s", obj.GetTarget().Namespace),
-							},
-							VolumeMounts: []corev1.VolumeMount{
+							Image:           images.Tools(imagesSpec),
+							ImagePullPolicy: images.PullPolicy(imagesSpec, corev1.PullAlways),
+							Command:         args,
+							Env:             env,
+							VolumeMounts:    volumeMounts,
+							Ports: []corev1.ContainerPort{
 								{
-									Name:      secretVolumeName,
-									ReadOnly:  true,
-									MountPath: secretVolumeMountPath,
-								},
-							},
-						},
-					},
-					RestartPolicy: corev1.RestartPolicyNever,
-					Volumes: []corev1.Volume{
-						{
-							Name: secretVolumeName,
-							VolumeSource: corev1.VolumeSource{
-								Secret: &corev1.SecretVolumeSource{
-									SecretName: secret.Name,
+									Name:          progressPortName,
+									ContainerPort: ProgressPort,
 								},
 							},
 						},
 					},
+					ImagePullSecrets: images.PullSecrets(imagesSpec),
+					RestartPolicy:    corev1.RestartPolicyNever,
+					Volumes:          volumes,
 				},
 			},
 			BackoffLimit: pointers.NewInt32(jobBackoffLimit),
 		},
+	}, nil
+}
+
+// getJobName returns the name of the job associated with the specified shard
+// of obj.
+func (h *AerospikeBackupRestoreHandler) getJobName(obj aerospikev1alpha2.BackupRestoreObject, shard int) string {
+	return getJobNameForOperation(obj, getJobOperation(obj), shard)
+}
+
+// getJobNameForOperation returns the name of the job that performs the
+// specified operation on the specified shard of obj. backups that are not
+// split keep the name used before backups could be split.
+func getJobNameForOperation(obj aerospikev1alpha2.BackupRestoreObject, operation string, shard int) string {
+	if operation == string(common.OperationTypeBackup) && getShardCount(obj) > 1 {
+		return fmt.Sprintf("%s-%s-%d", obj.GetName(), operation, shard)
+	}
+	return fmt.Sprintf("%s-%s", obj.GetName(), operation)
+}
+
+// getTerminationMessage returns the termination message of the most recently
+// failed pod created by job, or an empty string if there is none.
+func (h *AerospikeBackupRestoreHandler) getTerminationMessage(job *batchv1.Job) string {
+	pods, err := h.listJobPods(job)
+	if err != nil {
+		log.WithFields(log.Fields{
+			logfields.Job: meta.Key(job),
+		}).Warnf("failed to list pods: %v", err)
+		return ""
+	}
+	var (
+		msg    string
+		latest metav1.Time
+	)
+	for _, pod := range pods {
+		for _, status := range pod.Status.ContainerStatuses {
+			t := status.State.Terminated
+			if t == nil || t.ExitCode == 0 || t.Message == "" || t.FinishedAt.Before(&latest) {
+				continue
+			}
+			msg, latest = t.Message, t.FinishedAt
+		}
+	}
+	if len(msg) > terminationMessageMaxLength {
+		msg = msg[:terminationMessageMaxLength]
 	}
+	return strings.TrimSpace(msg)
+}
 
-	res, err := h.kubeclientset.BatchV1().Jobs(obj.GetObjectMeta().Namespace).Create(&job)
+// listJobPods returns the pods created by job.
+func (h *AerospikeBackupRestoreHandler) listJobPods(job *batchv1.Job) ([]corev1.Pod, error) {
+	pods, err := h.kubeclientset.CoreV1().Pods(job.Namespace).List(metav1.ListOptions{
+		LabelSelector: labels.SelectorFromSet(map[string]string{jobControllerUIDLabel: string(job.UID)}).String(),
+	})
 	if err != nil {
 		return nil, err
 	}
+	return pods.Items, nil
+}
 
-	log.WithFields(log.Fields{
-		logfields.Job: meta.Key(res),
-	}).Debugf("%s job created", obj.GetOperationType())
-	return res, nil
+// getJobOperation returns the operation performed by the job associated with
+// obj.
+func getJobOperation(obj aerospikev1alpha2.BackupRestoreObject) string {
+	if restore, ok := obj.(*aerospikev1alpha2.AerospikeNamespaceRestore); ok && restore.IsVerifyOnly() {
+		return verifyOperation
+	}
+	return string(obj.GetOperationType())
+}
+
+// getStorageArgs returns the type-specific command-line arguments describing
+// the cloud storage backend specified in storage.
+func getStorageArgs(storage *aerospikev1alpha2.BackupStorageSpec) []string {
+	args := make([]string, 0)
+	if storage.S3 != nil {
+		if storage.S3.Endpoint != nil {
+			args = append(args, fmt.Sprintf("-s3-endpoint=%s", *storage.S3.Endpoint))
+		}
+		if storage.S3.Region != nil {
+			args = append(args, fmt.Sprintf("-s3-region=%s", *storage.S3.Region))
+		}
+		if storage.S3.ForcePathStyle != nil {
+			args = append(args, fmt.Sprintf("-s3-force-path-style=%t", *storage.S3.ForcePathStyle))
+		}
+	}
+	if storage.Azure != nil {
+		args = append(args, fmt.Sprintf("-azure-storage-account=%s", storage.Azure.StorageAccount))
+		if storage.Azure.Endpoint != nil {
+			args = append(args, fmt.Sprintf("-azure-endpoint=%s", *storage.Azure.Endpoint))
+		}
+	}
+	return args
+}
+
+// getBackupFilterArgs returns the arguments that instruct the backup job to
+// backup only the records selected by the spec of backup, as well as the
+// name of the base backup of an incremental backup.
+func getBackupFilterArgs(backup *aerospikev1alpha2.AerospikeNamespaceBackup) []string {
+	args := make([]string, 0)
+	if len(backup.Spec.Sets) > 0 {
+		args = append(args, fmt.Sprintf("-sets=%s", strings.Join(backup.Spec.Sets, ",")))
+	}
+	if len(backup.Spec.Bins) > 0 {
+		args = append(args, fmt.Sprintf("-bins=%s", strings.Join(backup.Spec.Bins, ",")))
+	}
+	if backup.Spec.ModifiedAfter != nil {
+		args = append(args, fmt.Sprintf("-modified-after=%s", backup.Spec.ModifiedAfter.UTC().Format(time.RFC3339)))
+	}
+	if backup.Spec.ModifiedBefore != nil {
+		args = append(args, fmt.Sprintf("-modified-before=%s", backup.Spec.ModifiedBefore.UTC().Format(time.RFC3339)))
+	}
+	if backup.Status.BaseBackup != "" {
+		args = append(args, fmt.Sprintf("-base=%s", backup.Status.BaseBackup))
+	}
+	return args
 }
 
-// getJobName returns the name of the job associated with obj.
-func (h *AerospikeBackupRestoreHandler) getJobName(obj aerospikev1alpha2.BackupRestoreObject) string {
-	return fmt.Sprintf("%s-%s", obj.GetName(), obj.GetOperationType())
+// getRestoreOptionsArgs returns the arguments that instruct the restore job to
+// write the data of the backup as specified in options.
+func getRestoreOptionsArgs(options *aerospikev1alpha2.RestoreOptions) []string {
+	args := make([]string, 0)
+	if options == nil {
+		return args
+	}
+	if options.WritePolicy != nil {
+		args = append(args, fmt.Sprintf("-write-policy=%s", *options.WritePolicy))
+	}
+	if options.NoGeneration != nil {
+		args = append(args, fmt.Sprintf("-no-generation=%t", *options.NoGeneration))
+	}
+	if len(options.Sets) > 0 {
+		args = append(args, fmt.Sprintf("-sets=%s", strings.Join(options.Sets, ",")))
+	}
+	if len(options.Bins) > 0 {
+		args = append(args, fmt.Sprintf("-bins=%s", strings.Join(options.Bins, ",")))
+	}
+	if options.IgnoreRecordErrors != nil {
+		args = append(args, fmt.Sprintf("-ignore-record-errors=%t", *options.IgnoreRecordErrors))
+	}
+	if options.TTLExtension != nil {
+		args = append(args, fmt.Sprintf("-ttl-extension=%d", *options.TTLExtension))
+	}
+	return args
 }
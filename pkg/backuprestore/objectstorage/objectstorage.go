@@ -0,0 +1,38 @@
+/*
+Copyright 2019 The aerospike-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package objectstorage holds the types shared by the clients of every
+// supported backup storage backend.
+package objectstorage
+
+import (
+	"errors"
+	"io"
+)
+
+// ErrNotFound is the error returned by the clients of every supported backup
+// storage backend when reading or deleting an object that does not exist.
+var ErrNotFound = errors.New("object not found")
+
+// Writer is the interface implemented by the writers returned by the clients
+// of every supported backup storage backend.
+type Writer interface {
+	io.WriteCloser
+	// Abort aborts the upload, so that the object is neither created nor
+	// replaced. Close must only be called when every write has succeeded, as
+	// it completes the upload.
+	Abort() error
+}
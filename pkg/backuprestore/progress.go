@@ -0,0 +1,252 @@
+/*
+Copyright 2019 The aerospike-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package backuprestore
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	aerospikev1alpha2 "github.com/travelaudience/aerospike-operator/pkg/apis/aerospike/v1alpha2"
+	"github.com/travelaudience/aerospike-operator/pkg/logfields"
+	"github.com/travelaudience/aerospike-operator/pkg/meta"
+	"github.com/travelaudience/aerospike-operator/pkg/pointers"
+)
+
+const (
+	// ProgressPort is the port on which backup/restore jobs report their
+	// progress.
+	ProgressPort = 8080
+	// ProgressPath is the path at which backup/restore jobs report their
+	// progress.
+	ProgressPath = "/progress"
+
+	// progressUpdateInterval is the minimum interval between two consecutive
+	// requests for the progress of a running job. every update to the status
+	// of a resource triggers a new sync, so the progress is not requested on
+	// every sync.
+	progressUpdateInterval = 15 * time.Second
+	// progressRequestTimeout is the timeout for requests for the progress of a
+	// running job.
+	progressRequestTimeout = 5 * time.Second
+)
+
+// Progress describes the progress of a backup/restore operation, as reported
+// by the job performing it. Finished jobs report their final progress as their
+// termination message.
+type Progress struct {
+	// Bytes is the number of (uncompressed) bytes transferred so far.
+	Bytes int64 `json:"bytes"`
+	// Records is the number of records backed up or restored, if known.
+	Records *int64 `json:"records,omitempty"`
+	// Percentage is the estimated percentage of completion, if known.
+	Percentage *int32 `json:"percentage,omitempty"`
+	// StartTime is the time at which the operation started.
+	StartTime time.Time `json:"startTime"`
+	// CompletionTime is the time at which the operation finished.
+	CompletionTime *time.Time `json:"completionTime,omitempty"`
+}
+
+// toStatus returns the representation of p in the status of a resource, as
+// of now.
+func (p *Progress) toStatus(now time.Time) *aerospikev1alpha2.BackupRestoreProgress {
+	bytes := p.Bytes
+	res := &aerospikev1alpha2.BackupRestoreProgress{
+		Bytes:          &bytes,
+		Records:        p.Records,
+		Percentage:     p.Percentage,
+		StartTime:      &metav1.Time{Time: p.StartTime},
+		LastUpdateTime: &metav1.Time{Time: now},
+	}
+	end := now
+	if p.CompletionTime != nil {
+		res.CompletionTime = &metav1.Time{Time: *p.CompletionTime}
+		end = *p.CompletionTime
+	}
+	res.Duration = formatDuration(p.StartTime, end)
+	return res
+}
+
+// formatDuration returns the time elapsed between start and end, rounded to
+// the second.
+func formatDuration(start, end time.Time) string {
+	return end.Sub(start).Round(time.Second).String()
+}
+
+// aggregateProgress returns the overall progress of an operation split into
+// shards with the specified progress, as of now. Shards whose progress is
+// unknown are considered not to have made any progress.
+func aggregateProgress(shards []*aerospikev1alpha2.BackupRestoreProgress, now time.Time) *aerospikev1alpha2.BackupRestoreProgress {
+	if len(shards) == 1 {
+		return shards[0]
+	}
+	var (
+		bytes, records, percentage int64
+		recordsKnown, complete     = true, true
+		res                        = &aerospikev1alpha2.BackupRestoreProgress{LastUpdateTime: &metav1.Time{Time: now}}
+	)
+	for _, p := range shards {
+		if p == nil {
+			recordsKnown, complete = false, false
+			continue
+		}
+		if p.Bytes != nil {
+			bytes += *p.Bytes
+		}
+		if p.Records != nil {
+			records += *p.Records
+		} else {
+			recordsKnown = false
+		}
+		if p.Percentage != nil {
+			percentage += int64(*p.Percentage)
+		}
+		if p.StartTime != nil && (res.StartTime == nil || p.StartTime.Before(res.StartTime)) {
+			res.StartTime = p.StartTime
+		}
+		if p.CompletionTime == nil {
+			complete = false
+		} else if res.CompletionTime == nil || res.CompletionTime.Before(p.CompletionTime) {
+			res.CompletionTime = p.CompletionTime
+		}
+	}
+	res.Bytes = &bytes
+	if recordsKnown {
+		res.Records = &records
+	}
+	res.Percentage = pointers.NewInt32(int32(percentage / int64(len(shards))))
+	if !complete {
+		res.CompletionTime = nil
+	}
+	if res.StartTime != nil {
+		end := now
+		if res.CompletionTime != nil {
+			end = res.CompletionTime.Time
+		}
+		res.Duration = formatDuration(res.StartTime.Time, end)
+	}
+	return res
+}
+
+// updateProgress updates the progress of obj based on the progress reported
+// by the jobs performing it. The progress of each job is only updated until
+// the job completes.
+func (h *AerospikeBackupRestoreHandler) updateProgress(obj aerospikev1alpha2.BackupRestoreObject, jobs []*batchv1.Job) {
+	// avoid requesting the progress of running jobs too often
+	progress := obj.GetProgress()
+	recent := progress != nil && progress.LastUpdateTime != nil && time.Since(progress.LastUpdateTime.Time) < progressUpdateInterval
+
+	var (
+		now     = time.Now()
+		shards  = getShardProgress(obj, len(jobs))
+		updated = false
+	)
+	for i, job := range jobs {
+		if shards[i] != nil && shards[i].CompletionTime != nil {
+			continue
+		}
+		if recent && job.Status.Succeeded == 0 {
+			continue
+		}
+		if p := h.getJobProgress(obj, job, recent); p != nil {
+			shards[i] = p.toStatus(now)
+			updated = true
+		}
+	}
+	if updated {
+		setShardProgress(obj, shards)
+		obj.SetProgress(aggregateProgress(shards, now))
+	}
+}
+
+// getJobProgress returns the progress reported by job, or nil if it cannot be
+// determined. Unless finalOnly is true, the progress of a running job is
+// requested from it.
+func (h *AerospikeBackupRestoreHandler) getJobProgress(obj aerospikev1alpha2.BackupRestoreObject, job *batchv1.Job, finalOnly bool) *Progress {
+	pods, err := h.listJobPods(job)
+	if err != nil {
+		log.WithFields(log.Fields{
+			logfields.Job: meta.Key(job),
+		}).Warnf("failed to list pods: %v", err)
+		return nil
+	}
+
+	for _, pod := range pods {
+		if p := getFinalProgress(&pod); p != nil {
+			return p
+		}
+	}
+	if finalOnly {
+		return nil
+	}
+	for _, pod := range pods {
+		if pod.Status.Phase != corev1.PodRunning || pod.Status.PodIP == "" {
+			continue
+		}
+		p, err := getProgress(pod.Status.PodIP)
+		if err != nil {
+			log.WithFields(log.Fields{
+				logfields.Kind: obj.GetKind(),
+				logfields.Key:  meta.Key(obj),
+			}).Debugf("failed to get progress: %v", err)
+			continue
+		}
+		return p
+	}
+	return nil
+}
+
+// getFinalProgress returns the progress reported by pod upon successful
+// completion, or nil if pod has not completed successfully.
+func getFinalProgress(pod *corev1.Pod) *Progress {
+	for _, status := range pod.Status.ContainerStatuses {
+		t := status.State.Terminated
+		if t == nil || t.ExitCode != 0 || t.Message == "" {
+			continue
+		}
+		p := &Progress{}
+		if err := json.Unmarshal([]byte(t.Message), p); err != nil {
+			continue
+		}
+		return p
+	}
+	return nil
+}
+
+// getProgress requests the progress of the job running at the specified ip.
+func getProgress(ip string) (*Progress, error) {
+	client := &http.Client{Timeout: progressRequestTimeout}
+	res, err := client.Get(fmt.Sprintf("http://%s:%d%s", ip, ProgressPort, ProgressPath))
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code %d", res.StatusCode)
+	}
+	p := &Progress{}
+	if err := json.NewDecoder(res.Body).Decode(p); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
@@ -0,0 +1,71 @@
+/*
+Copyright 2019 The aerospike-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package backuprestore
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	aerospikev1alpha2 "github.com/travelaudience/aerospike-operator/pkg/apis/aerospike/v1alpha2"
+	"github.com/travelaudience/aerospike-operator/pkg/pointers"
+)
+
+func TestAggregateProgress(t *testing.T) {
+	date := func(minute int) *metav1.Time {
+		return &metav1.Time{Time: time.Date(2019, time.January, 1, 0, minute, 0, 0, time.UTC)}
+	}
+	now := date(10).Time
+	running := &aerospikev1alpha2.BackupRestoreProgress{
+		Bytes:      pointers.NewInt64(100),
+		Percentage: pointers.NewInt32(40),
+		StartTime:  date(1),
+	}
+	finished := &aerospikev1alpha2.BackupRestoreProgress{
+		Bytes:          pointers.NewInt64(200),
+		Records:        pointers.NewInt64(20),
+		Percentage:     pointers.NewInt32(100),
+		StartTime:      date(2),
+		CompletionTime: date(5),
+	}
+
+	// a single shard is reported as is
+	assert.Equal(t, running, aggregateProgress([]*aerospikev1alpha2.BackupRestoreProgress{running}, now))
+
+	tests := []struct {
+		shards         []*aerospikev1alpha2.BackupRestoreProgress
+		bytes          int64
+		records        *int64
+		percentage     int32
+		completionTime *metav1.Time
+		duration       string
+	}{
+		{[]*aerospikev1alpha2.BackupRestoreProgress{running, finished}, 300, nil, 70, nil, "9m0s"},
+		{[]*aerospikev1alpha2.BackupRestoreProgress{nil, finished}, 200, nil, 50, nil, "8m0s"},
+		{[]*aerospikev1alpha2.BackupRestoreProgress{finished, finished}, 400, pointers.NewInt64(40), 100, date(5), "3m0s"},
+	}
+	for _, test := range tests {
+		res := aggregateProgress(test.shards, now)
+		assert.Equal(t, pointers.NewInt64(test.bytes), res.Bytes)
+		assert.Equal(t, test.records, res.Records)
+		assert.Equal(t, pointers.NewInt32(test.percentage), res.Percentage)
+		assert.Equal(t, test.completionTime, res.CompletionTime)
+		assert.Equal(t, test.duration, res.Duration)
+	}
+}
@@ -0,0 +1,166 @@
+/*
+Copyright 2019 The aerospike-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pvc
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/travelaudience/aerospike-operator/pkg/backuprestore/objectstorage"
+)
+
+const (
+	// MountPath is the path at which the persistent volume claim holding the
+	// backups is mounted in backup/restore jobs.
+	MountPath = "/backup"
+)
+
+// PVCClient stores objects as files in a directory of a mounted persistent
+// volume.
+type PVCClient struct {
+	root string
+}
+
+// NewPVCClient returns a new PVCClient that stores objects in the specified
+// directory, creating it if necessary.
+func NewPVCClient(root string) (*PVCClient, error) {
+	if err := os.MkdirAll(root, 0755); err != nil {
+		return nil, err
+	}
+	return &PVCClient{
+		root: root,
+	}, nil
+}
+
+// Close closes the PVCClient. It exists so that PVCClient can be used
+// interchangeably with clients for other storage backends.
+func (c *PVCClient) Close() error {
+	return nil
+}
+
+// NewReader returns a reader for the contents of the specified object.
+func (c *PVCClient) NewReader(objectName string) (io.ReadCloser, error) {
+	path, err := c.path(objectName)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, objectstorage.ErrNotFound
+		}
+		return nil, err
+	}
+	return f, nil
+}
+
+// NewWriter returns a writer that writes the data written to it to the
+// specified object. The data is written to a temporary file which only
+// replaces the object when Close returns successfully.
+func (c *PVCClient) NewWriter(objectName string) (objectstorage.Writer, error) {
+	path, err := c.path(objectName)
+	if err != nil {
+		return nil, err
+	}
+	f, err := ioutil.TempFile(c.root, fmt.Sprintf(".%s.", objectName))
+	if err != nil {
+		return nil, err
+	}
+	return &writer{
+		file: f,
+		path: path,
+	}, nil
+}
+
+// DeleteObject deletes the specified object.
+func (c *PVCClient) DeleteObject(objectName string) error {
+	path, err := c.path(objectName)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); !os.IsNotExist(err) {
+		return err
+	}
+	return objectstorage.ErrNotFound
+}
+
+// path returns the path to the file holding the specified object.
+func (c *PVCClient) path(objectName string) (string, error) {
+	if objectName == "" || strings.ContainsRune(objectName, filepath.Separator) || strings.HasPrefix(objectName, ".") {
+		return "", fmt.Errorf("invalid object name %q", objectName)
+	}
+	return filepath.Join(c.root, objectName), nil
+}
+
+// writer writes data to a temporary file and renames it to its final path when
+// closed.
+type writer struct {
+	file   *os.File
+	path   string
+	err    error
+	closed bool
+}
+
+func (w *writer) Write(p []byte) (int, error) {
+	if w.err != nil {
+		return 0, w.err
+	}
+	n, err := w.file.Write(p)
+	if err != nil {
+		w.err = err
+	}
+	return n, err
+}
+
+func (w *writer) Close() error {
+	if w.closed {
+		return w.err
+	}
+	w.closed = true
+	// make sure the data is persisted before replacing the object
+	if w.err == nil {
+		w.err = w.file.Sync()
+	}
+	if err := w.file.Close(); err != nil && w.err == nil {
+		w.err = err
+	}
+	if w.err == nil {
+		w.err = os.Rename(w.file.Name(), w.path)
+	}
+	if w.err != nil {
+		os.Remove(w.file.Name())
+	}
+	return w.err
+}
+
+// Abort removes the temporary file, so that the object is neither created nor
+// replaced.
+func (w *writer) Abort() error {
+	if w.closed {
+		return nil
+	}
+	w.closed = true
+	if w.err == nil {
+		w.err = fmt.Errorf("write aborted")
+	}
+	w.file.Close()
+	return os.Remove(w.file.Name())
+}
@@ -0,0 +1,98 @@
+/*
+Copyright 2019 The aerospike-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pvc
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/travelaudience/aerospike-operator/pkg/backuprestore/objectstorage"
+)
+
+func newTestClient(t *testing.T) (*PVCClient, string, func()) {
+	dir, err := ioutil.TempDir("", "pvc")
+	assert.NoError(t, err)
+	client, err := NewPVCClient(filepath.Join(dir, "bucket"))
+	assert.NoError(t, err)
+	return client, filepath.Join(dir, "bucket"), func() { os.RemoveAll(dir) }
+}
+
+func TestReadWriteDelete(t *testing.T) {
+	client, root, closeFn := newTestClient(t)
+	defer closeFn()
+
+	w, err := client.NewWriter("backup.asb.gz")
+	assert.NoError(t, err)
+	_, err = w.Write([]byte("aerospike"))
+	assert.NoError(t, err)
+
+	// the object must not exist until the writer is closed
+	_, err = os.Stat(filepath.Join(root, "backup.asb.gz"))
+	assert.True(t, os.IsNotExist(err))
+	assert.NoError(t, w.Close())
+
+	r, err := client.NewReader("backup.asb.gz")
+	assert.NoError(t, err)
+	data, err := ioutil.ReadAll(r)
+	assert.NoError(t, err)
+	assert.NoError(t, r.Close())
+	assert.Equal(t, []byte("aerospike"), data)
+
+	assert.NoError(t, client.DeleteObject("backup.asb.gz"))
+	files, err := ioutil.ReadDir(root)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(files))
+}
+
+func TestAbort(t *testing.T) {
+	client, root, closeFn := newTestClient(t)
+	defer closeFn()
+
+	w, err := client.NewWriter("backup.asb.gz")
+	assert.NoError(t, err)
+	_, err = w.Write([]byte("aerospike"))
+	assert.NoError(t, err)
+	assert.NoError(t, w.Abort())
+
+	// aborted writes must neither create the object nor leave the temporary
+	// file behind
+	assert.Error(t, w.Close())
+	files, err := ioutil.ReadDir(root)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(files))
+}
+
+func TestErrors(t *testing.T) {
+	client, _, closeFn := newTestClient(t)
+	defer closeFn()
+
+	// reading and deleting a missing object must fail
+	_, err := client.NewReader("missing.json")
+	assert.Equal(t, objectstorage.ErrNotFound, err)
+	err = client.DeleteObject("missing.json")
+	assert.Equal(t, objectstorage.ErrNotFound, err)
+
+	// object names must not escape the root directory
+	for _, name := range []string{"", "../backup.json", "dir/backup.json", ".backup.json"} {
+		_, err := client.NewWriter(name)
+		assert.Error(t, err, name)
+	}
+}
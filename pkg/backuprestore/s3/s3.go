@@ -0,0 +1,429 @@
+/*
+Copyright 2019 The aerospike-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package s3
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/travelaudience/aerospike-operator/pkg/backuprestore/objectstorage"
+)
+
+const (
+	// DefaultRegion is the region used when none is specified.
+	DefaultRegion = "us-east-1"
+	// defaultPartSize is the initial size of each part of a multipart upload. S3
+	// requires every part but the last one to be at least 5MiB in size.
+	defaultPartSize = 16 * 1024 * 1024
+	// maxPartSize is the maximum size of each part of a multipart upload.
+	maxPartSize = 5 * 1024 * 1024 * 1024
+	// maxParts is the maximum number of parts of a multipart upload.
+	maxParts = 10000
+	// partSizeGrowthInterval is the number of parts after which the part size
+	// doubles, so that objects as large as the maximum object size supported
+	// by S3 (5TiB) can be uploaded in at most maxParts parts.
+	partSizeGrowthInterval = 1000
+	// requestTimeout is the timeout applied to requests that do not stream data.
+	requestTimeout = 1 * time.Minute
+)
+
+// Credentials holds the credentials used to access an S3-compatible service.
+type Credentials struct {
+	// AccessKeyID is the access key id used to sign requests.
+	AccessKeyID string `json:"accessKeyId"`
+	// SecretAccessKey is the secret access key used to sign requests.
+	SecretAccessKey string `json:"secretAccessKey"`
+	// SessionToken is the (optional) session token to use with temporary credentials.
+	SessionToken string `json:"sessionToken,omitempty"`
+}
+
+// Options holds the configuration of an S3Client.
+type Options struct {
+	// Endpoint is the URL of the S3-compatible service. Defaults to the AWS S3 endpoint for Region.
+	Endpoint string
+	// Region is the region in which the bucket is located. Defaults to DefaultRegion.
+	Region string
+	// ForcePathStyle indicates whether to use path-style requests (i.e. <endpoint>/<bucket>/<object>) instead of
+	// virtual-hosted-style requests (i.e. <bucket>.<endpoint>/<object>).
+	ForcePathStyle bool
+}
+
+type S3Client struct {
+	httpClient     *http.Client
+	endpoint       *url.URL
+	region         string
+	forcePathStyle bool
+	bucketName     string
+	credentials    *Credentials
+	partSize       int
+	now            func() time.Time
+}
+
+// ParseCredentials parses the JSON representation of a Credentials object and checks that it is complete.
+func ParseCredentials(jsonBytes []byte) (*Credentials, error) {
+	creds := &Credentials{}
+	if err := json.Unmarshal(jsonBytes, creds); err != nil {
+		return nil, fmt.Errorf("failed to parse s3 credentials: %v", err)
+	}
+	if creds.AccessKeyID == "" {
+		return nil, fmt.Errorf("s3 credentials do not contain an access key id")
+	}
+	if creds.SecretAccessKey == "" {
+		return nil, fmt.Errorf("s3 credentials do not contain a secret access key")
+	}
+	return creds, nil
+}
+
+// ParseEndpoint returns the URL of the S3-compatible service described by the specified options.
+func ParseEndpoint(opts Options) (*url.URL, error) {
+	endpoint := opts.Endpoint
+	if endpoint == "" {
+		endpoint = fmt.Sprintf("https://s3.%s.amazonaws.com", region(opts))
+	}
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid s3 endpoint %q: %v", endpoint, err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("invalid s3 endpoint %q: scheme must be http or https", endpoint)
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("invalid s3 endpoint %q: missing host", endpoint)
+	}
+	if u.Path != "" && u.Path != "/" {
+		return nil, fmt.Errorf("invalid s3 endpoint %q: must not contain a path", endpoint)
+	}
+	return u, nil
+}
+
+// NewS3ClientFromJSON returns a new S3Client for the specified bucket, loading
+// the credentials from the given JSON string
+func NewS3ClientFromJSON(jsonBytes []byte, bucketName string, opts Options) (*S3Client, error) {
+	creds, err := ParseCredentials(jsonBytes)
+	if err != nil {
+		return nil, err
+	}
+	endpoint, err := ParseEndpoint(opts)
+	if err != nil {
+		return nil, err
+	}
+	return &S3Client{
+		httpClient:     &http.Client{},
+		endpoint:       endpoint,
+		region:         region(opts),
+		forcePathStyle: opts.ForcePathStyle,
+		bucketName:     bucketName,
+		credentials:    creds,
+		partSize:       defaultPartSize,
+		now:            time.Now,
+	}, nil
+}
+
+// Close closes the S3Client. It exists so that S3Client can be used
+// interchangeably with clients for other storage backends.
+func (c *S3Client) Close() error {
+	return nil
+}
+
+// NewReader returns a reader for the contents of the specified object.
+func (c *S3Client) NewReader(objectName string) (io.ReadCloser, error) {
+	res, err := c.do(http.MethodGet, objectName, nil, nil, 0)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode != http.StatusOK {
+		defer res.Body.Close()
+		return nil, errorFromResponse(res)
+	}
+	return res.Body, nil
+}
+
+// NewWriter returns a writer that uploads the data written to it to the
+// specified object. The upload is only complete when Close returns.
+func (c *S3Client) NewWriter(objectName string) (objectstorage.Writer, error) {
+	return &writer{
+		client:     c,
+		objectName: objectName,
+	}, nil
+}
+
+// DeleteObject deletes the specified object.
+func (c *S3Client) DeleteObject(objectName string) error {
+	res, err := c.doWithTimeout(http.MethodDelete, objectName, nil, nil)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusNoContent && res.StatusCode != http.StatusOK {
+		return errorFromResponse(res)
+	}
+	return nil
+}
+
+// objectURL returns the URL of the specified object. If objectName is empty,
+// the URL of the bucket is returned.
+func (c *S3Client) objectURL(objectName string, query url.Values) *url.URL {
+	u := *c.endpoint
+	p := "/" + objectName
+	if c.forcePathStyle {
+		p = "/" + c.bucketName + p
+	} else {
+		u.Host = c.bucketName + "." + u.Host
+	}
+	u.Path = p
+	u.RawPath = uriEncode(p, false)
+	u.RawQuery = query.Encode()
+	return &u
+}
+
+// doWithTimeout performs a request that is not expected to stream large
+// amounts of data.
+func (c *S3Client) doWithTimeout(method, objectName string, query url.Values, body []byte) (*http.Response, error) {
+	return c.do(method, objectName, query, body, requestTimeout)
+}
+
+// do builds, signs and performs a request against the specified object.
+func (c *S3Client) do(method, objectName string, query url.Values, body []byte, timeout time.Duration) (*http.Response, error) {
+	req, err := http.NewRequest(method, c.objectURL(objectName, query).String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.ContentLength = int64(len(body))
+	if c.credentials.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", c.credentials.SessionToken)
+	}
+	sign(req, c.credentials, c.region, hashHex(body), c.now())
+	client := c.httpClient
+	if timeout > 0 {
+		client = &http.Client{Transport: c.httpClient.Transport, Timeout: timeout}
+	}
+	return client.Do(req)
+}
+
+// writer buffers the data written to it and uploads it to S3, using a
+// multipart upload whenever the data exceeds the part size.
+type writer struct {
+	client     *S3Client
+	objectName string
+	buf        bytes.Buffer
+	uploadID   string
+	parts      []completedPart
+	err        error
+	closed     bool
+}
+
+func (w *writer) Write(p []byte) (int, error) {
+	if w.err != nil {
+		return 0, w.err
+	}
+	if w.closed {
+		return 0, fmt.Errorf("write on closed writer")
+	}
+	n, _ := w.buf.Write(p)
+	for size := w.partSize(); w.buf.Len() >= size; size = w.partSize() {
+		if w.err = w.uploadPart(w.buf.Next(size)); w.err != nil {
+			w.abort()
+			return n, w.err
+		}
+	}
+	return n, nil
+}
+
+// partSize returns the size of the next part to upload, which doubles every
+// partSizeGrowthInterval parts up to maxPartSize.
+func (w *writer) partSize() int {
+	size := w.client.partSize << uint(len(w.parts)/partSizeGrowthInterval)
+	if size <= 0 || size > maxPartSize {
+		return maxPartSize
+	}
+	return size
+}
+
+func (w *writer) Close() error {
+	if w.closed {
+		return w.err
+	}
+	w.closed = true
+	if w.err != nil {
+		return w.err
+	}
+	// if no parts have been uploaded yet, upload the data in a single request
+	if w.uploadID == "" {
+		w.err = w.putObject(w.buf.Bytes())
+		return w.err
+	}
+	// upload the remaining data as the last part and complete the upload
+	if w.buf.Len() > 0 {
+		if w.err = w.uploadPart(w.buf.Bytes()); w.err != nil {
+			w.abort()
+			return w.err
+		}
+	}
+	if w.err = w.complete(); w.err != nil {
+		w.abort()
+	}
+	return w.err
+}
+
+// Abort aborts the upload, so that the object is neither created nor
+// replaced.
+func (w *writer) Abort() error {
+	if w.closed {
+		return nil
+	}
+	w.closed = true
+	if w.err == nil {
+		w.err = fmt.Errorf("upload aborted")
+	}
+	w.abort()
+	return nil
+}
+
+func (w *writer) putObject(data []byte) error {
+	res, err := w.client.do(http.MethodPut, w.objectName, nil, data, 0)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return errorFromResponse(res)
+	}
+	return nil
+}
+
+func (w *writer) uploadPart(data []byte) error {
+	// initiate the multipart upload if needed
+	if w.uploadID == "" {
+		res, err := w.client.doWithTimeout(http.MethodPost, w.objectName, url.Values{"uploads": {""}}, nil)
+		if err != nil {
+			return err
+		}
+		defer res.Body.Close()
+		if res.StatusCode != http.StatusOK {
+			return errorFromResponse(res)
+		}
+		r := &initiateMultipartUploadResult{}
+		if err := xml.NewDecoder(res.Body).Decode(r); err != nil {
+			return fmt.Errorf("failed to decode multipart upload response: %v", err)
+		}
+		w.uploadID = r.UploadID
+	}
+	// upload the part, failing early if the upload cannot be completed
+	partNumber := len(w.parts) + 1
+	if partNumber > maxParts {
+		return fmt.Errorf("the object exceeds the maximum number of parts (%d) of a multipart upload", maxParts)
+	}
+	res, err := w.client.do(http.MethodPut, w.objectName, url.Values{
+		"partNumber": {strconv.Itoa(partNumber)},
+		"uploadId":   {w.uploadID},
+	}, data, 0)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return errorFromResponse(res)
+	}
+	w.parts = append(w.parts, completedPart{
+		PartNumber: partNumber,
+		ETag:       res.Header.Get("ETag"),
+	})
+	return nil
+}
+
+func (w *writer) complete() error {
+	body, err := xml.Marshal(completeMultipartUpload{Parts: w.parts})
+	if err != nil {
+		return err
+	}
+	res, err := w.client.doWithTimeout(http.MethodPost, w.objectName, url.Values{"uploadId": {w.uploadID}}, body)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	// s3 may report an error in the body of a successful response
+	b, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return err
+	}
+	if res.StatusCode != http.StatusOK || bytes.Contains(b, []byte("<Error>")) {
+		return errorFromBody(res.StatusCode, b)
+	}
+	return nil
+}
+
+// abort aborts the multipart upload (if any) so that uploaded parts do not
+// linger in the bucket.
+func (w *writer) abort() {
+	if w.uploadID == "" {
+		return
+	}
+	if res, err := w.client.doWithTimeout(http.MethodDelete, w.objectName, url.Values{"uploadId": {w.uploadID}}, nil); err == nil {
+		res.Body.Close()
+	}
+}
+
+type initiateMultipartUploadResult struct {
+	UploadID string `xml:"UploadId"`
+}
+
+type completedPart struct {
+	PartNumber int    `xml:"PartNumber"`
+	ETag       string `xml:"ETag"`
+}
+
+type completeMultipartUpload struct {
+	XMLName xml.Name        `xml:"CompleteMultipartUpload"`
+	Parts   []completedPart `xml:"Part"`
+}
+
+type errorResponse struct {
+	Code    string `xml:"Code"`
+	Message string `xml:"Message"`
+}
+
+func errorFromResponse(res *http.Response) error {
+	b, _ := ioutil.ReadAll(res.Body)
+	return errorFromBody(res.StatusCode, b)
+}
+
+func errorFromBody(statusCode int, body []byte) error {
+	e := &errorResponse{}
+	if err := xml.Unmarshal(body, e); err != nil || e.Code == "" {
+		return fmt.Errorf("s3 request failed with status %d", statusCode)
+	}
+	if e.Code == "NoSuchKey" {
+		return objectstorage.ErrNotFound
+	}
+	return fmt.Errorf("s3 request failed with status %d: %s: %s", statusCode, e.Code, strings.TrimSpace(e.Message))
+}
+
+func region(opts Options) string {
+	if opts.Region != "" {
+		return opts.Region
+	}
+	return DefaultRegion
+}
@@ -40,6 +40,40 @@ func (h *AerospikeBackupRestoreHandler) getSecret(obj aerospikev1alpha2.BackupRe
 	return h.createTempSecret(secret, obj)
 }
 
+// CredentialsEnv returns the environment variables holding the credentials
+// of the admin user of an aerospike cluster with the specified security
+// configuration, read from its admin secret. It returns nil if security is not
+// enabled.
+func CredentialsEnv(security *aerospikev1alpha2.AerospikeClusterSecuritySpec) []corev1.EnvVar {
+	if security == nil {
+		return nil
+	}
+	return []corev1.EnvVar{
+		{
+			Name: UserEnvVar,
+			ValueFrom: &corev1.EnvVarSource{
+				SecretKeyRef: &corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{
+						Name: security.AdminSecret,
+					},
+					Key: corev1.BasicAuthUsernameKey,
+				},
+			},
+		},
+		{
+			Name: PasswordEnvVar,
+			ValueFrom: &corev1.EnvVarSource{
+				SecretKeyRef: &corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{
+						Name: security.AdminSecret,
+					},
+					Key: corev1.BasicAuthPasswordKey,
+				},
+			},
+		},
+	}
+}
+
 func (h *AerospikeBackupRestoreHandler) clearSecrets(obj aerospikev1alpha2.BackupRestoreObject) error {
 	secrets, err := h.kubeclientset.CoreV1().Secrets(obj.GetNamespace()).List(listoptions.ResourcesByBackupRestoreObject(obj))
 	if err != nil {
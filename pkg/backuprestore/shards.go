@@ -0,0 +1,183 @@
+/*
+Copyright 2019 The aerospike-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package backuprestore
+
+import (
+	"fmt"
+	"sort"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/travelaudience/aerospike-operator/pkg/apis/aerospike/common"
+	aerospikev1alpha2 "github.com/travelaudience/aerospike-operator/pkg/apis/aerospike/v1alpha2"
+	"github.com/travelaudience/aerospike-operator/pkg/utils/selectors"
+)
+
+const (
+	// aerospikeServicePort is the port on which aerospike nodes serve
+	// clients.
+	aerospikeServicePort = 3000
+)
+
+// getShardCount returns the number of shards into which the operation
+// described by obj is split, each of which is performed by a separate job.
+// Restores replay every shard of the backup from a single job.
+func getShardCount(obj aerospikev1alpha2.BackupRestoreObject) int {
+	if backup, ok := obj.(*aerospikev1alpha2.AerospikeNamespaceBackup); ok {
+		return backup.Spec.GetParallelism()
+	}
+	return 1
+}
+
+// hasShardNodes returns whether the nodes backed up by each shard of backup
+// have already been assigned.
+func hasShardNodes(backup *aerospikev1alpha2.AerospikeNamespaceBackup) bool {
+	shards := backup.Spec.GetParallelism()
+	if len(backup.Status.Shards) != shards {
+		return false
+	}
+	for _, s := range backup.Status.Shards {
+		if len(s.Nodes) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// assignShardNodes assigns the nodes of the target cluster to the shards of
+// backup and records the assignment in the resource's status, so that every
+// job backing up a shard uses the same assignment regardless of when it is
+// created.
+func (h *AerospikeBackupRestoreHandler) assignShardNodes(backup *aerospikev1alpha2.AerospikeNamespaceBackup) error {
+	nodes, err := h.getNodeAddresses(backup)
+	if err != nil {
+		return err
+	}
+	assignment, err := splitNodes(nodes, backup.Spec.GetParallelism())
+	if err != nil {
+		return err
+	}
+	shards := make([]aerospikev1alpha2.BackupShardStatus, len(assignment))
+	for i, nodes := range assignment {
+		shards[i] = aerospikev1alpha2.BackupShardStatus{
+			Index: int32(i),
+			State: common.ShardStatePending,
+			Nodes: nodes,
+		}
+	}
+	backup.Status.Shards = shards
+	return nil
+}
+
+// getNodeAddresses returns the addresses (in host:port format) of the nodes
+// of the cluster targeted by backup, sorted by the name of the pod running
+// them. Pods that are not running are ignored.
+func (h *AerospikeBackupRestoreHandler) getNodeAddresses(backup *aerospikev1alpha2.AerospikeNamespaceBackup) ([]string, error) {
+	pods, err := h.kubeclientset.CoreV1().Pods(backup.Namespace).List(metav1.ListOptions{
+		LabelSelector: selectors.ResourcesByClusterName(backup.Spec.Target.Cluster).String(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	items := pods.Items
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].Name < items[j].Name
+	})
+	res := make([]string, 0, len(items))
+	for _, pod := range items {
+		if pod.Status.Phase != corev1.PodRunning || pod.Status.PodIP == "" {
+			continue
+		}
+		res = append(res, fmt.Sprintf("%s:%d", pod.Status.PodIP, aerospikeServicePort))
+	}
+	return res, nil
+}
+
+// splitNodes assigns nodes to the specified number of shards in a
+// round-robin fashion.
+func splitNodes(nodes []string, shards int) ([][]string, error) {
+	if shards < 1 {
+		return nil, fmt.Errorf("invalid number of shards %d", shards)
+	}
+	if len(nodes) < shards {
+		return nil, fmt.Errorf("cannot split the backup of a cluster of %d running node(s) into %d shards", len(nodes), shards)
+	}
+	res := make([][]string, shards)
+	for i, node := range nodes {
+		res[i%shards] = append(res[i%shards], node)
+	}
+	return res, nil
+}
+
+// updateShardStatus reports the state of the job backing up each shard of
+// backup in the resource's status. Nothing is reported for backups that are
+// not split.
+func (h *AerospikeBackupRestoreHandler) updateShardStatus(backup *aerospikev1alpha2.AerospikeNamespaceBackup, jobs []*batchv1.Job) {
+	if len(jobs) <= 1 {
+		return
+	}
+	shards := make([]aerospikev1alpha2.BackupShardStatus, len(jobs))
+	for i, job := range jobs {
+		shards[i] = aerospikev1alpha2.BackupShardStatus{
+			Index: int32(i),
+			Job:   job.Name,
+			State: common.ShardStateRunning,
+		}
+		if i < len(backup.Status.Shards) {
+			shards[i].Message = backup.Status.Shards[i].Message
+			shards[i].Progress = backup.Status.Shards[i].Progress
+			shards[i].Nodes = backup.Status.Shards[i].Nodes
+		}
+		switch getJobCondition(job) {
+		case batchv1.JobComplete:
+			shards[i].State = common.ShardStateFinished
+		case batchv1.JobFailed:
+			shards[i].State = common.ShardStateFailed
+			if shards[i].Message == "" {
+				shards[i].Message = h.getTerminationMessage(job)
+			}
+		}
+	}
+	backup.Status.Shards = shards
+}
+
+// getShardProgress returns the progress of each shard of obj, as currently
+// reported in the resource's status.
+func getShardProgress(obj aerospikev1alpha2.BackupRestoreObject, shards int) []*aerospikev1alpha2.BackupRestoreProgress {
+	if shards <= 1 {
+		return []*aerospikev1alpha2.BackupRestoreProgress{obj.GetProgress()}
+	}
+	res := make([]*aerospikev1alpha2.BackupRestoreProgress, shards)
+	if backup, ok := obj.(*aerospikev1alpha2.AerospikeNamespaceBackup); ok {
+		for i := 0; i < shards && i < len(backup.Status.Shards); i++ {
+			res[i] = backup.Status.Shards[i].Progress
+		}
+	}
+	return res
+}
+
+// setShardProgress reports the progress of each shard of obj in the
+// resource's status.
+func setShardProgress(obj aerospikev1alpha2.BackupRestoreObject, progress []*aerospikev1alpha2.BackupRestoreProgress) {
+	if backup, ok := obj.(*aerospikev1alpha2.AerospikeNamespaceBackup); ok && len(progress) > 1 {
+		for i := 0; i < len(progress) && i < len(backup.Status.Shards); i++ {
+			backup.Status.Shards[i].Progress = progress[i]
+		}
+	}
+}
@@ -0,0 +1,47 @@
+/*
+Copyright 2019 The aerospike-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package backuprestore
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplitNodes(t *testing.T) {
+	nodes := []string{"10.0.0.1:3000", "10.0.0.2:3000", "10.0.0.3:3000", "10.0.0.4:3000", "10.0.0.5:3000"}
+	tests := []struct {
+		shards   int
+		expected [][]string
+		valid    bool
+	}{
+		{1, [][]string{nodes}, true},
+		{2, [][]string{{"10.0.0.1:3000", "10.0.0.3:3000", "10.0.0.5:3000"}, {"10.0.0.2:3000", "10.0.0.4:3000"}}, true},
+		{5, [][]string{{"10.0.0.1:3000"}, {"10.0.0.2:3000"}, {"10.0.0.3:3000"}, {"10.0.0.4:3000"}, {"10.0.0.5:3000"}}, true},
+		{6, nil, false},
+		{0, nil, false},
+	}
+	for _, test := range tests {
+		res, err := splitNodes(nodes, test.shards)
+		if test.valid {
+			assert.NoError(t, err)
+			assert.Equal(t, test.expected, res)
+		} else {
+			assert.Error(t, err)
+		}
+	}
+}
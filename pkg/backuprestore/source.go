@@ -0,0 +1,143 @@
+/*
+Copyright 2019 The aerospike-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package backuprestore
+
+import (
+	"fmt"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"k8s.io/api/core/v1"
+	"k8s.io/apiextensions-apiserver/pkg/apis/apiextensions"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	aerospikev1alpha2 "github.com/travelaudience/aerospike-operator/pkg/apis/aerospike/v1alpha2"
+	"github.com/travelaudience/aerospike-operator/pkg/logfields"
+	"github.com/travelaudience/aerospike-operator/pkg/meta"
+	"github.com/travelaudience/aerospike-operator/pkg/pointers"
+	"github.com/travelaudience/aerospike-operator/pkg/utils/events"
+)
+
+// FindLatestBackup returns the latest successful backup of the specified
+// Aerospike cluster and namespace created before the specified time, or nil if
+// there is no such backup.
+func FindLatestBackup(backups []aerospikev1alpha2.AerospikeNamespaceBackup, target aerospikev1alpha2.TargetNamespace, before time.Time) *aerospikev1alpha2.AerospikeNamespaceBackup {
+	var latest *aerospikev1alpha2.AerospikeNamespaceBackup
+	for i := range backups {
+		backup := &backups[i]
+		if backup.Spec.Target != target || backup.CreationTimestamp.Time.After(before) || !isFinished(backup) {
+			continue
+		}
+		if latest == nil || backup.CreationTimestamp.After(latest.CreationTimestamp.Time) {
+			latest = backup
+		}
+	}
+	return latest
+}
+
+// isFinished returns whether backup has finished successfully.
+func isFinished(backup *aerospikev1alpha2.AerospikeNamespaceBackup) bool {
+	for _, c := range backup.Status.Conditions {
+		if c.Type == backup.GetFinishedConditionType() && c.Status == apiextensions.ConditionTrue {
+			return true
+		}
+	}
+	return false
+}
+
+// resolveSource determines the backup that will be restored by restore and
+// records its name in the resource's status. It returns false if no such
+// backup exists, in which case the restore is marked as failed.
+func (h *AerospikeBackupRestoreHandler) resolveSource(restore *aerospikev1alpha2.AerospikeNamespaceRestore) (bool, error) {
+	if restore.Status.BackupName == "" {
+		if source := restore.Spec.Source; source != nil && source.Before != nil {
+			// look for the latest successful backup of the source cluster and
+			// namespace created before the specified time
+			target := source.GetSourceTarget(restore.Spec.Target)
+			backups, err := h.aerospikeclientset.AerospikeV1alpha2().AerospikeNamespaceBackups(restore.GetBackupNamespace()).List(metav1.ListOptions{})
+			if err != nil {
+				return false, err
+			}
+			backup := FindLatestBackup(backups.Items, target, source.Before.Time)
+			if backup == nil {
+				h.markBackupNotFound(restore, fmt.Sprintf("no successful backup of %s/%s created before %s was found",
+					target.Cluster, target.Namespace, source.Before.UTC().Format(time.RFC3339)))
+				return false, nil
+			}
+			restore.Status.BackupName = backup.Name
+		} else {
+			restore.Status.BackupName = restore.GetBackupName()
+		}
+		log.WithFields(log.Fields{
+			logfields.Kind: restore.GetKind(),
+			logfields.Key:  meta.Key(restore),
+		}).Debugf("restoring backup %s", restore.Status.BackupName)
+	}
+
+	// restore the backup from where it was stored unless otherwise specified.
+	// the backup may have been created by another instance of
+	// aerospike-operator, so it is fine for the backup resource not to exist
+	// as long as its data exists in storage.
+	if restore.Spec.Storage == nil {
+		backup, err := h.aerospikeclientset.AerospikeV1alpha2().AerospikeNamespaceBackups(restore.GetBackupNamespace()).Get(restore.Status.BackupName, metav1.GetOptions{})
+		if err != nil && !errors.IsNotFound(err) {
+			return false, err
+		}
+		if err == nil {
+			restore.Spec.Storage = GetSourceStorage(backup, restore.Namespace)
+		}
+	}
+	return true, nil
+}
+
+// GetSourceStorage returns the storage from which backup can be restored by a
+// restore operation running in the specified namespace, or nil if it cannot be
+// determined. Unless specified otherwise, the secret containing the credentials
+// to access the storage is read from the namespace of the backup.
+func GetSourceStorage(backup *aerospikev1alpha2.AerospikeNamespaceBackup, namespace string) *aerospikev1alpha2.BackupStorageSpec {
+	// the storage of backups which use the storage of their target cluster is
+	// only recorded in their status
+	storage := backup.Spec.Storage
+	if storage == nil {
+		storage = backup.Status.Storage
+	}
+	if storage == nil {
+		return nil
+	}
+	storage = storage.DeepCopy()
+	if storage.SecretNamespace == nil && backup.Namespace != namespace {
+		storage.SecretNamespace = pointers.NewString(backup.Namespace)
+	}
+	return storage
+}
+
+// markBackupNotFound marks restore as failed because the backup to restore
+// could not be found.
+func (h *AerospikeBackupRestoreHandler) markBackupNotFound(restore *aerospikev1alpha2.AerospikeNamespaceRestore, msg string) {
+	log.WithFields(log.Fields{
+		logfields.Kind: restore.GetKind(),
+		logfields.Key:  meta.Key(restore),
+	}).Debug(msg)
+	h.recorder.Event(restore, v1.EventTypeWarning, events.ReasonBackupNotFound, msg)
+	restore.SetConditions(append(restore.GetConditions(), apiextensions.CustomResourceDefinitionCondition{
+		LastTransitionTime: metav1.NewTime(time.Now()),
+		Type:               restore.GetFailedConditionType(),
+		Status:             apiextensions.ConditionTrue,
+		Message:            msg,
+	}))
+}
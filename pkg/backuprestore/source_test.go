@@ -0,0 +1,134 @@
+/*
+Copyright 2019 The aerospike-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package backuprestore
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apiextensions-apiserver/pkg/apis/apiextensions"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/travelaudience/aerospike-operator/pkg/apis/aerospike/common"
+	aerospikev1alpha2 "github.com/travelaudience/aerospike-operator/pkg/apis/aerospike/v1alpha2"
+)
+
+func TestFindLatestBackup(t *testing.T) {
+	date := func(hour int) time.Time {
+		return time.Date(2019, time.January, 1, hour, 0, 0, 0, time.UTC)
+	}
+	newBackup := func(name, cluster, namespace string, created time.Time, conditionType apiextensions.CustomResourceDefinitionConditionType) aerospikev1alpha2.AerospikeNamespaceBackup {
+		return aerospikev1alpha2.AerospikeNamespaceBackup{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              name,
+				CreationTimestamp: metav1.NewTime(created),
+			},
+			Spec: aerospikev1alpha2.AerospikeNamespaceBackupSpec{
+				Target: aerospikev1alpha2.TargetNamespace{
+					Cluster:   cluster,
+					Namespace: namespace,
+				},
+			},
+			Status: aerospikev1alpha2.AerospikeNamespaceBackupStatus{
+				Conditions: []apiextensions.CustomResourceDefinitionCondition{
+					{
+						Type:   conditionType,
+						Status: apiextensions.ConditionTrue,
+					},
+				},
+			},
+		}
+	}
+
+	backups := []aerospikev1alpha2.AerospikeNamespaceBackup{
+		newBackup("a", "as-cluster-0", "as-namespace-0", date(1), common.ConditionBackupFinished),
+		newBackup("b", "as-cluster-0", "as-namespace-0", date(3), common.ConditionBackupFinished),
+		newBackup("c", "as-cluster-0", "as-namespace-0", date(4), common.ConditionBackupFailed),
+		newBackup("d", "as-cluster-0", "as-namespace-1", date(5), common.ConditionBackupFinished),
+		newBackup("e", "as-cluster-1", "as-namespace-0", date(6), common.ConditionBackupFinished),
+		newBackup("f", "as-cluster-0", "as-namespace-0", date(7), common.ConditionBackupStarted),
+	}
+
+	tests := []struct {
+		cluster   string
+		namespace string
+		before    time.Time
+		expected  string
+	}{
+		// no backup was created before the specified time
+		{"as-cluster-0", "as-namespace-0", date(0), ""},
+		// backups created at the specified time are considered
+		{"as-cluster-0", "as-namespace-0", date(1), "a"},
+		{"as-cluster-0", "as-namespace-0", date(2), "a"},
+		// failed and running backups are ignored
+		{"as-cluster-0", "as-namespace-0", date(8), "b"},
+		// backups of other clusters and namespaces are ignored
+		{"as-cluster-0", "as-namespace-1", date(8), "d"},
+		{"as-cluster-1", "as-namespace-0", date(8), "e"},
+		{"as-cluster-1", "as-namespace-1", date(8), ""},
+	}
+	for _, test := range tests {
+		target := aerospikev1alpha2.TargetNamespace{Cluster: test.cluster, Namespace: test.namespace}
+		var name string
+		if backup := FindLatestBackup(backups, target, test.before); backup != nil {
+			name = backup.Name
+		}
+		assert.Equal(t, test.expected, name)
+	}
+}
+
+func TestGetSourceStorage(t *testing.T) {
+	newBackup := func(namespace string, spec, status *aerospikev1alpha2.BackupStorageSpec) *aerospikev1alpha2.AerospikeNamespaceBackup {
+		backup := &aerospikev1alpha2.AerospikeNamespaceBackup{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "backup",
+				Namespace: namespace,
+			},
+		}
+		backup.Spec.Storage = spec
+		backup.Status.Storage = status
+		return backup
+	}
+	storage := func(bucket string, secretNamespace *string) *aerospikev1alpha2.BackupStorageSpec {
+		return &aerospikev1alpha2.BackupStorageSpec{
+			Type:            common.StorageTypeGCS,
+			Bucket:          bucket,
+			Secret:          "secret",
+			SecretNamespace: secretNamespace,
+		}
+	}
+	production, other := "production", "other"
+
+	tests := []struct {
+		backup    *aerospikev1alpha2.AerospikeNamespaceBackup
+		namespace string
+		expected  *aerospikev1alpha2.BackupStorageSpec
+	}{
+		// the storage cannot be determined
+		{newBackup(production, nil, nil), production, nil},
+		// the storage in the spec takes precedence over the one in the status
+		{newBackup(production, storage("spec", nil), storage("status", nil)), production, storage("spec", nil)},
+		{newBackup(production, nil, storage("status", nil)), production, storage("status", nil)},
+		// the secret is read from the namespace of the backup
+		{newBackup(production, storage("spec", nil), nil), "staging", storage("spec", &production)},
+		{newBackup(production, storage("spec", &other), nil), "staging", storage("spec", &other)},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.expected, GetSourceStorage(test.backup, test.namespace))
+	}
+}
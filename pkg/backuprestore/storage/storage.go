@@ -0,0 +1,265 @@
+/*
+Copyright 2019 The aerospike-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storage
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/travelaudience/aerospike-operator/pkg/apis/aerospike/common"
+	aerospikev1alpha2 "github.com/travelaudience/aerospike-operator/pkg/apis/aerospike/v1alpha2"
+	"github.com/travelaudience/aerospike-operator/pkg/backuprestore/azure"
+	"github.com/travelaudience/aerospike-operator/pkg/backuprestore/compression"
+	"github.com/travelaudience/aerospike-operator/pkg/backuprestore/encryption"
+	"github.com/travelaudience/aerospike-operator/pkg/backuprestore/gcs"
+	"github.com/travelaudience/aerospike-operator/pkg/backuprestore/objectstorage"
+	"github.com/travelaudience/aerospike-operator/pkg/backuprestore/pvc"
+	"github.com/travelaudience/aerospike-operator/pkg/backuprestore/s3"
+)
+
+// Client is the interface implemented by the clients of every supported
+// backup storage backend. A Client is bound to the bucket specified in the
+// BackupStorageSpec it was created from.
+type Client interface {
+	// NewReader returns a reader for the contents of the specified object.
+	NewReader(objectName string) (io.ReadCloser, error)
+	// NewWriter returns a writer that uploads the data written to it to the
+	// specified object. The upload is only complete when Close returns, and
+	// failed uploads must be aborted.
+	NewWriter(objectName string) (Writer, error)
+	// DeleteObject deletes the specified object.
+	DeleteObject(objectName string) error
+	// Close releases any resources held by the client.
+	Close() error
+}
+
+// Writer is the interface implemented by the writers returned by the clients
+// of every supported backup storage backend.
+type Writer = objectstorage.Writer
+
+// ErrNotFound is the error returned by every Client when reading or deleting
+// an object that does not exist.
+var ErrNotFound = objectstorage.ErrNotFound
+
+// NewClient returns a Client for the backup storage backend described by spec,
+// using the given credentials. Clients for the pvc backend can only be created
+// from within a backup/restore job, as they require the persistent volume claim
+// to be mounted.
+func NewClient(spec *aerospikev1alpha2.BackupStorageSpec, credentials []byte) (Client, error) {
+	switch spec.Type {
+	case common.StorageTypeGCS:
+		return gcs.NewGCSClientFromJSON(credentials, spec.Bucket)
+	case common.StorageTypeS3:
+		return s3.NewS3ClientFromJSON(credentials, spec.Bucket, s3Options(spec))
+	case common.StorageTypeAzure:
+		return azure.NewAzureClientFromJSON(credentials, spec.Bucket, azureOptions(spec))
+	case common.StorageTypePVC:
+		return pvc.NewPVCClient(filepath.Join(pvc.MountPath, spec.Bucket))
+	default:
+		return nil, fmt.Errorf("unsupported storage type %q", spec.Type)
+	}
+}
+
+// Validate checks whether spec and the given credentials are valid for the
+// backup storage backend described by spec. It does not attempt to access the
+// backend. Credentials are ignored for the pvc backend.
+func Validate(spec *aerospikev1alpha2.BackupStorageSpec, credentials []byte) error {
+	if spec.Type == common.StorageTypePVC {
+		if spec.PVC == nil || spec.PVC.ClaimName == "" {
+			return fmt.Errorf("the name of the persistent volume claim must be specified")
+		}
+		if spec.Bucket != "" && (spec.Bucket != filepath.Base(spec.Bucket) || spec.Bucket == ".." || spec.Bucket == ".") {
+			return fmt.Errorf("invalid directory name %q", spec.Bucket)
+		}
+		return nil
+	}
+	if spec.Bucket == "" {
+		return fmt.Errorf("the name of the bucket must be specified")
+	}
+	switch spec.Type {
+	case common.StorageTypeGCS:
+		if _, err := gcs.ParseCredentials(credentials); err != nil {
+			return fmt.Errorf("invalid gcs credentials: %v", err)
+		}
+	case common.StorageTypeS3:
+		if _, err := s3.ParseEndpoint(s3Options(spec)); err != nil {
+			return err
+		}
+		if _, err := s3.ParseCredentials(credentials); err != nil {
+			return err
+		}
+	case common.StorageTypeAzure:
+		if _, err := azure.ParseEndpoint(azureOptions(spec)); err != nil {
+			return err
+		}
+		if _, err := azure.ParseCredentials(credentials); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("unsupported storage type %q", spec.Type)
+	}
+	return nil
+}
+
+// StreamOptions specifies how backup data is transformed before being stored.
+type StreamOptions struct {
+	// Compression is the codec used to compress the data.
+	Compression string
+	// CompressionLevel is the compression level, or 0 to use the codec's
+	// default level. It is ignored when downloading.
+	CompressionLevel int
+	// EncryptionKey is the key used to encrypt the compressed data, or nil if
+	// the data is not encrypted.
+	EncryptionKey []byte
+}
+
+// NewWriter returns a Writer that compresses and encrypts the data written to
+// it as specified in opts, and uploads it to the specified object. The upload
+// is only complete when Close returns, and must be aborted on failure so that
+// no truncated object is created.
+func NewWriter(client Client, objectName string, opts StreamOptions) (Writer, error) {
+	// create a writer that writes to the target object
+	w, err := client.NewWriter(objectName)
+	if err != nil {
+		return nil, err
+	}
+	// create a writer that encrypts the backup data, if requested
+	var ew io.WriteCloser = nopWriteCloser{w}
+	if opts.EncryptionKey != nil {
+		if ew, err = encryption.NewWriter(w, opts.EncryptionKey); err != nil {
+			w.Abort()
+			return nil, err
+		}
+	}
+	// create a writer that compresses the backup data
+	cw, err := compression.NewWriter(ew, opts.Compression, opts.CompressionLevel)
+	if err != nil {
+		w.Abort()
+		return nil, err
+	}
+	return &streamWriter{
+		WriteCloser: cw,
+		encryption:  ew,
+		object:      w,
+	}, nil
+}
+
+// streamWriter compresses and encrypts the data written to it before
+// uploading it.
+type streamWriter struct {
+	// WriteCloser compresses the data written to the writer.
+	io.WriteCloser
+	// encryption encrypts the compressed data.
+	encryption io.WriteCloser
+	// object uploads the encrypted data to the target object.
+	object Writer
+}
+
+// Close flushes the compression and encryption writers and completes the
+// upload, aborting it on failure.
+func (w *streamWriter) Close() error {
+	if err := w.WriteCloser.Close(); err != nil {
+		w.object.Abort()
+		return err
+	}
+	if err := w.encryption.Close(); err != nil {
+		w.object.Abort()
+		return err
+	}
+	return w.object.Close()
+}
+
+// Abort aborts the upload, so that the object is neither created nor
+// replaced.
+func (w *streamWriter) Abort() error {
+	w.WriteCloser.Close()
+	return w.object.Abort()
+}
+
+// Download streams the data in the specified object to w, decrypting and
+// decompressing it as specified in opts.
+func Download(client Client, w io.Writer, objectName string, opts StreamOptions) error {
+	// create a reader that reads from the source object
+	r, err := client.NewReader(objectName)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	// create a reader that decrypts the backup data, if requested
+	var er io.Reader = r
+	if opts.EncryptionKey != nil {
+		if er, err = encryption.NewReader(r, opts.EncryptionKey); err != nil {
+			return err
+		}
+	}
+	// create a reader that decompresses the backup data
+	cr, err := compression.NewReader(er, opts.Compression)
+	if err != nil {
+		return err
+	}
+	defer cr.Close()
+	// read the decompressed backup data from the object
+	s, err := io.Copy(w, cr)
+	if err != nil {
+		return err
+	}
+	log.Infof("%d bytes read", s)
+	return nil
+}
+
+// nopWriteCloser wraps an io.Writer, adding a no-op Close method.
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error {
+	return nil
+}
+
+// s3Options returns the options for an S3 client based on spec.
+func s3Options(spec *aerospikev1alpha2.BackupStorageSpec) s3.Options {
+	opts := s3.Options{}
+	if spec.S3 == nil {
+		return opts
+	}
+	if spec.S3.Endpoint != nil {
+		opts.Endpoint = *spec.S3.Endpoint
+	}
+	if spec.S3.Region != nil {
+		opts.Region = *spec.S3.Region
+	}
+	if spec.S3.ForcePathStyle != nil {
+		opts.ForcePathStyle = *spec.S3.ForcePathStyle
+	}
+	return opts
+}
+
+// azureOptions returns the options for an Azure client based on spec.
+func azureOptions(spec *aerospikev1alpha2.BackupStorageSpec) azure.Options {
+	opts := azure.Options{}
+	if spec.Azure == nil {
+		return opts
+	}
+	opts.StorageAccount = spec.Azure.StorageAccount
+	if spec.Azure.Endpoint != nil {
+		opts.Endpoint = *spec.Azure.Endpoint
+	}
+	return opts
+}
@@ -0,0 +1,61 @@
+/*
+Copyright 2019 The aerospike-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storage
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/travelaudience/aerospike-operator/pkg/apis/aerospike/common"
+	"github.com/travelaudience/aerospike-operator/pkg/backuprestore/encryption"
+	"github.com/travelaudience/aerospike-operator/pkg/backuprestore/pvc"
+)
+
+func TestNewWriter(t *testing.T) {
+	dir, err := ioutil.TempDir("", "storage")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	client, err := pvc.NewPVCClient(dir)
+	assert.NoError(t, err)
+	opts := StreamOptions{
+		Compression:   common.CompressionCodecGzip,
+		EncryptionKey: bytes.Repeat([]byte{1}, encryption.KeySize),
+	}
+
+	// aborted uploads must not create the object
+	w, err := NewWriter(client, "aborted.asb.gz", opts)
+	assert.NoError(t, err)
+	_, err = w.Write([]byte("aerospike"))
+	assert.NoError(t, err)
+	assert.NoError(t, w.Abort())
+	_, err = client.NewReader("aborted.asb.gz")
+	assert.Equal(t, ErrNotFound, err)
+
+	// completed uploads must be readable with the same options
+	w, err = NewWriter(client, "backup.asb.gz", opts)
+	assert.NoError(t, err)
+	_, err = w.Write([]byte("aerospike"))
+	assert.NoError(t, err)
+	assert.NoError(t, w.Close())
+	var buf bytes.Buffer
+	assert.NoError(t, Download(client, &buf, "backup.asb.gz", opts))
+	assert.Equal(t, "aerospike", buf.String())
+}
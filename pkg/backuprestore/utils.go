@@ -16,15 +16,24 @@ limitations under the License.
 
 package backuprestore
 
-import "fmt"
+import (
+	"fmt"
+
+	"github.com/travelaudience/aerospike-operator/pkg/backuprestore/compression"
+)
 
 const (
 	// metaObjectFormatString represents the string format used by the backup tool
 	// to generate the metadata file name.
 	metaObjectFormatString = "%s.json"
 	// backupObjectFormatString represents the string format used by the backup tool
-	// to generate the backup data file name.
-	backupObjectFormatString = "%s.asb.gz"
+	// to generate the backup data file name, before appending the extension of
+	// the compression codec.
+	backupObjectFormatString = "%s.asb"
+	// shardFormatString represents the string format used by the backup tool
+	// to generate the name of each shard of a backup split into more than one
+	// shard, from which the names of the shard's objects are generated.
+	shardFormatString = "%s-shard-%d"
 )
 
 // GetObjectName returns the object name formatted according to
@@ -33,6 +42,28 @@ func GetMetadataObjectName(asNamespaceBackupName string) string {
 	return fmt.Sprintf(metaObjectFormatString, asNamespaceBackupName)
 }
 
-func GetBackupObjectName(asNamespaceBackupName string) string {
-	return fmt.Sprintf(backupObjectFormatString, asNamespaceBackupName)
+// GetBackupObjectName returns the name of the object holding the backup data
+// compressed using the specified codec.
+func GetBackupObjectName(asNamespaceBackupName, codec string) string {
+	return fmt.Sprintf(backupObjectFormatString, asNamespaceBackupName) + compression.Extension(codec)
+}
+
+// GetShardName returns the name of the specified shard of a backup split into
+// more than one shard.
+func GetShardName(asNamespaceBackupName string, shard int) string {
+	return fmt.Sprintf(shardFormatString, asNamespaceBackupName, shard)
+}
+
+// GetShardNames returns the names from which the names of the objects holding
+// the data of a backup split into the specified number of shards are
+// generated.
+func GetShardNames(asNamespaceBackupName string, shards int) []string {
+	if shards <= 1 {
+		return []string{asNamespaceBackupName}
+	}
+	res := make([]string, shards)
+	for i := range res {
+		res[i] = GetShardName(asNamespaceBackupName, i)
+	}
+	return res
 }
@@ -0,0 +1,210 @@
+/*
+Copyright 2019 The aerospike-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package backupschedule
+
+import (
+	"fmt"
+	"reflect"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+
+	aerospikev1alpha2 "github.com/travelaudience/aerospike-operator/pkg/apis/aerospike/v1alpha2"
+	aerospikeclientset "github.com/travelaudience/aerospike-operator/pkg/client/clientset/versioned"
+	aerospikelisters "github.com/travelaudience/aerospike-operator/pkg/client/listers/aerospike/v1alpha2"
+	"github.com/travelaudience/aerospike-operator/pkg/crd"
+	"github.com/travelaudience/aerospike-operator/pkg/garbagecollector"
+	"github.com/travelaudience/aerospike-operator/pkg/logfields"
+	"github.com/travelaudience/aerospike-operator/pkg/meta"
+	"github.com/travelaudience/aerospike-operator/pkg/pointers"
+	"github.com/travelaudience/aerospike-operator/pkg/utils/cron"
+	"github.com/travelaudience/aerospike-operator/pkg/utils/events"
+	"github.com/travelaudience/aerospike-operator/pkg/utils/selectors"
+)
+
+type AerospikeNamespaceBackupScheduleHandler struct {
+	aerospikeclientset             aerospikeclientset.Interface
+	aerospikeNamespaceBackupLister aerospikelisters.AerospikeNamespaceBackupLister
+	recorder                       record.EventRecorder
+	// now returns the current time (overridden in tests)
+	now func() time.Time
+}
+
+func New(aerospikeclientset aerospikeclientset.Interface,
+	aerospikeNamespaceBackupLister aerospikelisters.AerospikeNamespaceBackupLister,
+	recorder record.EventRecorder) *AerospikeNamespaceBackupScheduleHandler {
+	return &AerospikeNamespaceBackupScheduleHandler{
+		aerospikeclientset:             aerospikeclientset,
+		aerospikeNamespaceBackupLister: aerospikeNamespaceBackupLister,
+		recorder:                       recorder,
+		now:                            time.Now,
+	}
+}
+
+// Handle creates a new aerospikenamespacebackup if one is due, updates the
+// status of the schedule and marks the backups exceeding the retention count
+// as expired. It returns the duration after which the schedule must be handled
+// again, or zero if the schedule will never be due.
+func (h *AerospikeNamespaceBackupScheduleHandler) Handle(schedule *aerospikev1alpha2.AerospikeNamespaceBackupSchedule) (time.Duration, error) {
+	log.WithFields(log.Fields{
+		logfields.Key: meta.Key(schedule),
+	}).Debug("checking whether action is needed")
+
+	// parse the cron expression (already validated by the admission webhook)
+	cronSchedule, err := cron.Parse(schedule.Spec.Schedule)
+	if err != nil {
+		h.recorder.Eventf(schedule, v1.EventTypeWarning, events.ReasonValidationError,
+			"invalid schedule: %v", err)
+		return 0, nil
+	}
+
+	// list the backups created by the schedule
+	backups, err := h.aerospikeNamespaceBackupLister.AerospikeNamespaceBackups(schedule.Namespace).List(selectors.ResourcesByBackupScheduleName(schedule.Name))
+	if err != nil {
+		return 0, err
+	}
+
+	oldStatus := schedule.Status.DeepCopy()
+	now := h.now().UTC()
+
+	// create a backup for the latest scheduled time we've missed, if any
+	if missed := latestMissedTime(schedule, cronSchedule, now); !missed.IsZero() {
+		if schedule.IsSuspended() {
+			log.WithFields(log.Fields{
+				logfields.Key: meta.Key(schedule),
+			}).Debug("schedule is suspended")
+		} else {
+			backup, err := h.createBackup(schedule, missed)
+			if err != nil {
+				return 0, err
+			}
+			backups = append(backups, backup)
+			schedule.Status.LastScheduleTime = &metav1.Time{Time: missed}
+			schedule.Status.LastBackup = backup.Name
+		}
+	}
+
+	// report the latest successful and failed backups and prune the ones that
+	// exceed the retention count
+	succeeded, failed := classifyBackups(backups)
+	if len(succeeded) > 0 {
+		schedule.Status.LastSuccessfulBackup = succeeded[0].backup.Name
+		schedule.Status.LastSuccessfulTime = &succeeded[0].time
+	}
+	if len(failed) > 0 {
+		schedule.Status.LastFailedBackup = failed[0].backup.Name
+		schedule.Status.LastFailureTime = &failed[0].time
+	}
+	if err := h.pruneBackups(schedule, succeeded); err != nil {
+		return 0, err
+	}
+	if err := h.pruneBackups(schedule, failed); err != nil {
+		return 0, err
+	}
+
+	if !reflect.DeepEqual(oldStatus, &schedule.Status) {
+		if _, err := h.aerospikeclientset.AerospikeV1alpha2().AerospikeNamespaceBackupSchedules(schedule.Namespace).UpdateStatus(schedule); err != nil {
+			return 0, err
+		}
+	}
+
+	next := cronSchedule.Next(now)
+	if next.IsZero() {
+		return 0, nil
+	}
+	return next.Sub(now), nil
+}
+
+// createBackup creates the aerospikenamespacebackup corresponding to the
+// specified scheduled time. backups are named after the scheduled time so that
+// a given time never results in more than one backup.
+func (h *AerospikeNamespaceBackupScheduleHandler) createBackup(schedule *aerospikev1alpha2.AerospikeNamespaceBackupSchedule, scheduledTime time.Time) (*aerospikev1alpha2.AerospikeNamespaceBackup, error) {
+	backup := &aerospikev1alpha2.AerospikeNamespaceBackup{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("%s-%d", schedule.Name, scheduledTime.Unix()),
+			Namespace: schedule.Namespace,
+			Labels: map[string]string{
+				selectors.LabelAppKey:            selectors.LabelAppVal,
+				selectors.LabelBackupScheduleKey: schedule.Name,
+			},
+			OwnerReferences: []metav1.OwnerReference{
+				{
+					APIVersion:         aerospikev1alpha2.SchemeGroupVersion.String(),
+					Kind:               crd.AerospikeNamespaceBackupScheduleKind,
+					Name:               schedule.Name,
+					UID:                schedule.UID,
+					Controller:         pointers.NewBool(true),
+					BlockOwnerDeletion: pointers.NewBool(true),
+				},
+			},
+		},
+		Spec: aerospikev1alpha2.AerospikeNamespaceBackupSpec{
+			Target:      schedule.Spec.Target,
+			Storage:     schedule.Spec.Storage.DeepCopy(),
+			TTL:         schedule.Spec.TTL,
+			Parallelism: schedule.Spec.Parallelism,
+		},
+	}
+	res, err := h.aerospikeclientset.AerospikeV1alpha2().AerospikeNamespaceBackups(schedule.Namespace).Create(backup)
+	if err != nil {
+		if errors.IsAlreadyExists(err) {
+			return h.aerospikeclientset.AerospikeV1alpha2().AerospikeNamespaceBackups(schedule.Namespace).Get(backup.Name, metav1.GetOptions{})
+		}
+		return nil, err
+	}
+
+	log.WithFields(log.Fields{
+		logfields.Key: meta.Key(schedule),
+	}).Infof("backup created as %s", meta.Key(res))
+	h.recorder.Eventf(schedule, v1.EventTypeNormal, events.ReasonBackupScheduled,
+		"backup created as %s", meta.Key(res))
+	return res, nil
+}
+
+// pruneBackups marks the backups in the specified list (sorted from newest to
+// oldest) that exceed the retention count of the schedule as expired, so that
+// they are deleted by the garbage collector.
+func (h *AerospikeNamespaceBackupScheduleHandler) pruneBackups(schedule *aerospikev1alpha2.AerospikeNamespaceBackupSchedule, backups []finishedBackup) error {
+	retentionCount := int(schedule.GetRetentionCount())
+	if retentionCount == 0 || len(backups) <= retentionCount {
+		return nil
+	}
+	for _, b := range backups[retentionCount:] {
+		if b.backup.Annotations[garbagecollector.ExpiredAnnotation] == "true" {
+			continue
+		}
+		backup := b.backup.DeepCopy()
+		if backup.Annotations == nil {
+			backup.Annotations = make(map[string]string)
+		}
+		backup.Annotations[garbagecollector.ExpiredAnnotation] = "true"
+		if _, err := h.aerospikeclientset.AerospikeV1alpha2().AerospikeNamespaceBackups(backup.Namespace).Update(backup); err != nil {
+			return err
+		}
+
+		log.WithFields(log.Fields{
+			logfields.Key: meta.Key(schedule),
+		}).Infof("backup %s exceeds the retention count and has been marked as expired", meta.Key(backup))
+		h.recorder.Eventf(schedule, v1.EventTypeNormal, events.ReasonBackupPruned,
+			"backup %s exceeds the retention count and has been marked as expired", meta.Key(backup))
+	}
+	return nil
+}
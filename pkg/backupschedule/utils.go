@@ -0,0 +1,86 @@
+/*
+Copyright 2019 The aerospike-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package backupschedule
+
+import (
+	"sort"
+	"time"
+
+	"k8s.io/apiextensions-apiserver/pkg/apis/apiextensions"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	aerospikev1alpha2 "github.com/travelaudience/aerospike-operator/pkg/apis/aerospike/v1alpha2"
+	"github.com/travelaudience/aerospike-operator/pkg/utils/cron"
+)
+
+// finishedBackup holds a backup that has either finished or failed, together
+// with the time at which it did so.
+type finishedBackup struct {
+	backup *aerospikev1alpha2.AerospikeNamespaceBackup
+	time   metav1.Time
+}
+
+// latestMissedTime returns the latest time matching cronSchedule that is not
+// after now and after the last time a backup was scheduled (or the schedule
+// was created), or the zero time if no backup is due.
+func latestMissedTime(schedule *aerospikev1alpha2.AerospikeNamespaceBackupSchedule, cronSchedule *cron.Schedule, now time.Time) time.Time {
+	last := schedule.CreationTimestamp.Time
+	if schedule.Status.LastScheduleTime != nil {
+		last = schedule.Status.LastScheduleTime.Time
+	}
+	var missed time.Time
+	for t := cronSchedule.Next(last); !t.IsZero() && !t.After(now); t = cronSchedule.Next(t) {
+		missed = t
+	}
+	return missed
+}
+
+// classifyBackups returns the backups that have finished and the ones that
+// have failed, sorted from newest to oldest. backups that are still running
+// are not included.
+func classifyBackups(backups []*aerospikev1alpha2.AerospikeNamespaceBackup) ([]finishedBackup, []finishedBackup) {
+	var succeeded, failed []finishedBackup
+	for _, backup := range backups {
+		for _, c := range backup.Status.Conditions {
+			if c.Status != apiextensions.ConditionTrue {
+				continue
+			}
+			if c.Type == backup.GetFinishedConditionType() {
+				succeeded = append(succeeded, finishedBackup{backup: backup, time: c.LastTransitionTime})
+				break
+			}
+			if c.Type == backup.GetFailedConditionType() {
+				failed = append(failed, finishedBackup{backup: backup, time: c.LastTransitionTime})
+				break
+			}
+		}
+	}
+	sortNewestFirst(succeeded)
+	sortNewestFirst(failed)
+	return succeeded, failed
+}
+
+// sortNewestFirst sorts backups by creation time, from newest to oldest.
+func sortNewestFirst(backups []finishedBackup) {
+	sort.SliceStable(backups, func(i, j int) bool {
+		ti, tj := backups[i].backup.CreationTimestamp, backups[j].backup.CreationTimestamp
+		if ti.Equal(&tj) {
+			return backups[i].backup.Name > backups[j].backup.Name
+		}
+		return tj.Before(&ti)
+	})
+}
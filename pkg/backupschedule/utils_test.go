@@ -0,0 +1,108 @@
+/*
+Copyright 2019 The aerospike-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package backupschedule
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apiextensions-apiserver/pkg/apis/apiextensions"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/travelaudience/aerospike-operator/pkg/apis/aerospike/common"
+	aerospikev1alpha2 "github.com/travelaudience/aerospike-operator/pkg/apis/aerospike/v1alpha2"
+	"github.com/travelaudience/aerospike-operator/pkg/utils/cron"
+)
+
+func date(hour, minute int) time.Time {
+	return time.Date(2019, time.January, 1, hour, minute, 0, 0, time.UTC)
+}
+
+func TestLatestMissedTime(t *testing.T) {
+	cronSchedule, err := cron.Parse("0 * * * *")
+	assert.NoError(t, err)
+
+	tests := []struct {
+		created      time.Time
+		lastSchedule time.Time
+		now          time.Time
+		expected     time.Time
+	}{
+		// the first backup is not due yet
+		{date(0, 30), time.Time{}, date(0, 59), time.Time{}},
+		// the first backup is due
+		{date(0, 30), time.Time{}, date(1, 0), date(1, 0)},
+		// only the latest of several missed backups is due
+		{date(0, 30), time.Time{}, date(4, 10), date(4, 0)},
+		// the latest backup has already been scheduled
+		{date(0, 30), date(4, 0), date(4, 10), time.Time{}},
+		{date(0, 30), date(4, 0), date(5, 0), date(5, 0)},
+	}
+	for _, test := range tests {
+		schedule := &aerospikev1alpha2.AerospikeNamespaceBackupSchedule{
+			ObjectMeta: metav1.ObjectMeta{
+				CreationTimestamp: metav1.NewTime(test.created),
+			},
+		}
+		if !test.lastSchedule.IsZero() {
+			schedule.Status.LastScheduleTime = &metav1.Time{Time: test.lastSchedule}
+		}
+		assert.Equal(t, test.expected, latestMissedTime(schedule, cronSchedule, test.now))
+	}
+}
+
+func TestClassifyBackups(t *testing.T) {
+	newBackup := func(name string, created time.Time, conditionTypes ...apiextensions.CustomResourceDefinitionConditionType) *aerospikev1alpha2.AerospikeNamespaceBackup {
+		backup := &aerospikev1alpha2.AerospikeNamespaceBackup{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              name,
+				CreationTimestamp: metav1.NewTime(created),
+			},
+		}
+		for _, conditionType := range conditionTypes {
+			backup.Status.Conditions = append(backup.Status.Conditions, apiextensions.CustomResourceDefinitionCondition{
+				Type:               conditionType,
+				Status:             apiextensions.ConditionTrue,
+				LastTransitionTime: metav1.NewTime(created.Add(time.Minute)),
+			})
+		}
+		return backup
+	}
+
+	succeeded, failed := classifyBackups([]*aerospikev1alpha2.AerospikeNamespaceBackup{
+		newBackup("b", date(1, 0), common.ConditionBackupStarted, common.ConditionBackupFinished),
+		newBackup("d", date(3, 0), common.ConditionBackupStarted, common.ConditionBackupFinished),
+		newBackup("a", date(0, 0), common.ConditionBackupStarted, common.ConditionBackupFailed),
+		newBackup("e", date(4, 0), common.ConditionBackupStarted),
+		newBackup("c", date(2, 0), common.ConditionBackupStarted, common.ConditionBackupFailed),
+		newBackup("f", date(3, 0), common.ConditionBackupStarted, common.ConditionBackupFinished),
+	})
+
+	var names []string
+	for _, b := range succeeded {
+		names = append(names, b.backup.Name)
+	}
+	assert.Equal(t, []string{"f", "d", "b"}, names)
+	assert.Equal(t, metav1.NewTime(date(3, 1)), succeeded[0].time)
+
+	names = nil
+	for _, b := range failed {
+		names = append(names, b.backup.Name)
+	}
+	assert.Equal(t, []string{"c", "a"}, names)
+}
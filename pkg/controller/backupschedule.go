@@ -0,0 +1,158 @@
+/*
+Copyright 2019 The aerospike-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/travelaudience/aerospike-operator/pkg/backupschedule"
+	aerospikeclientset "github.com/travelaudience/aerospike-operator/pkg/client/clientset/versioned"
+	aerospikeinformers "github.com/travelaudience/aerospike-operator/pkg/client/informers/externalversions"
+	aerospikelisters "github.com/travelaudience/aerospike-operator/pkg/client/listers/aerospike/v1alpha2"
+	"github.com/travelaudience/aerospike-operator/pkg/utils/selectors"
+)
+
+const (
+	// backupScheduleControllerDefaultThreadiness is the number of workers the
+	// backup schedule controller will use to process items from the queue.
+	backupScheduleControllerDefaultThreadiness = 1
+)
+
+// AerospikeNamespaceBackupScheduleController is the controller for AerospikeNamespaceBackupSchedule resources
+type AerospikeNamespaceBackupScheduleController struct {
+	*genericController
+	aerospikeNamespaceBackupScheduleLister aerospikelisters.AerospikeNamespaceBackupScheduleLister
+	handler                                *backupschedule.AerospikeNamespaceBackupScheduleHandler
+}
+
+// NewAerospikeNamespaceBackupScheduleController returns a new controller for AerospikeNamespaceBackupSchedule resources
+func NewAerospikeNamespaceBackupScheduleController(
+	kubeClient kubernetes.Interface,
+	aerospikeClient aerospikeclientset.Interface,
+	aerospikeInformerFactory aerospikeinformers.SharedInformerFactory) *AerospikeNamespaceBackupScheduleController {
+
+	// obtain references to shared informers for the required types
+	aerospikeNamespaceBackupInformer := aerospikeInformerFactory.Aerospike().V1alpha2().AerospikeNamespaceBackups()
+	aerospikeNamespaceBackupScheduleInformer := aerospikeInformerFactory.Aerospike().V1alpha2().AerospikeNamespaceBackupSchedules()
+
+	// obtain references to listers for the required types
+	aerospikeNamespaceBackupLister := aerospikeNamespaceBackupInformer.Lister()
+	aerospikeNamespaceBackupScheduleLister := aerospikeNamespaceBackupScheduleInformer.Lister()
+
+	c := &AerospikeNamespaceBackupScheduleController{
+		genericController:                      newGenericController("aerospikenamespacebackupschedule", backupScheduleControllerDefaultThreadiness, kubeClient),
+		aerospikeNamespaceBackupScheduleLister: aerospikeNamespaceBackupScheduleLister,
+	}
+	c.hasSyncedFuncs = []cache.InformerSynced{
+		aerospikeNamespaceBackupInformer.Informer().HasSynced,
+		aerospikeNamespaceBackupScheduleInformer.Informer().HasSynced,
+	}
+	c.syncHandler = c.processQueueItem
+
+	c.handler = backupschedule.New(aerospikeClient, aerospikeNamespaceBackupLister, c.recorder)
+	c.logger.Debug("setting up event handlers")
+
+	// setup an event handler for when AerospikeNamespaceBackupSchedule resources change
+	aerospikeNamespaceBackupScheduleInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: c.enqueue,
+		UpdateFunc: func(_, obj interface{}) {
+			c.enqueue(obj)
+		},
+	})
+	// setup an event handler for when AerospikeNamespaceBackup resources
+	// change, so that the status of the schedule that created them is kept up
+	// to date.
+	aerospikeNamespaceBackupInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: c.handleObject,
+		UpdateFunc: func(_, obj interface{}) {
+			c.handleObject(obj)
+		},
+		DeleteFunc: c.handleObject,
+	})
+
+	return c
+}
+
+// processQueueItem compares the actual state with the desired, and attempts to converge the two
+func (c *AerospikeNamespaceBackupScheduleController) processQueueItem(key string) error {
+	// Convert the namespace/name string into a distinct namespace and name
+	namespace, name, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		runtime.HandleError(fmt.Errorf("invalid resource key: %s", key))
+		return nil
+	}
+
+	// Get the AerospikeNamespaceBackupSchedule resource with this namespace/name
+	aerospikeNamespaceBackupSchedule, err := c.aerospikeNamespaceBackupScheduleLister.AerospikeNamespaceBackupSchedules(namespace).Get(name)
+	if err != nil {
+		// The AerospikeNamespaceBackupSchedule resource may no longer exist, in which case we stop
+		// processing.
+		if errors.IsNotFound(err) {
+			runtime.HandleError(fmt.Errorf("aerospikenamespacebackupschedule '%s' in work queue no longer exists", key))
+			return nil
+		}
+		return err
+	}
+
+	// deepcopy aerospikeNamespaceBackupSchedule before handling it so we don't possibly mutate the cache
+	next, err := c.handler.Handle(aerospikeNamespaceBackupSchedule.DeepCopy())
+	if err != nil {
+		return err
+	}
+	// process the schedule again as soon as the next backup is due
+	if next > 0 {
+		c.workqueue.AddAfter(key, next)
+	}
+	return nil
+}
+
+// handleObject will take any resource implementing metav1.Object and enqueue
+// the AerospikeNamespaceBackupSchedule resource that created it, if any.
+func (c *AerospikeNamespaceBackupScheduleController) handleObject(obj interface{}) {
+	var object metav1.Object
+	var ok bool
+	if object, ok = obj.(metav1.Object); !ok {
+		tombstone, ok := obj.(cache.DeletedFinalStateUnknown)
+		if !ok {
+			runtime.HandleError(fmt.Errorf("error decoding object, invalid type"))
+			return
+		}
+		object, ok = tombstone.Obj.(metav1.Object)
+		if !ok {
+			runtime.HandleError(fmt.Errorf("error decoding object tombstone, invalid type"))
+			return
+		}
+		c.logger.Debugf("recovered deleted object '%s' from tombstone", object.GetName())
+	}
+	scheduleName, ok := object.GetLabels()[selectors.LabelBackupScheduleKey]
+	if !ok {
+		return
+	}
+	c.logger.Debugf("processing object: %s", object.GetName())
+	asnbs, err := c.aerospikeNamespaceBackupScheduleLister.AerospikeNamespaceBackupSchedules(object.GetNamespace()).Get(scheduleName)
+	if err != nil {
+		c.logger.Debugf("ignoring object '%s' of aerospikenamespacebackupschedule '%s'", object.GetSelfLink(), scheduleName)
+		return
+	}
+	c.enqueue(asnbs)
+}
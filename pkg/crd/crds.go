@@ -19,6 +19,7 @@ package crd
 import (
 	"fmt"
 
+	corev1 "k8s.io/api/core/v1"
 	extsv1beta1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
@@ -42,18 +43,60 @@ const (
 	AerospikeNamespaceRestorePlural = "aerospikenamespacerestores"
 	AerospikeNamespaceRestoreShort  = "asnr"
 
+	AerospikeNamespaceBackupScheduleKind   = common.AerospikeNamespaceBackupScheduleKind
+	AerospikeNamespaceBackupSchedulePlural = "aerospikenamespacebackupschedules"
+	AerospikeNamespaceBackupScheduleShort  = "asnbs"
+
 	// ttlPattern is the regex used to match a number of days (with
 	// optional fraction) suffixed with a "d"
 	ttlPattern = `^([0-9]*[.])?[0-9]+d$`
+	// agePattern is the regex used to match a number of seconds, minutes,
+	// hours or days (with optional fraction) suffixed with the unit
+	agePattern = `^([0-9]*[.])?[0-9]+[smhd]$`
+	// tlsNamePattern is the regex used to match the name a certificate is
+	// valid for (i.e. a dns name), which is also used as the name of a tls
+	// context in the Aerospike configuration
+	tlsNamePattern = `^[a-zA-Z0-9]([-a-zA-Z0-9]*[a-zA-Z0-9])?(\.[a-zA-Z0-9]([-a-zA-Z0-9]*[a-zA-Z0-9])?)*$`
 )
 
 var (
-	AerospikeClusterCRDName          = fmt.Sprintf("%s.%s", AerospikeClusterPlural, aerospikev1alpha2.SchemeGroupVersion.Group)
-	AerospikeNamespaceBackupCRDName  = fmt.Sprintf("%s.%s", AerospikeNamespaceBackupPlural, aerospikev1alpha2.SchemeGroupVersion.Group)
-	AerospikeNamespaceRestoreCRDName = fmt.Sprintf("%s.%s", AerospikeNamespaceRestorePlural, aerospikev1alpha2.SchemeGroupVersion.Group)
+	AerospikeClusterCRDName                 = fmt.Sprintf("%s.%s", AerospikeClusterPlural, aerospikev1alpha2.SchemeGroupVersion.Group)
+	AerospikeNamespaceBackupCRDName         = fmt.Sprintf("%s.%s", AerospikeNamespaceBackupPlural, aerospikev1alpha2.SchemeGroupVersion.Group)
+	AerospikeNamespaceRestoreCRDName        = fmt.Sprintf("%s.%s", AerospikeNamespaceRestorePlural, aerospikev1alpha2.SchemeGroupVersion.Group)
+	AerospikeNamespaceBackupScheduleCRDName = fmt.Sprintf("%s.%s", AerospikeNamespaceBackupSchedulePlural, aerospikev1alpha2.SchemeGroupVersion.Group)
 )
 
 var (
+	// configOverridesProps describes a set of overrides for the parameters of
+	// a context of the Aerospike configuration, which are validated by the
+	// admission webhook
+	configOverridesProps = extsv1beta1.JSONSchemaProps{
+		Type: "object",
+		AdditionalProperties: &extsv1beta1.JSONSchemaPropsOrBool{
+			Schema: &extsv1beta1.JSONSchemaProps{
+				Type: "string",
+			},
+		},
+	}
+	// tlsListenerProps describes the tls configuration of a listener of an
+	// Aerospike cluster
+	tlsListenerProps = extsv1beta1.JSONSchemaProps{
+		Type: "object",
+		Properties: map[string]extsv1beta1.JSONSchemaProps{
+			"secretName": {
+				Type:      "string",
+				MinLength: pointers.NewInt64(1),
+			},
+			"tlsName": {
+				Type:    "string",
+				Pattern: tlsNamePattern,
+			},
+		},
+		Required: []string{
+			"secretName",
+			"tlsName",
+		},
+	}
 	backupStorageSpecProps = extsv1beta1.JSONSchemaProps{
 		Type: "object",
 		Properties: map[string]extsv1beta1.JSONSchemaProps{
@@ -61,6 +104,9 @@ var (
 				Type: "string",
 				Enum: []extsv1beta1.JSON{
 					{Raw: []byte(asstrings.DoubleQuoted(common.StorageTypeGCS))},
+					{Raw: []byte(asstrings.DoubleQuoted(common.StorageTypeS3))},
+					{Raw: []byte(asstrings.DoubleQuoted(common.StorageTypeAzure))},
+					{Raw: []byte(asstrings.DoubleQuoted(common.StorageTypePVC))},
 				},
 			},
 			"bucket": {
@@ -79,11 +125,93 @@ var (
 				Type:      "string",
 				MinLength: pointers.NewInt64(1),
 			},
+			"s3": {
+				Type: "object",
+				Properties: map[string]extsv1beta1.JSONSchemaProps{
+					"endpoint": {
+						Type:      "string",
+						MinLength: pointers.NewInt64(1),
+					},
+					"region": {
+						Type:      "string",
+						MinLength: pointers.NewInt64(1),
+					},
+					"forcePathStyle": {
+						Type: "boolean",
+					},
+				},
+			},
+			"azure": {
+				Type: "object",
+				Properties: map[string]extsv1beta1.JSONSchemaProps{
+					"storageAccount": {
+						Type:      "string",
+						MinLength: pointers.NewInt64(1),
+					},
+					"endpoint": {
+						Type:      "string",
+						MinLength: pointers.NewInt64(1),
+					},
+				},
+				Required: []string{
+					"storageAccount",
+				},
+			},
+			"pvc": {
+				Type: "object",
+				Properties: map[string]extsv1beta1.JSONSchemaProps{
+					"claimName": {
+						Type:      "string",
+						MinLength: pointers.NewInt64(1),
+					},
+				},
+				Required: []string{
+					"claimName",
+				},
+			},
+			"encryption": {
+				Type: "object",
+				Properties: map[string]extsv1beta1.JSONSchemaProps{
+					"secret": {
+						Type:      "string",
+						MinLength: pointers.NewInt64(1),
+					},
+					"secretKey": {
+						Type:      "string",
+						MinLength: pointers.NewInt64(1),
+					},
+				},
+				Required: []string{
+					"secret",
+				},
+			},
+			// the compression level is validated by the admission webhook, as
+			// the valid range depends on the codec
+			"compression": {
+				Type: "object",
+				Properties: map[string]extsv1beta1.JSONSchemaProps{
+					"codec": {
+						Type: "string",
+						Enum: []extsv1beta1.JSON{
+							{Raw: []byte(asstrings.DoubleQuoted(common.CompressionCodecNone))},
+							{Raw: []byte(asstrings.DoubleQuoted(common.CompressionCodecGzip))},
+							{Raw: []byte(asstrings.DoubleQuoted(common.CompressionCodecZstd))},
+						},
+					},
+					"level": {
+						Type:    "integer",
+						Minimum: pointers.NewFloat64(1),
+					},
+				},
+				Required: []string{
+					"codec",
+				},
+			},
 		},
+		// bucket and secret are required for every type of storage but pvc,
+		// and are validated by the admission webhook
 		Required: []string{
 			"type",
-			"bucket",
-			"secret",
 		},
 	}
 
@@ -105,6 +233,16 @@ var (
 		},
 	}
 
+	namesProps = extsv1beta1.JSONSchemaProps{
+		Type: "array",
+		Items: &extsv1beta1.JSONSchemaPropsOrArray{
+			Schema: &extsv1beta1.JSONSchemaProps{
+				Type:      "string",
+				MinLength: pointers.NewInt64(1),
+			},
+		},
+	}
+
 	crds = []*extsv1beta1.CustomResourceDefinition{
 		{
 			ObjectMeta: metav1.ObjectMeta{
@@ -145,7 +283,8 @@ var (
 										Pattern: `^\d+\.\d+\.\d+(\.\d+)?$`,
 									},
 									"namespaces": {
-										Type: "array",
+										Type:     "array",
+										MinItems: pointers.NewInt64(1),
 										Items: &extsv1beta1.JSONSchemaPropsOrArray{
 											Schema: &extsv1beta1.JSONSchemaProps{
 												Title: "namespace",
@@ -192,12 +331,14 @@ var (
 															"dataInMemory": {
 																Type: "boolean",
 															},
+															"config": configOverridesProps,
 														},
 														Required: []string{
 															"type",
 															"size",
 														},
 													},
+													"config": configOverridesProps,
 												},
 												Required: []string{
 													"name",
@@ -219,6 +360,218 @@ var (
 											"storage",
 										},
 									},
+									"upgradePolicy": {
+										Type: "object",
+										Properties: map[string]extsv1beta1.JSONSchemaProps{
+											"backup": {
+												Type: "string",
+												Enum: []extsv1beta1.JSON{
+													{Raw: []byte(asstrings.DoubleQuoted(common.UpgradeBackupPolicyRequired))},
+													{Raw: []byte(asstrings.DoubleQuoted(common.UpgradeBackupPolicySkip))},
+													{Raw: []byte(asstrings.DoubleQuoted(common.UpgradeBackupPolicyReuse))},
+												},
+											},
+											"maxBackupAge": {
+												Type:    "string",
+												Pattern: agePattern,
+											},
+										},
+										Required: []string{
+											"backup",
+										},
+									},
+									"racks": {
+										Type: "array",
+										Items: &extsv1beta1.JSONSchemaPropsOrArray{
+											Schema: &extsv1beta1.JSONSchemaProps{
+												Type: "object",
+												Properties: map[string]extsv1beta1.JSONSchemaProps{
+													"id": {
+														Type:    "integer",
+														Minimum: pointers.NewFloat64(1),
+														Maximum: pointers.NewFloat64(1000000),
+													},
+													"zone": {
+														Type:      "string",
+														MinLength: pointers.NewInt64(1),
+													},
+													"nodeLabels": {
+														Type: "object",
+														AdditionalProperties: &extsv1beta1.JSONSchemaPropsOrBool{
+															Schema: &extsv1beta1.JSONSchemaProps{
+																Type: "string",
+															},
+														},
+													},
+												},
+												Required: []string{
+													"id",
+												},
+											},
+										},
+									},
+									"security": {
+										Type: "object",
+										Properties: map[string]extsv1beta1.JSONSchemaProps{
+											"adminSecret": {
+												Type:      "string",
+												MinLength: pointers.NewInt64(1),
+											},
+											"users": {
+												Type: "array",
+												Items: &extsv1beta1.JSONSchemaPropsOrArray{
+													Schema: &extsv1beta1.JSONSchemaProps{
+														Type: "object",
+														Properties: map[string]extsv1beta1.JSONSchemaProps{
+															"name": {
+																Type:      "string",
+																MinLength: pointers.NewInt64(1),
+															},
+															"passwordSecret": {
+																Type:      "string",
+																MinLength: pointers.NewInt64(1),
+															},
+															"roles": {
+																Type: "array",
+																Items: &extsv1beta1.JSONSchemaPropsOrArray{
+																	Schema: &extsv1beta1.JSONSchemaProps{
+																		Type:      "string",
+																		MinLength: pointers.NewInt64(1),
+																	},
+																},
+															},
+														},
+														Required: []string{
+															"name",
+															"passwordSecret",
+														},
+													},
+												},
+											},
+											"roles": {
+												Type: "array",
+												Items: &extsv1beta1.JSONSchemaPropsOrArray{
+													Schema: &extsv1beta1.JSONSchemaProps{
+														Type: "object",
+														Properties: map[string]extsv1beta1.JSONSchemaProps{
+															"name": {
+																Type:      "string",
+																MinLength: pointers.NewInt64(1),
+															},
+															"privileges": {
+																Type:     "array",
+																MinItems: pointers.NewInt64(1),
+																Items: &extsv1beta1.JSONSchemaPropsOrArray{
+																	Schema: &extsv1beta1.JSONSchemaProps{
+																		Type: "object",
+																		Properties: map[string]extsv1beta1.JSONSchemaProps{
+																			"code": {
+																				Type: "string",
+																				Enum: []extsv1beta1.JSON{
+																					{Raw: []byte(asstrings.DoubleQuoted(common.PrivilegeUserAdmin))},
+																					{Raw: []byte(asstrings.DoubleQuoted(common.PrivilegeSysAdmin))},
+																					{Raw: []byte(asstrings.DoubleQuoted(common.PrivilegeDataAdmin))},
+																					{Raw: []byte(asstrings.DoubleQuoted(common.PrivilegeRead))},
+																					{Raw: []byte(asstrings.DoubleQuoted(common.PrivilegeReadWrite))},
+																					{Raw: []byte(asstrings.DoubleQuoted(common.PrivilegeReadWriteUDF))},
+																				},
+																			},
+																			"namespace": {
+																				Type:      "string",
+																				MinLength: pointers.NewInt64(1),
+																			},
+																			"set": {
+																				Type:      "string",
+																				MinLength: pointers.NewInt64(1),
+																			},
+																		},
+																		Required: []string{
+																			"code",
+																		},
+																	},
+																},
+															},
+														},
+														Required: []string{
+															"name",
+															"privileges",
+														},
+													},
+												},
+											},
+										},
+										Required: []string{
+											"adminSecret",
+										},
+									},
+									"tls": {
+										Type: "object",
+										Properties: map[string]extsv1beta1.JSONSchemaProps{
+											"service":   tlsListenerProps,
+											"fabric":    tlsListenerProps,
+											"heartbeat": tlsListenerProps,
+										},
+									},
+									"featureKeySecret": {
+										Type:      "string",
+										MinLength: pointers.NewInt64(1),
+									},
+									"config": {
+										Type: "object",
+										Properties: map[string]extsv1beta1.JSONSchemaProps{
+											"service": configOverridesProps,
+											"network": {
+												Type: "object",
+												Properties: map[string]extsv1beta1.JSONSchemaProps{
+													"heartbeat": configOverridesProps,
+													"fabric":    configOverridesProps,
+												},
+											},
+											"logging": configOverridesProps,
+										},
+									},
+									"images": {
+										Type: "object",
+										Properties: map[string]extsv1beta1.JSONSchemaProps{
+											"serverRepository": {
+												Type:      "string",
+												MinLength: pointers.NewInt64(1),
+											},
+											"tools": {
+												Type:      "string",
+												MinLength: pointers.NewInt64(1),
+											},
+											"exporter": {
+												Type:      "string",
+												MinLength: pointers.NewInt64(1),
+											},
+											"pullPolicy": {
+												Type: "string",
+												Enum: []extsv1beta1.JSON{
+													{Raw: []byte(asstrings.DoubleQuoted(string(corev1.PullAlways)))},
+													{Raw: []byte(asstrings.DoubleQuoted(string(corev1.PullIfNotPresent)))},
+													{Raw: []byte(asstrings.DoubleQuoted(string(corev1.PullNever)))},
+												},
+											},
+											"pullSecrets": {
+												Type: "array",
+												Items: &extsv1beta1.JSONSchemaPropsOrArray{
+													Schema: &extsv1beta1.JSONSchemaProps{
+														Type: "object",
+														Properties: map[string]extsv1beta1.JSONSchemaProps{
+															"name": {
+																Type:      "string",
+																MinLength: pointers.NewInt64(1),
+															},
+														},
+														Required: []string{
+															"name",
+														},
+													},
+												},
+											},
+										},
+									},
 								},
 								Required: []string{
 									"nodeCount",
@@ -294,6 +647,23 @@ var (
 										Type:    "string",
 										Pattern: ttlPattern,
 									},
+									"parallelism": {
+										Type:    "integer",
+										Minimum: pointers.NewFloat64(1),
+									},
+									"sets": namesProps,
+									"bins": namesProps,
+									"modifiedAfter": {
+										Type:   "string",
+										Format: "date-time",
+									},
+									"modifiedBefore": {
+										Type:   "string",
+										Format: "date-time",
+									},
+									"incremental": {
+										Type: "boolean",
+									},
 								},
 								Required: []string{
 									"target",
@@ -318,6 +688,30 @@ var (
 						Description: "The name of the Aerospike namespace targeted by the backup operation",
 						JSONPath:    ".status.target.namespace",
 					},
+					{
+						Name:        "Progress",
+						Type:        "integer",
+						Description: "The estimated percentage of completion of the backup operation",
+						JSONPath:    ".status.progress.percentage",
+					},
+					{
+						Name:        "Size",
+						Type:        "integer",
+						Description: "The number of (uncompressed) bytes backed up",
+						JSONPath:    ".status.progress.bytes",
+					},
+					{
+						Name:        "Records",
+						Type:        "integer",
+						Description: "The number of records in the backup",
+						JSONPath:    ".status.progress.records",
+					},
+					{
+						Name:        "Duration",
+						Type:        "string",
+						Description: "The duration of the backup operation",
+						JSONPath:    ".status.progress.duration",
+					},
 					{
 						Name:        "Age",
 						Type:        "date",
@@ -358,6 +752,64 @@ var (
 								Properties: map[string]extsv1beta1.JSONSchemaProps{
 									"target":  backupRestoreTargetProps,
 									"storage": backupStorageSpecProps,
+									// the combination of fields is validated by the admission webhook
+									"source": {
+										Type: "object",
+										Properties: map[string]extsv1beta1.JSONSchemaProps{
+											"name": {
+												Type:      "string",
+												MinLength: pointers.NewInt64(1),
+											},
+											"kubernetesNamespace": {
+												Type:      "string",
+												MinLength: pointers.NewInt64(1),
+											},
+											"path": {
+												Type:      "string",
+												MinLength: pointers.NewInt64(1),
+											},
+											"before": {
+												Type:   "string",
+												Format: "date-time",
+											},
+											"cluster": {
+												Type:      "string",
+												MinLength: pointers.NewInt64(1),
+											},
+											"namespace": {
+												Type:      "string",
+												MinLength: pointers.NewInt64(1),
+											},
+										},
+									},
+									"verifyOnly": {
+										Type: "boolean",
+									},
+									"options": {
+										Type: "object",
+										Properties: map[string]extsv1beta1.JSONSchemaProps{
+											"writePolicy": {
+												Type: "string",
+												Enum: []extsv1beta1.JSON{
+													{Raw: []byte(asstrings.DoubleQuoted(common.WritePolicyUpdate))},
+													{Raw: []byte(asstrings.DoubleQuoted(common.WritePolicyUnique))},
+													{Raw: []byte(asstrings.DoubleQuoted(common.WritePolicyReplace))},
+												},
+											},
+											"noGeneration": {
+												Type: "boolean",
+											},
+											"sets": namesProps,
+											"bins": namesProps,
+											"ignoreRecordErrors": {
+												Type: "boolean",
+											},
+											"ttlExtension": {
+												Type:    "integer",
+												Minimum: pointers.NewFloat64(0),
+											},
+										},
+									},
 								},
 								Required: []string{
 									"target",
@@ -382,6 +834,134 @@ var (
 						Description: "The name of the Aerospike namespace targeted by the restore operation",
 						JSONPath:    ".status.target.namespace",
 					},
+					{
+						Name:        "Progress",
+						Type:        "integer",
+						Description: "The estimated percentage of completion of the restore operation",
+						JSONPath:    ".status.progress.percentage",
+					},
+					{
+						Name:        "Size",
+						Type:        "integer",
+						Description: "The number of (uncompressed) bytes restored",
+						JSONPath:    ".status.progress.bytes",
+					},
+					{
+						Name:        "Records",
+						Type:        "integer",
+						Description: "The number of records in the backup",
+						JSONPath:    ".status.progress.records",
+					},
+					{
+						Name:        "Duration",
+						Type:        "string",
+						Description: "The duration of the restore operation",
+						JSONPath:    ".status.progress.duration",
+					},
+					{
+						Name:        "Age",
+						Type:        "date",
+						Description: "Time elapsed since the resource was created",
+						JSONPath:    ".metadata.creationTimestamp",
+					},
+				},
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: AerospikeNamespaceBackupScheduleCRDName,
+			},
+			Spec: extsv1beta1.CustomResourceDefinitionSpec{
+				Group: aerospikev1alpha2.SchemeGroupVersion.Group,
+				Versions: []extsv1beta1.CustomResourceDefinitionVersion{
+					{
+						Name:    aerospikev1alpha2.SchemeGroupVersion.Version,
+						Served:  true,
+						Storage: true,
+					},
+				},
+				Scope: extsv1beta1.NamespaceScoped,
+				Names: extsv1beta1.CustomResourceDefinitionNames{
+					Plural:     AerospikeNamespaceBackupSchedulePlural,
+					Kind:       AerospikeNamespaceBackupScheduleKind,
+					ShortNames: []string{AerospikeNamespaceBackupScheduleShort},
+				},
+				Validation: &extsv1beta1.CustomResourceValidation{
+					OpenAPIV3Schema: &extsv1beta1.JSONSchemaProps{
+						Properties: map[string]extsv1beta1.JSONSchemaProps{
+							"spec": {
+								Properties: map[string]extsv1beta1.JSONSchemaProps{
+									// the cron expression itself is validated by the admission webhook
+									"schedule": {
+										Type:      "string",
+										MinLength: pointers.NewInt64(1),
+									},
+									"target":  backupRestoreTargetProps,
+									"storage": backupStorageSpecProps,
+									"ttl": {
+										Type:    "string",
+										Pattern: ttlPattern,
+									},
+									"parallelism": {
+										Type:    "integer",
+										Minimum: pointers.NewFloat64(1),
+									},
+									"retentionCount": {
+										Type:    "integer",
+										Minimum: pointers.NewFloat64(0),
+									},
+									"suspend": {
+										Type: "boolean",
+									},
+								},
+								Required: []string{
+									"schedule",
+									"target",
+								},
+							},
+						},
+					},
+				},
+				Subresources: &extsv1beta1.CustomResourceSubresources{
+					Status: &extsv1beta1.CustomResourceSubresourceStatus{},
+				},
+				AdditionalPrinterColumns: []extsv1beta1.CustomResourceColumnDefinition{
+					{
+						Name:        "Schedule",
+						Type:        "string",
+						Description: "The schedule in cron format",
+						JSONPath:    ".spec.schedule",
+					},
+					{
+						Name:        "Target Cluster",
+						Type:        "string",
+						Description: "The name of the Aerospike cluster targeted by the scheduled backups",
+						JSONPath:    ".spec.target.cluster",
+					},
+					{
+						Name:        "Target Namespace",
+						Type:        "string",
+						Description: "The name of the Aerospike namespace targeted by the scheduled backups",
+						JSONPath:    ".spec.target.namespace",
+					},
+					{
+						Name:        "Suspend",
+						Type:        "boolean",
+						Description: "Whether the creation of new backups is suspended",
+						JSONPath:    ".spec.suspend",
+					},
+					{
+						Name:        "Last Schedule",
+						Type:        "date",
+						Description: "Time elapsed since the latest backup was scheduled",
+						JSONPath:    ".status.lastScheduleTime",
+					},
+					{
+						Name:        "Last Success",
+						Type:        "date",
+						Description: "Time elapsed since the latest successful backup finished",
+						JSONPath:    ".status.lastSuccessfulTime",
+					},
 					{
 						Name:        "Age",
 						Type:        "date",
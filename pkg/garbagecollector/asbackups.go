@@ -22,6 +22,7 @@ import (
 
 	log "github.com/sirupsen/logrus"
 	"k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/record"
 
@@ -34,6 +35,13 @@ import (
 	astime "github.com/travelaudience/aerospike-operator/pkg/utils/time"
 )
 
+const (
+	// ExpiredAnnotation is the name of the annotation that marks an
+	// aerospikenamespacebackup as expired regardless of its ttl (e.g. when it
+	// exceeds the retention count of the schedule that created it).
+	ExpiredAnnotation = "aerospike.travelaudience.com/expired"
+)
+
 type AerospikeNamespaceBackupHandler struct {
 	kubeclientset                  kubernetes.Interface
 	aerospikeclientset             aerospikeclientset.Interface
@@ -64,34 +72,26 @@ func (h *AerospikeNamespaceBackupHandler) Handle(asBackup *aerospikev1alpha2.Aer
 		return err
 	}
 
-	// skip aerospikenamespacebackup if no TTL was set
-	if asBackup.Spec.TTL == nil {
-		if aerospikeCluster.Spec.BackupSpec != nil {
-			asBackup.Spec.TTL = aerospikeCluster.Spec.BackupSpec.TTL
-		}
-		if asBackup.Spec.TTL == nil {
-			return nil
-		}
-	}
-
-	// get the aerospikenamespacebackup object expiration as a
-	// duration object
-	objExpiration, err := astime.ParseDuration(*asBackup.Spec.TTL)
+	// check whether the aerospikenamespacebackup has expired
+	expired, err := isExpired(asBackup, aerospikeCluster)
 	if err != nil {
 		return err
 	}
+	if expired {
+		// an expired backup on which incremental backups depend is kept until
+		// these are deleted, so that the chains they belong to are expired
+		// together instead of being left incomplete
+		dependents, err := h.getDependentBackups(asBackup)
+		if err != nil {
+			return err
+		}
+		if len(dependents) > 0 {
+			log.WithFields(log.Fields{
+				logfields.Key: meta.Key(asBackup),
+			}).Debugf("aerospikenamespacebackup has expired but is the base of %v", dependents)
+			return nil
+		}
 
-	// check if the aerospikenamespacebackup object expiration
-	// has no duration, in which case we return immediately
-	if objExpiration == time.Second*0 {
-		log.WithFields(log.Fields{
-			logfields.Key: meta.Key(asBackup),
-		}).Debug("no expiration set for aerospikenamespacebackup")
-		return nil
-	}
-
-	// check if aerospikenamespacebackup object has expired
-	if time.Now().After(asBackup.CreationTimestamp.Add(objExpiration)) {
 		// get backupStorage spec from target aerospikecluster
 		// if not available in aerospikenamespacebackup resource.
 		if asBackup.Spec.Storage == nil {
@@ -101,19 +101,28 @@ func (h *AerospikeNamespaceBackupHandler) Handle(asBackup *aerospikev1alpha2.Aer
 			}
 		}
 
-		// delete backup data from cloud storage
-		switch asBackup.Spec.Storage.Type {
-		case common.StorageTypeGCS:
-			if err := h.deleteBackupDataGCS(asBackup); err != nil {
+		if asBackup.Spec.Storage.Type == common.StorageTypePVC {
+			// backup data stored in a persistent volume claim is deleted by a
+			// job, and the aerospikenamespacebackup resource must be kept
+			// until said job finishes
+			finished, err := h.deleteBackupDataPVC(asBackup)
+			if err != nil {
+				return err
+			}
+			if !finished {
+				return nil
+			}
+		} else {
+			// delete backup data from cloud storage
+			if err := h.deleteBackupData(asBackup); err != nil {
 				log.WithFields(log.Fields{
 					logfields.Key: meta.Key(asBackup),
 				}).Infof("could not delete backup data from cloud storage: %s", err)
+			} else {
+				log.WithFields(log.Fields{
+					logfields.Key: meta.Key(asBackup),
+				}).Info("backup data deleted from cloud storage")
 			}
-			log.WithFields(log.Fields{
-				logfields.Key: meta.Key(asBackup),
-			}).Info("backup data deleted from cloud storage")
-		default:
-			return fmt.Errorf("storage type not supported")
 		}
 
 		// delete AerospikeNamespaceBackup resource
@@ -127,3 +136,56 @@ func (h *AerospikeNamespaceBackupHandler) Handle(asBackup *aerospikev1alpha2.Aer
 
 	return nil
 }
+
+// getDependentBackups returns the names of the aerospikenamespacebackups that
+// have been made on top of asBackup.
+func (h *AerospikeNamespaceBackupHandler) getDependentBackups(asBackup *aerospikev1alpha2.AerospikeNamespaceBackup) ([]string, error) {
+	backups, err := h.aerospikeNamespaceBackupLister.AerospikeNamespaceBackups(asBackup.Namespace).List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	var dependents []string
+	for _, backup := range backups {
+		if backup.Status.BaseBackup == asBackup.Name {
+			dependents = append(dependents, backup.Name)
+		}
+	}
+	return dependents, nil
+}
+
+// isExpired returns whether asBackup has been explicitly marked as expired or
+// its ttl (or the one of its target aerospikecluster) has elapsed.
+func isExpired(asBackup *aerospikev1alpha2.AerospikeNamespaceBackup, aerospikeCluster *aerospikev1alpha2.AerospikeCluster) (bool, error) {
+	if asBackup.Annotations[ExpiredAnnotation] == "true" {
+		return true, nil
+	}
+
+	// skip aerospikenamespacebackup if no TTL was set
+	if asBackup.Spec.TTL == nil {
+		if aerospikeCluster.Spec.BackupSpec != nil {
+			asBackup.Spec.TTL = aerospikeCluster.Spec.BackupSpec.TTL
+		}
+		if asBackup.Spec.TTL == nil {
+			return false, nil
+		}
+	}
+
+	// get the aerospikenamespacebackup object expiration as a
+	// duration object
+	objExpiration, err := astime.ParseDuration(*asBackup.Spec.TTL)
+	if err != nil {
+		return false, err
+	}
+
+	// check if the aerospikenamespacebackup object expiration
+	// has no duration, in which case we return immediately
+	if objExpiration == time.Second*0 {
+		log.WithFields(log.Fields{
+			logfields.Key: meta.Key(asBackup),
+		}).Debug("no expiration set for aerospikenamespacebackup")
+		return false, nil
+	}
+
+	// check if aerospikenamespacebackup object has expired
+	return time.Now().After(asBackup.CreationTimestamp.Add(objExpiration)), nil
+}
@@ -0,0 +1,103 @@
+/*
+Copyright 2018 The aerospike-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package garbagecollector
+
+import (
+	log "github.com/sirupsen/logrus"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	aerospikev1alpha2 "github.com/travelaudience/aerospike-operator/pkg/apis/aerospike/v1alpha2"
+	"github.com/travelaudience/aerospike-operator/pkg/backuprestore"
+	"github.com/travelaudience/aerospike-operator/pkg/backuprestore/storage"
+	"github.com/travelaudience/aerospike-operator/pkg/logfields"
+	"github.com/travelaudience/aerospike-operator/pkg/meta"
+)
+
+func (h *AerospikeNamespaceBackupHandler) deleteBackupData(asBackup *aerospikev1alpha2.AerospikeNamespaceBackup) error {
+	// get the secret containing the credentials to access the bucket
+	namespace := asBackup.Spec.Storage.GetSecretNamespace(asBackup.Namespace)
+	secret, err := h.kubeclientset.CoreV1().Secrets(namespace).Get(asBackup.Spec.Storage.GetSecret(), v1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	// get the storage client
+	client, err := storage.NewClient(asBackup.Spec.Storage, secret.Data[asBackup.Spec.Storage.GetSecretKey()])
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	// delete the data of every shard of the backup, ignoring objects that do
+	// not exist (e.g., because the backup has failed or was interrupted)
+	for _, shardName := range backuprestore.GetShardNames(asBackup.Name, asBackup.Spec.GetParallelism()) {
+		objectNames := []string{
+			backuprestore.GetMetadataObjectName(shardName),
+			backuprestore.GetBackupObjectName(shardName, asBackup.Spec.Storage.GetCompressionCodec()),
+		}
+		for _, objectName := range objectNames {
+			if err := client.DeleteObject(objectName); err != nil && err != storage.ErrNotFound {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// deleteBackupDataPVC makes sure that a job deleting the data of asBackup from
+// the persistent volume claim where it is stored exists, and returns whether
+// said job has finished.
+func (h *AerospikeNamespaceBackupHandler) deleteBackupDataPVC(asBackup *aerospikev1alpha2.AerospikeNamespaceBackup) (bool, error) {
+	job, err := h.kubeclientset.BatchV1().Jobs(asBackup.Namespace).Get(backuprestore.GetDeleteJobName(asBackup), v1.GetOptions{})
+	if err != nil {
+		if !errors.IsNotFound(err) {
+			return false, err
+		}
+		// the job doesn't exist yet, so create it
+		job, err = backuprestore.NewDeleteJob(asBackup)
+		if err != nil {
+			return false, err
+		}
+		if _, err := h.kubeclientset.BatchV1().Jobs(asBackup.Namespace).Create(job); err != nil {
+			return false, err
+		}
+		log.WithFields(log.Fields{
+			logfields.Key: meta.Key(asBackup),
+			logfields.Job: meta.Key(job),
+		}).Debug("delete job created")
+		return false, nil
+	}
+
+	// check whether the job has finished
+	for _, c := range job.Status.Conditions {
+		if c.Type == batchv1.JobComplete && c.Status == corev1.ConditionTrue {
+			log.WithFields(log.Fields{
+				logfields.Key: meta.Key(asBackup),
+			}).Info("backup data deleted from persistent volume claim")
+			return true, nil
+		}
+		if c.Type == batchv1.JobFailed && c.Status == corev1.ConditionTrue {
+			log.WithFields(log.Fields{
+				logfields.Key: meta.Key(asBackup),
+			}).Infof("could not delete backup data from persistent volume claim: %s", c.Message)
+			return true, nil
+		}
+	}
+	return false, nil
+}
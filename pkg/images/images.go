@@ -0,0 +1,138 @@
+/*
+Copyright 2019 The aerospike-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package images determines the container images used to run Aerospike and
+// the tools of aerospike-operator, as well as how to pull them. The defaults
+// set in this package (usually from the command-line flags of the operator)
+// can be overridden for each Aerospike cluster.
+package images
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+
+	aerospikev1alpha2 "github.com/travelaudience/aerospike-operator/pkg/apis/aerospike/v1alpha2"
+	"github.com/travelaudience/aerospike-operator/pkg/versioning"
+)
+
+const (
+	// toolsRepository is the repository of the default tools image, which is
+	// tagged with the version of aerospike-operator.
+	toolsRepository = "quay.io/travelaudience/aerospike-operator-tools"
+)
+
+var (
+	// DefaultServerRepository is the repository of the images used to run the
+	// versions of Aerospike for which the version catalogue does not specify
+	// an image.
+	DefaultServerRepository = "aerospike/aerospike-server"
+	// DefaultTools is the image used to run the init container of each pod
+	// as well as backup and restore jobs. Defaults to the tools image matching
+	// the version of aerospike-operator.
+	DefaultTools = ""
+	// DefaultExporter is the image used to run the Prometheus exporter in each
+	// pod. Defaults to the tools image.
+	DefaultExporter = ""
+	// DefaultPullPolicy is the pull policy for every image. The pull policy of
+	// each container is left unchanged when empty.
+	DefaultPullPolicy corev1.PullPolicy = ""
+	// DefaultPullSecrets holds the names of the secrets used to pull the
+	// images, which must exist in the Kubernetes namespace of every Aerospike
+	// cluster.
+	DefaultPullSecrets []string
+)
+
+// Server returns the image used to run the specified version of Aerospike in
+// an Aerospike cluster whose images are described by spec.
+func Server(version versioning.Version, spec *aerospikev1alpha2.AerospikeClusterImagesSpec) string {
+	if spec != nil && spec.ServerRepository != nil {
+		return fmt.Sprintf("%s:%s", *spec.ServerRepository, version)
+	}
+	if image := version.CatalogueImage(); image != "" {
+		return image
+	}
+	return fmt.Sprintf("%s:%s", DefaultServerRepository, version)
+}
+
+// IsEnterprise returns whether image runs Aerospike Enterprise, which is the
+// case when the name of its repository mentions it (e.g.
+// aerospike/aerospike-server-enterprise).
+func IsEnterprise(image string) bool {
+	repository := strings.SplitN(image, "@", 2)[0]
+	if i := strings.LastIndex(repository, ":"); i > strings.LastIndex(repository, "/") {
+		repository = repository[:i]
+	}
+	return strings.Contains(path.Base(repository), "enterprise")
+}
+
+// Tools returns the image used to run the tools of aerospike-operator for an
+// Aerospike cluster whose images are described by spec.
+func Tools(spec *aerospikev1alpha2.AerospikeClusterImagesSpec) string {
+	if spec != nil && spec.Tools != nil {
+		return *spec.Tools
+	}
+	if DefaultTools != "" {
+		return DefaultTools
+	}
+	return fmt.Sprintf("%s:%s", toolsRepository, versioning.OperatorVersion)
+}
+
+// Exporter returns the image used to run the Prometheus exporter in an
+// Aerospike cluster whose images are described by spec.
+func Exporter(spec *aerospikev1alpha2.AerospikeClusterImagesSpec) string {
+	if spec != nil && spec.Exporter != nil {
+		return *spec.Exporter
+	}
+	if spec != nil && spec.Tools != nil {
+		return *spec.Tools
+	}
+	if DefaultExporter != "" {
+		return DefaultExporter
+	}
+	return Tools(nil)
+}
+
+// PullPolicy returns the pull policy for the images of an Aerospike cluster
+// whose images are described by spec, or def if no pull policy has been
+// configured.
+func PullPolicy(spec *aerospikev1alpha2.AerospikeClusterImagesSpec, def corev1.PullPolicy) corev1.PullPolicy {
+	if spec != nil && spec.PullPolicy != nil {
+		return *spec.PullPolicy
+	}
+	if DefaultPullPolicy != "" {
+		return DefaultPullPolicy
+	}
+	return def
+}
+
+// PullSecrets returns the secrets used to pull the images of an Aerospike
+// cluster whose images are described by spec.
+func PullSecrets(spec *aerospikev1alpha2.AerospikeClusterImagesSpec) []corev1.LocalObjectReference {
+	if spec != nil && spec.PullSecrets != nil {
+		return spec.PullSecrets
+	}
+	if len(DefaultPullSecrets) == 0 {
+		return nil
+	}
+	res := make([]corev1.LocalObjectReference, 0, len(DefaultPullSecrets))
+	for _, name := range DefaultPullSecrets {
+		res = append(res, corev1.LocalObjectReference{Name: name})
+	}
+	return res
+}
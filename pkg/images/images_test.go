@@ -0,0 +1,92 @@
+/*
+Copyright 2019 The aerospike-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package images
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+
+	aerospikev1alpha2 "github.com/travelaudience/aerospike-operator/pkg/apis/aerospike/v1alpha2"
+	"github.com/travelaudience/aerospike-operator/pkg/pointers"
+	"github.com/travelaudience/aerospike-operator/pkg/versioning"
+)
+
+func TestImages(t *testing.T) {
+	version := versioning.Version{Major: 4, Minor: 3, Patch: 0, Revision: 10}
+	spec := &aerospikev1alpha2.AerospikeClusterImagesSpec{
+		ServerRepository: pointers.NewString("registry.example.com/aerospike/aerospike-server-enterprise"),
+		Tools:            pointers.NewString("registry.example.com/aerospike-operator-tools:custom"),
+		PullPolicy:       pullPolicy(corev1.PullIfNotPresent),
+		PullSecrets:      []corev1.LocalObjectReference{{Name: "registry"}},
+	}
+
+	// defaults
+	assert.Equal(t, "aerospike/aerospike-server:4.3.0.10", Server(version, nil))
+	assert.Equal(t, "quay.io/travelaudience/aerospike-operator-tools:"+versioning.OperatorVersion, Tools(nil))
+	assert.Equal(t, Tools(nil), Exporter(nil))
+	assert.Equal(t, corev1.PullAlways, PullPolicy(nil, corev1.PullAlways))
+	assert.Equal(t, 0, len(PullSecrets(nil)))
+
+	// cluster overrides
+	assert.Equal(t, "registry.example.com/aerospike/aerospike-server-enterprise:4.3.0.10", Server(version, spec))
+	assert.Equal(t, "registry.example.com/aerospike-operator-tools:custom", Tools(spec))
+	assert.Equal(t, "registry.example.com/aerospike-operator-tools:custom", Exporter(spec))
+	assert.Equal(t, corev1.PullIfNotPresent, PullPolicy(spec, corev1.PullAlways))
+	assert.Equal(t, spec.PullSecrets, PullSecrets(spec))
+
+	// operator overrides
+	DefaultServerRepository = "registry.example.com/aerospike/aerospike-server"
+	DefaultExporter = "registry.example.com/asprom:latest"
+	DefaultPullPolicy = corev1.PullNever
+	DefaultPullSecrets = []string{"a", "b"}
+	defer func() {
+		DefaultServerRepository = "aerospike/aerospike-server"
+		DefaultExporter = ""
+		DefaultPullPolicy = ""
+		DefaultPullSecrets = nil
+	}()
+	assert.Equal(t, "registry.example.com/aerospike/aerospike-server:4.3.0.10", Server(version, nil))
+	assert.Equal(t, "registry.example.com/asprom:latest", Exporter(nil))
+	assert.Equal(t, corev1.PullNever, PullPolicy(nil, corev1.PullAlways))
+	assert.Equal(t, []corev1.LocalObjectReference{{Name: "a"}, {Name: "b"}}, PullSecrets(nil))
+	// cluster overrides take precedence
+	assert.Equal(t, corev1.PullIfNotPresent, PullPolicy(spec, corev1.PullAlways))
+	assert.Equal(t, spec.PullSecrets, PullSecrets(spec))
+}
+
+func TestIsEnterprise(t *testing.T) {
+	tests := []struct {
+		image    string
+		expected bool
+	}{
+		{"aerospike/aerospike-server:4.3.0.10", false},
+		{"aerospike/aerospike-server-enterprise:4.6.0.2", true},
+		{"registry.example.com:5000/aerospike/aerospike-server-enterprise", true},
+		{"registry.example.com:5000/aerospike/aerospike-server", false},
+		{"registry.example.com/enterprise/aerospike-server:4.3.0.10", false},
+		{"aerospike/aerospike-server-enterprise@sha256:0123456789abcdef", true},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.expected, IsEnterprise(test.image), test.image)
+	}
+}
+
+func pullPolicy(p corev1.PullPolicy) *corev1.PullPolicy {
+	return &p
+}
@@ -19,29 +19,109 @@ package reconciler
 import (
 	"fmt"
 	"strings"
+	"time"
 
 	"k8s.io/apiextensions-apiserver/pkg/apis/apiextensions"
-	"k8s.io/apimachinery/pkg/apis/meta/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 
 	"github.com/travelaudience/aerospike-operator/pkg/apis/aerospike/common"
 	aerospikev1alpha2 "github.com/travelaudience/aerospike-operator/pkg/apis/aerospike/v1alpha2"
+	"github.com/travelaudience/aerospike-operator/pkg/backuprestore"
 	"github.com/travelaudience/aerospike-operator/pkg/crd"
 	"github.com/travelaudience/aerospike-operator/pkg/errors"
 	"github.com/travelaudience/aerospike-operator/pkg/pointers"
 	"github.com/travelaudience/aerospike-operator/pkg/utils/selectors"
+	astime "github.com/travelaudience/aerospike-operator/pkg/utils/time"
 )
 
 func (r *AerospikeClusterReconciler) backupCluster(aerospikeCluster *aerospikev1alpha2.AerospikeCluster) error {
 	// create a backup of each namespace specified in .spec.namespaces
+	// backups that already exist (i.e. that have finished before retrying a
+	// failed upgrade) are kept
 	for _, namespace := range aerospikeCluster.Spec.Namespaces {
-		if err := r.createNamespaceBackup(aerospikeCluster, namespace.Name); err != nil {
+		if err := r.createNamespaceBackup(aerospikeCluster, namespace.Name); err != nil && !kerrors.IsAlreadyExists(err) {
 			return err
 		}
 	}
 	return nil
 }
 
+// deleteFailedBackups deletes the pre-upgrade backups of aerospikeCluster
+// that have failed, so that they can be made again when retrying the upgrade.
+// As deletion is asynchronous, it returns whether every failed backup is gone,
+// as otherwise the backups would not be re-created under the same names.
+func (r *AerospikeClusterReconciler) deleteFailedBackups(aerospikeCluster *aerospikev1alpha2.AerospikeCluster) (bool, error) {
+	deleted := true
+	for _, namespace := range aerospikeCluster.Spec.Namespaces {
+		name := GetBackupName(namespace.Name, aerospikeCluster.Status.Version, aerospikeCluster.Spec.Version)
+		// bypass the lister, as it may not have observed the deletion yet
+		backup, err := r.aerospikeclientset.AerospikeV1alpha2().AerospikeNamespaceBackups(aerospikeCluster.Namespace).Get(name, metav1.GetOptions{})
+		if err != nil {
+			if kerrors.IsNotFound(err) {
+				continue
+			}
+			return false, err
+		}
+		if backup.DeletionTimestamp != nil {
+			deleted = false
+			continue
+		}
+		if !isBackupFailed(backup) {
+			continue
+		}
+		if err := r.aerospikeclientset.AerospikeV1alpha2().AerospikeNamespaceBackups(aerospikeCluster.Namespace).Delete(name, &metav1.DeleteOptions{}); err != nil && !kerrors.IsNotFound(err) {
+			return false, err
+		}
+		deleted = false
+	}
+	return deleted, nil
+}
+
+// findReusableBackups returns the names of the backups of every namespace of
+// aerospikeCluster that can be reused instead of backing up the cluster before
+// upgrading it. It returns nil if the upgrade policy does not allow for
+// reusing backups, or if no such backup exists for some namespace.
+func (r *AerospikeClusterReconciler) findReusableBackups(aerospikeCluster *aerospikev1alpha2.AerospikeCluster) ([]string, error) {
+	if aerospikeCluster.Spec.GetBackupPolicy() != common.UpgradeBackupPolicyReuse || aerospikeCluster.Spec.UpgradePolicy.MaxBackupAge == nil {
+		return nil, nil
+	}
+	maxAge, err := astime.ParseDuration(*aerospikeCluster.Spec.UpgradePolicy.MaxBackupAge)
+	if err != nil {
+		return nil, err
+	}
+	list, err := r.aerospikeBackupsLister.AerospikeNamespaceBackups(aerospikeCluster.Namespace).List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	// only backups including every record of the namespace can be reused
+	backups := make([]aerospikev1alpha2.AerospikeNamespaceBackup, 0, len(list))
+	for _, backup := range list {
+		if isFullBackup(backup) {
+			backups = append(backups, *backup)
+		}
+	}
+	now := time.Now()
+	names := make([]string, 0, len(aerospikeCluster.Spec.Namespaces))
+	for _, namespace := range aerospikeCluster.Spec.Namespaces {
+		target := aerospikev1alpha2.TargetNamespace{Cluster: aerospikeCluster.Name, Namespace: namespace.Name}
+		backup := backuprestore.FindLatestBackup(backups, target, now)
+		if backup == nil || backup.CreationTimestamp.Time.Before(now.Add(-maxAge)) {
+			return nil, nil
+		}
+		names = append(names, backup.Name)
+	}
+	return names, nil
+}
+
+// isFullBackup returns whether backup includes every record of the namespace
+// it targets (i.e. it is neither filtered nor incremental).
+func isFullBackup(backup *aerospikev1alpha2.AerospikeNamespaceBackup) bool {
+	status := backup.Status
+	return len(status.Sets) == 0 && len(status.Bins) == 0 && status.ModifiedAfter == nil && status.ModifiedBefore == nil && status.BaseBackup == ""
+}
+
 func (r *AerospikeClusterReconciler) isClusterBackupFinished(aerospikeCluster *aerospikev1alpha2.AerospikeCluster) (bool, error) {
 	// if the backup of one of the namespaces have not finished, return false
 	for _, namespace := range aerospikeCluster.Spec.Namespaces {
@@ -56,7 +136,7 @@ func (r *AerospikeClusterReconciler) isClusterBackupFinished(aerospikeCluster *a
 
 func (r *AerospikeClusterReconciler) createNamespaceBackup(aerospikeCluster *aerospikev1alpha2.AerospikeCluster, ns string) error {
 	backup := aerospikev1alpha2.AerospikeNamespaceBackup{
-		ObjectMeta: v1.ObjectMeta{
+		ObjectMeta: metav1.ObjectMeta{
 			Name: GetBackupName(ns, aerospikeCluster.Status.Version, aerospikeCluster.Spec.Version),
 			Labels: map[string]string{
 				selectors.LabelAppKey:       selectors.LabelAppVal,
@@ -80,14 +160,8 @@ func (r *AerospikeClusterReconciler) createNamespaceBackup(aerospikeCluster *aer
 				Cluster:   aerospikeCluster.Name,
 				Namespace: ns,
 			},
-			Storage: &aerospikev1alpha2.BackupStorageSpec{
-				Type:            aerospikeCluster.Spec.BackupSpec.Storage.Type,
-				Bucket:          aerospikeCluster.Spec.BackupSpec.Storage.Bucket,
-				Secret:          aerospikeCluster.Spec.BackupSpec.Storage.GetSecret(),
-				SecretNamespace: aerospikeCluster.Spec.BackupSpec.Storage.SecretNamespace,
-				SecretKey:       aerospikeCluster.Spec.BackupSpec.Storage.SecretKey,
-			},
-			TTL: aerospikeCluster.Spec.BackupSpec.TTL,
+			Storage: aerospikeCluster.Spec.BackupSpec.Storage.DeepCopy(),
+			TTL:     aerospikeCluster.Spec.BackupSpec.TTL,
 		},
 	}
 
@@ -99,8 +173,9 @@ func (r *AerospikeClusterReconciler) createNamespaceBackup(aerospikeCluster *aer
 }
 
 func (r *AerospikeClusterReconciler) isBackupCompleted(aerospikeCluster *aerospikev1alpha2.AerospikeCluster, ns string) (bool, error) {
-	// get the AerospikeNamespaceBackup resource
-	backup, err := r.aerospikeBackupsLister.AerospikeNamespaceBackups(aerospikeCluster.Namespace).Get(GetBackupName(ns, aerospikeCluster.Status.Version, aerospikeCluster.Spec.Version))
+	// get the AerospikeNamespaceBackup resource, bypassing the lister as the
+	// backup may have just been re-created when retrying a failed upgrade
+	backup, err := r.aerospikeclientset.AerospikeV1alpha2().AerospikeNamespaceBackups(aerospikeCluster.Namespace).Get(GetBackupName(ns, aerospikeCluster.Status.Version, aerospikeCluster.Spec.Version), metav1.GetOptions{})
 	if err != nil {
 		return false, err
 	}
@@ -110,14 +185,25 @@ func (r *AerospikeClusterReconciler) isBackupCompleted(aerospikeCluster *aerospi
 		if condition.Type == common.ConditionBackupFinished &&
 			condition.Status == apiextensions.ConditionTrue {
 			return true, nil
-		} else if condition.Type == common.ConditionBackupFailed &&
-			condition.Status == apiextensions.ConditionTrue {
-			return false, errors.ClusterBackupFailed
 		}
 	}
+	if isBackupFailed(backup) {
+		return false, errors.ClusterBackupFailed
+	}
 	return false, nil
 }
 
+// isBackupFailed returns whether backup has failed.
+func isBackupFailed(backup *aerospikev1alpha2.AerospikeNamespaceBackup) bool {
+	for _, condition := range backup.Status.Conditions {
+		if condition.Type == common.ConditionBackupFailed &&
+			condition.Status == apiextensions.ConditionTrue {
+			return true
+		}
+	}
+	return false
+}
+
 // GetBackupName returns the name of a backup created automatically before upgrading
 func GetBackupName(ns, sourceVersion, targetVersion string) string {
 	return fmt.Sprintf("%s-%s-%s-upgrade", ns,
@@ -23,6 +23,7 @@ import (
 	storagelistersv1 "k8s.io/client-go/listers/storage/v1"
 	"k8s.io/client-go/tools/record"
 
+	"github.com/travelaudience/aerospike-operator/pkg/apis/aerospike/common"
 	aerospikev1alpha2 "github.com/travelaudience/aerospike-operator/pkg/apis/aerospike/v1alpha2"
 	aerospikeclientset "github.com/travelaudience/aerospike-operator/pkg/client/clientset/versioned"
 	aerospikelisters "github.com/travelaudience/aerospike-operator/pkg/client/listers/aerospike/v1alpha2"
@@ -73,12 +74,19 @@ func (r *AerospikeClusterReconciler) MaybeReconcile(aerospikeCluster *aerospikev
 	}).Info("processing cluster")
 
 	// check if a previous upgrade operation has failed, in which case we return
+	// unless the upgrade is to be retried
 	if v, ok := aerospikeCluster.ObjectMeta.Annotations[UpgradeStatusAnnotationKey]; ok {
 		if v == UpgradeStatusFailedAnnotationValue {
-			log.WithFields(log.Fields{
-				logfields.AerospikeCluster: meta.Key(aerospikeCluster),
-			}).Warn("a previous version upgrade has failed. aborting")
-			return nil
+			if aerospikeCluster.ObjectMeta.Annotations[UpgradeRetryAnnotationKey] != UpgradeRetryAnnotationValue {
+				log.WithFields(log.Fields{
+					logfields.AerospikeCluster: meta.Key(aerospikeCluster),
+				}).Warn("a previous version upgrade has failed. aborting")
+				return nil
+			}
+			var err error
+			if aerospikeCluster, err = r.retryUpgrade(aerospikeCluster); err != nil {
+				return err
+			}
 		}
 	}
 
@@ -103,13 +111,22 @@ func (r *AerospikeClusterReconciler) MaybeReconcile(aerospikeCluster *aerospikev
 	// if the current reconcile operation is an upgrade set the
 	// appropriate annotations (for internal use) and conditions
 	if upgrade != nil {
-		// start the backup if no annotation is present
+		// start the upgrade if no annotation is present, backing up the
+		// cluster first unless the upgrade policy says otherwise
 		if status, ok := aerospikeCluster.Annotations[UpgradeStatusAnnotationKey]; !ok {
-			var err error
-			if aerospikeCluster, err = r.signalBackupStarted(aerospikeCluster); err != nil {
+			reusedBackups, err := r.findReusableBackups(aerospikeCluster)
+			if err != nil {
+				return err
+			}
+			if aerospikeCluster.Spec.GetBackupPolicy() != common.UpgradeBackupPolicySkip && reusedBackups == nil {
+				if aerospikeCluster, err = r.signalBackupStarted(aerospikeCluster); err != nil {
+					return err
+				}
+				return r.backupCluster(aerospikeCluster)
+			}
+			if aerospikeCluster, err = r.signalUpgradeStarted(aerospikeCluster, upgrade, reusedBackups); err != nil {
 				return err
 			}
-			return r.backupCluster(aerospikeCluster)
 		} else if status == UpgradeStatusBackupAnnotationValue {
 			// check if autobackups have finished
 			if backupsCompleted, err := r.isClusterBackupFinished(aerospikeCluster); err != nil {
@@ -131,7 +148,7 @@ func (r *AerospikeClusterReconciler) MaybeReconcile(aerospikeCluster *aerospikev
 				if aerospikeCluster, err = r.signalBackupFinished(aerospikeCluster); err != nil {
 					return err
 				}
-				if aerospikeCluster, err = r.signalUpgradeStarted(aerospikeCluster, upgrade); err != nil {
+				if aerospikeCluster, err = r.signalUpgradeStarted(aerospikeCluster, upgrade, nil); err != nil {
 					return err
 				}
 
@@ -181,9 +198,15 @@ func (r *AerospikeClusterReconciler) MaybeReconcile(aerospikeCluster *aerospikev
 		// return the original error
 		return err
 	}
+	// make sure that users and roles are up-to-date with the spec
+	if err := r.ensureUsersAndRoles(aerospikeCluster); err != nil {
+		return err
+	}
 
 	// update the status field of aerospikeCluster
-	r.updateStatus(aerospikeCluster)
+	if err := r.updateStatus(aerospikeCluster, configMap); err != nil {
+		return err
+	}
 
 	// patch the cluster with the changes performed in the ensurePods and
 	// updateStatus
@@ -18,8 +18,6 @@ package reconciler
 
 import (
 	"bytes"
-	"strconv"
-	"strings"
 
 	log "github.com/sirupsen/logrus"
 	"k8s.io/api/core/v1"
@@ -28,12 +26,14 @@ import (
 
 	"github.com/travelaudience/aerospike-operator/pkg/apis/aerospike/common"
 	aerospikev1alpha2 "github.com/travelaudience/aerospike-operator/pkg/apis/aerospike/v1alpha2"
+	"github.com/travelaudience/aerospike-operator/pkg/asconfig"
 	"github.com/travelaudience/aerospike-operator/pkg/crd"
 	"github.com/travelaudience/aerospike-operator/pkg/logfields"
 	"github.com/travelaudience/aerospike-operator/pkg/meta"
 	"github.com/travelaudience/aerospike-operator/pkg/pointers"
 	"github.com/travelaudience/aerospike-operator/pkg/utils/selectors"
 	asstrings "github.com/travelaudience/aerospike-operator/pkg/utils/strings"
+	"github.com/travelaudience/aerospike-operator/pkg/versioning"
 )
 
 func (r *AerospikeClusterReconciler) ensureConfigMap(aerospikeCluster *aerospikev1alpha2.AerospikeCluster) (*v1.ConfigMap, error) {
@@ -92,23 +92,30 @@ func (r *AerospikeClusterReconciler) updateConfigMap(aerospikeCluster *aerospike
 }
 
 func buildConfig(aerospikeCluster *aerospikev1alpha2.AerospikeCluster) string {
+	return renderConfig(aerospikeCluster, getConfigParameters(aerospikeCluster))
+}
+
+// renderConfig renders the aerospike config file of aerospikeCluster, setting
+// the parameters that can be overridden to the values in params.
+func renderConfig(aerospikeCluster *aerospikev1alpha2.AerospikeCluster, params []configParameter) string {
 	var namespacesConfig []string
 
 	for index, namespace := range aerospikeCluster.Spec.Namespaces {
 		buf := new(bytes.Buffer)
-		asNamespaceTemplate.Execute(buf, getNamespaceProps(aerospikeCluster, index, &namespace))
+		asNamespaceTemplate.Execute(buf, getNamespaceProps(aerospikeCluster, index, &namespace, params))
 		namespacesConfig = append(namespacesConfig, buf.String())
 	}
 
 	configMapBuffer := new(bytes.Buffer)
-	asConfigTemplate.Execute(configMapBuffer, getClusterProps(aerospikeCluster, namespacesConfig))
+	asConfigTemplate.Execute(configMapBuffer, getClusterProps(aerospikeCluster, namespacesConfig, params))
 
 	return configMapBuffer.String()
 }
 
 func buildConfigMap(aerospikeCluster *aerospikev1alpha2.AerospikeCluster) *v1.ConfigMap {
 	// build the aerospike config file based on the current spec
-	aerospikeConfig := buildConfig(aerospikeCluster)
+	params := getConfigParameters(aerospikeCluster)
+	aerospikeConfig := renderConfig(aerospikeCluster, params)
 	// return a configmap object containing aerospikeConfig
 	return &v1.ConfigMap{
 		ObjectMeta: metav1.ObjectMeta{
@@ -129,26 +136,61 @@ func buildConfigMap(aerospikeCluster *aerospikev1alpha2.AerospikeCluster) *v1.Co
 				},
 			},
 			Annotations: map[string]string{
-				configMapHashAnnotation: asstrings.Hash(aerospikeConfig),
+				configMapHashAnnotation:    asstrings.Hash(aerospikeConfig),
+				staticConfigHashAnnotation: asstrings.Hash(renderConfig(aerospikeCluster, staticConfigParameters(params))),
+				dynamicConfigAnnotation:    encodeDynamicConfig(params),
 			},
 		},
 		Data: map[string]string{configFileName: aerospikeConfig},
 	}
 }
 
-func getClusterProps(aerospikeCluster *aerospikev1alpha2.AerospikeCluster, namespacesConfig []string) map[string]interface{} {
+func getClusterProps(aerospikeCluster *aerospikev1alpha2.AerospikeCluster, namespacesConfig []string, params []configParameter) map[string]interface{} {
+	tls := getTLSSpec(aerospikeCluster)
+	// nodes connect to the heartbeat tls port of their mesh seeds when tls is
+	// enabled for heartbeats
+	heartbeatAddresses := HeartbeatAddressesValue
+	if tls.Heartbeat != nil {
+		heartbeatAddresses = TLSHeartbeatAddressesValue
+	}
 	return map[string]interface{}{
 		serviceNodeIdKey:            ServiceNodeIdValue,
 		clusterNamespacesKey:        namespacesConfig,
-		heartbeatAddressesConfigKey: HeartbeatAddressesValue,
+		heartbeatAddressesConfigKey: heartbeatAddresses,
+		serviceConfigKey:            configLines(params, asconfig.ContextService, ""),
+		loggingConfigKey:            configLines(params, asconfig.ContextLogging, ""),
+		heartbeatConfigKey:          configLines(params, asconfig.ContextHeartbeat, ""),
+		fabricConfigKey:             configLines(params, asconfig.ContextFabric, ""),
+		securityKey:                 aerospikeCluster.Spec.Security != nil,
+		featureKeyFileKey:           getFeatureKeyFile(aerospikeCluster),
+		securityConfigKey:           asconfig.Lines(asconfig.Merge(getConfigVersion(aerospikeCluster), asconfig.ContextSecurity, defaultSecurityConfig, nil)),
+		tlsKey:                      getTLSContexts(aerospikeCluster),
+		tlsServiceKey:               getTLSName(tls.Service),
+		tlsFabricKey:                getTLSName(tls.Fabric),
+		tlsHeartbeatKey:             getTLSName(tls.Heartbeat),
 	}
 }
 
-func getNamespaceProps(aerospikeCluster *aerospikev1alpha2.AerospikeCluster, index int, namespace *aerospikev1alpha2.AerospikeNamespaceSpec) map[string]interface{} {
+// getConfigVersion returns the version of aerospike for which the
+// configuration of aerospikeCluster is built.
+func getConfigVersion(aerospikeCluster *aerospikev1alpha2.AerospikeCluster) versioning.Version {
+	// the version has been validated by the admission webhook, so the zero
+	// value (for which every default is used) is only returned in case the
+	// webhook is disabled
+	version, _ := versioning.NewVersionFromString(aerospikeCluster.Spec.Version)
+	return version
+}
+
+func getNamespaceProps(aerospikeCluster *aerospikev1alpha2.AerospikeCluster, index int, namespace *aerospikev1alpha2.AerospikeNamespaceSpec, params []configParameter) map[string]interface{} {
 	props := make(map[string]interface{})
 
 	props[nsNameKey] = namespace.Name
 
+	// the rack-id of each pod is set by asinit
+	if len(aerospikeCluster.Spec.Racks) > 0 {
+		props[nsRackIdKey] = RackIdValue
+	}
+
 	if namespace.ReplicationFactor != nil {
 		if *namespace.ReplicationFactor <= aerospikeCluster.Spec.NodeCount && *namespace.ReplicationFactor > 0 {
 			props[nsReplicationFactorKey] = namespace.ReplicationFactor
@@ -164,12 +206,6 @@ func getNamespaceProps(aerospikeCluster *aerospikev1alpha2.AerospikeCluster, ind
 		props[nsMemorySizeKey] = defaultMemorySize
 	}
 
-	if namespace.DefaultTTL != nil {
-		if value, err := strconv.Atoi(strings.TrimSuffix(*namespace.DefaultTTL, "s")); err == nil {
-			props[nsDefaultTTLKey] = value
-		}
-	}
-
 	props[nsStorageTypeKey] = namespace.Storage.Type
 
 	if namespace.Storage.Type == common.StorageTypeFile {
@@ -183,5 +219,8 @@ func getNamespaceProps(aerospikeCluster *aerospikev1alpha2.AerospikeCluster, ind
 		props[nsDataInMemory] = *namespace.Storage.DataInMemory
 	}
 
+	props[nsConfigKey] = configLines(params, asconfig.ContextNamespace, namespace.Name)
+	props[nsStorageConfigKey] = configLines(params, asconfig.ContextStorageEngine, namespace.Name)
+
 	return props
 }
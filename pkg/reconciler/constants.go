@@ -36,6 +36,19 @@ const (
 	finalConfigMountPath = "/aerospike-conf"
 	// the name of the aerospike.conf file
 	configFileName = "aerospike.conf"
+	// the prefix of the names of the volumes that contain the tls secrets
+	tlsVolumePrefix = "tls"
+	// the path under which the tls secrets are mounted, each in a directory
+	// named after the secret
+	tlsMountPathPrefix = "/aerospike-tls"
+	// the name of the volume that contains the feature key secret
+	featureKeyVolumeName = "feature-key"
+	// the path under which the feature key secret is mounted, in a directory
+	// named after the secret
+	featureKeyMountPathPrefix = "/aerospike-feature-key"
+	// the suffix of the name of the secret holding the credentials of the
+	// admin user as currently set in the cluster
+	appliedAdminSecretSuffix = "applied-admin"
 
 	namespaceVolumePrefix = "data-ns"
 
@@ -48,6 +61,13 @@ const (
 	infoPort          = 3003
 	infoPortName      = "info"
 
+	serviceTLSPort       = 4333
+	serviceTLSPortName   = "service-tls"
+	HeartbeatTLSPort     = 3012
+	heartbeatTLSPortName = "heartbeat-tls"
+	fabricTLSPort        = 3011
+	fabricTLSPortName    = "fabric-tls"
+
 	watchCreatePodTimeout  = 3 * time.Hour
 	watchDeletePodTimeout  = 3 * time.Minute
 	terminationGracePeriod = 2 * time.Minute
@@ -61,6 +81,22 @@ const (
 
 	// the name of the annotation that holds the hash of the mounted configmap
 	configMapHashAnnotation = "aerospike.travelaudience.com/config-map-hash"
+	// the name of the annotation that holds the hash of the configuration
+	// file without the parameters that can be changed on running nodes
+	staticConfigHashAnnotation = "aerospike.travelaudience.com/static-config-hash"
+	// the name of the annotation that holds the values of the parameters of
+	// the configuration that can be changed on running nodes (as a json object
+	// indexed by the key of each parameter)
+	dynamicConfigAnnotation = "aerospike.travelaudience.com/dynamic-config"
+	// the name of the annotation that holds the hash of the images requested
+	// in the spec of the cluster
+	imagesHashAnnotation = "aerospike.travelaudience.com/images-hash"
+	// the name of the annotation that holds the hash of the contents of the
+	// tls secrets used by the cluster
+	tlsHashAnnotation = "aerospike.travelaudience.com/tls-hash"
+	// the name of the annotation that holds the hash of the credentials of
+	// the admin user of the cluster
+	adminHashAnnotation = "aerospike.travelaudience.com/admin-hash"
 	// the name of the annotation that holds the aerospike node id
 	nodeIdAnnotation = "aerospike.travelaudience.com/node-id"
 	// the name of the annotation that holds the name of the pod with which a
@@ -81,7 +117,26 @@ const (
 	ServiceNodeIdValue          = "__SERVICE__NODE_ID__"
 	clusterNamespacesKey        = "namespaces"
 	heartbeatAddressesConfigKey = "heartbeatAddresses"
+	serviceConfigKey            = "serviceConfig"
+	loggingConfigKey            = "loggingConfig"
+	heartbeatConfigKey          = "heartbeatConfig"
+	fabricConfigKey             = "fabricConfig"
+	securityKey                 = "security"
+	securityConfigKey           = "securityConfig"
+	featureKeyFileKey           = "featureKeyFile"
+	tlsKey                      = "tls"
+	tlsServiceKey               = "tlsService"
+	tlsFabricKey                = "tlsFabric"
+	tlsHeartbeatKey             = "tlsHeartbeat"
 	HeartbeatAddressesValue     = "__NETWORK__HEARTBEAT__MESH_SEED_ADDRESS_PORT__"
+	// the value of the key that corresponds to the
+	// network.heartbeat.tls-mesh-seed-address-port property, used instead of
+	// HeartbeatAddressesValue when tls is enabled for heartbeats (used for
+	// templating)
+	TLSHeartbeatAddressesValue = "__NETWORK__HEARTBEAT__TLS_MESH_SEED_ADDRESS_PORT__"
+	// the value of the key that corresponds to the namespace.rack-id property
+	// (used for templating)
+	RackIdValue = "__NAMESPACE__RACK_ID__"
 
 	defaultFilePath         = "/opt/aerospike/data/"
 	defaultDevicePathPrefix = "/dev/xvd"
@@ -89,12 +144,22 @@ const (
 	nsNameKey              = "name"
 	nsReplicationFactorKey = "replicationFactor"
 	nsMemorySizeKey        = "memorySize"
-	nsDefaultTTLKey        = "defaultTTL"
 	nsStorageTypeKey       = "storageType"
 	nsStorageSizeKey       = "storageSize"
 	nsFilePath             = "filePath"
 	nsDevicePath           = "devicePath"
 	nsDataInMemory         = "dataInMemory"
+	nsConfigKey            = "config"
+	nsRackIdKey            = "rackId"
+	nsStorageConfigKey     = "storageConfig"
+	// the name of the parameter of the namespace context that is set from
+	// the defaultTTL field of the namespace's spec
+	nsDefaultTTLParameter = "default-ttl"
+
+	tlsNameKey     = "name"
+	tlsCertFileKey = "certFile"
+	tlsKeyFileKey  = "keyFile"
+	tlsCAFileKey   = "caFile"
 
 	aspromPortName      = "prometheus"
 	aspromPort          = 9145
@@ -103,6 +168,11 @@ const (
 	aspromCpuLimit      = "10m"
 	aspromMemoryLimit   = "64Mi"
 
+	// the name and password of the admin user created by aerospike when
+	// security is enabled
+	defaultAdminUser     = "admin"
+	defaultAdminPassword = "admin"
+
 	asReadinessInitialDelaySeconds = 3
 	asReadinessTimeoutSeconds      = 2
 	asReadinessPeriodSeconds       = 10
@@ -131,6 +201,13 @@ const (
 	// UpgradeStatusBackupAnnotationValue is the value of the annotation added
 	// to AerospikeCluster resources that are undergoing a pre-upgrade backup.
 	UpgradeStatusBackupAnnotationValue = "backup"
+	// UpgradeRetryAnnotationKey is the name of the annotation used to request
+	// that a failed version upgrade of an AerospikeCluster resource is
+	// retried.
+	UpgradeRetryAnnotationKey = "aerospike.travelaudience.com/upgrade-retry"
+	// UpgradeRetryAnnotationValue is the value of the annotation used to
+	// request that a failed version upgrade is retried.
+	UpgradeRetryAnnotationValue = "true"
 
 	// terminal state reasons when pod status is Pending
 	// container image pull failed
@@ -150,6 +227,27 @@ const (
 	defaultMemorySize = "4G"
 )
 
+// the default values of the parameters of the aerospike configuration that can
+// be overridden in the spec of the cluster, by context
+var (
+	defaultServiceConfig = map[string]string{
+		"service-threads":               "4",
+		"transaction-queues":            "4",
+		"transaction-threads-per-queue": "4",
+		"proto-fd-max":                  "15000",
+	}
+	defaultLoggingConfig = map[string]string{
+		"any": "info",
+	}
+	defaultHeartbeatConfig = map[string]string{
+		"interval": "100",
+		"timeout":  "10",
+	}
+	defaultSecurityConfig = map[string]string{
+		"enable-security": "true",
+	}
+)
+
 var asConfigTemplate = template.Must(template.New("aerospike-config").Parse(aerospikeConfig))
 var asNamespaceTemplate = template.Must(template.New("as-namespace-config").Parse(aerospikeNamespaceConfig))
 
@@ -159,41 +257,74 @@ service {
 	group root
 	paxos-single-replica-limit 1
 	pidfile /var/run/aerospike/asd.pid
-	service-threads 4
-	transaction-queues 4
-	transaction-threads-per-queue 4
-	proto-fd-max 15000
+	{{- with .featureKeyFile}}
+	feature-key-file {{.}}
+	{{- end}}
+	{{- range .serviceConfig}}
+	{{.}}
+	{{- end}}
 	node-id {{.nodeId}}
 }
 
 logging {
 	file /var/log/aerospike/aerospike.log {
-		context any info
+		{{- range .loggingConfig}}
+		context {{.}}
+		{{- end}}
 	}
 
 	console {
-		context any info 
+		{{- range .loggingConfig}}
+		context {{.}}
+		{{- end}}
 	}
 }
 
-network {
+{{if .security}}security {
+	{{- range .securityConfig}}
+	{{.}}
+	{{- end}}
+}
+
+{{end}}network {
+	{{- range .tls}}
+	tls {{.name}} {
+		cert-file {{.certFile}}
+		key-file {{.keyFile}}
+		{{- if .caFile}}
+		ca-file {{.caFile}}
+		{{- end}}
+	}
+	{{end}}
 	service {
 		address any
 		port 3000
+		{{- with .tlsService}}
+		tls-address any
+		tls-port 4333
+		tls-name {{.}}
+		tls-authenticate-client false
+		{{- end}}
 	}
 
 	heartbeat {
 		mode mesh
-		port 3002
+		{{if .tlsHeartbeat}}tls-port 3012
+		tls-name {{.tlsHeartbeat}}{{else}}port 3002{{end}}
 
 		{{.heartbeatAddresses}}
 
-		interval 100
-		timeout 10
+		{{- range .heartbeatConfig}}
+		{{.}}
+		{{- end}}
 	}
 
 	fabric {
-		port 3001
+		{{if .tlsFabric}}tls-port 3011
+		tls-name {{.tlsFabric}}{{else}}port 3001{{end}}
+		{{- range .fabricConfig}}
+		{{.}}
+		{{- end}}
 	}
 
 	info {
@@ -209,6 +340,10 @@ network {
 const aerospikeNamespaceConfig = `
 namespace {{.name}} {
 
+	{{if .rackId}}
+		rack-id {{.rackId}}
+	{{end}}
+
 	{{if .replicationFactor}}
 		replication-factor {{.replicationFactor}}
 	{{end}}
@@ -217,9 +352,9 @@ namespace {{.name}} {
 		memory-size {{.memorySize}}
 	{{end}}
 
-	{{if .defaultTTL}}
-		default-ttl {{.defaultTTL}}
-	{{end}}
+	{{- range .config}}
+	{{.}}
+	{{- end}}
 
 	storage-engine device {
 
@@ -236,5 +371,9 @@ namespace {{.name}} {
 		{{- if .dataInMemory}}
 			data-in-memory {{.dataInMemory}}
 		{{- end}}
+
+		{{- range .storageConfig}}
+		{{.}}
+		{{- end}}
 	}
 }`
@@ -0,0 +1,371 @@
+/*
+Copyright 2019 The aerospike-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reconciler
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+
+	aerospikev1alpha2 "github.com/travelaudience/aerospike-operator/pkg/apis/aerospike/v1alpha2"
+	"github.com/travelaudience/aerospike-operator/pkg/asconfig"
+	"github.com/travelaudience/aerospike-operator/pkg/asutils"
+	"github.com/travelaudience/aerospike-operator/pkg/logfields"
+	"github.com/travelaudience/aerospike-operator/pkg/meta"
+	"github.com/travelaudience/aerospike-operator/pkg/utils/events"
+	"github.com/travelaudience/aerospike-operator/pkg/versioning"
+)
+
+// configParameter represents the value of a parameter of the aerospike
+// configuration that can be overridden in the spec of a cluster.
+type configParameter struct {
+	// context is the context the parameter belongs to.
+	context asconfig.Context
+	// namespace is the name of the aerospike namespace the parameter applies
+	// to (for the namespace contexts only).
+	namespace string
+	name      string
+	value     string
+	// dynamic indicates whether the parameter can be changed on running nodes.
+	dynamic bool
+}
+
+// key returns a string that uniquely identifies the parameter.
+func (p configParameter) key() string {
+	if p.namespace == "" {
+		return fmt.Sprintf("%s/%s", p.context, p.name)
+	}
+	return fmt.Sprintf("%s/%s/%s", p.context, p.namespace, p.name)
+}
+
+// getConfigParameters returns the values of the parameters of the aerospike
+// configuration of aerospikeCluster that can be overridden in its spec,
+// including the defaults used by aerospike-operator.
+func getConfigParameters(aerospikeCluster *aerospikev1alpha2.AerospikeCluster) []configParameter {
+	version := getConfigVersion(aerospikeCluster)
+
+	var service, logging, heartbeat, fabric map[string]string
+	if config := aerospikeCluster.Spec.Config; config != nil {
+		service = config.Service
+		logging = config.Logging
+		if config.Network != nil {
+			heartbeat = config.Network.Heartbeat
+			fabric = config.Network.Fabric
+		}
+	}
+
+	res := make([]configParameter, 0)
+	res = appendConfigParameters(res, version, asconfig.ContextService, "", asconfig.Merge(version, asconfig.ContextService, defaultServiceConfig, service))
+	res = appendConfigParameters(res, version, asconfig.ContextLogging, "", asconfig.Merge(version, asconfig.ContextLogging, defaultLoggingConfig, logging))
+	res = appendConfigParameters(res, version, asconfig.ContextHeartbeat, "", asconfig.Merge(version, asconfig.ContextHeartbeat, defaultHeartbeatConfig, heartbeat))
+	res = appendConfigParameters(res, version, asconfig.ContextFabric, "", asconfig.Merge(version, asconfig.ContextFabric, nil, fabric))
+	for _, namespace := range aerospikeCluster.Spec.Namespaces {
+		params := asconfig.Merge(version, asconfig.ContextNamespace, nil, namespace.Config)
+		if namespace.DefaultTTL != nil {
+			if value, err := strconv.Atoi(strings.TrimSuffix(*namespace.DefaultTTL, "s")); err == nil {
+				params[nsDefaultTTLParameter] = strconv.Itoa(value)
+			}
+		}
+		res = appendConfigParameters(res, version, asconfig.ContextNamespace, namespace.Name, params)
+		res = appendConfigParameters(res, version, asconfig.ContextStorageEngine, namespace.Name, asconfig.Merge(version, asconfig.ContextStorageEngine, nil, namespace.Storage.Config))
+	}
+	return res
+}
+
+// appendConfigParameters appends the parameters in params (sorted by name) to
+// res.
+func appendConfigParameters(res []configParameter, version versioning.Version, ctx asconfig.Context, namespace string, params map[string]string) []configParameter {
+	names := make([]string, 0, len(params))
+	for name := range params {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		res = append(res, configParameter{
+			context:   ctx,
+			namespace: namespace,
+			name:      name,
+			value:     params[name],
+			dynamic:   asconfig.IsDynamic(version, ctx, name),
+		})
+	}
+	return res
+}
+
+// staticConfigParameters returns the parameters in params that cannot be
+// changed on running nodes.
+func staticConfigParameters(params []configParameter) []configParameter {
+	res := make([]configParameter, 0, len(params))
+	for _, p := range params {
+		if !p.dynamic {
+			res = append(res, p)
+		}
+	}
+	return res
+}
+
+// configLines returns the lines of the config file that set the parameters in
+// params belonging to the specified context (and aerospike namespace).
+func configLines(params []configParameter, ctx asconfig.Context, namespace string) []string {
+	values := make(map[string]string)
+	for _, p := range params {
+		if p.context == ctx && p.namespace == namespace {
+			values[p.name] = p.value
+		}
+	}
+	return asconfig.Lines(values)
+}
+
+// encodeDynamicConfig returns the json object holding the values of the
+// parameters in params that can be changed on running nodes, indexed by key.
+func encodeDynamicConfig(params []configParameter) string {
+	values := make(map[string]string)
+	for _, p := range params {
+		if p.dynamic {
+			values[p.key()] = p.value
+		}
+	}
+	// marshaling a map of strings never fails
+	b, _ := json.Marshal(values)
+	return string(b)
+}
+
+// decodeDynamicConfig parses the value of the dynamicConfigAnnotation
+// annotation.
+func decodeDynamicConfig(value string) (map[string]string, error) {
+	res := make(map[string]string)
+	if err := json.Unmarshal([]byte(value), &res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// podRequiresRestart returns whether pod must be restarted in order to use the
+// config file in configMap, the images identified by imagesHash, the tls
+// certificates identified by tlsHash and the admin credentials identified by
+// adminHash, as opposed to having any configuration changes applied while it
+// is running.
+func podRequiresRestart(configMap *corev1.ConfigMap, pod *corev1.Pod, imagesHash, tlsHash, adminHash string) bool {
+	if imagesHash != pod.Annotations[imagesHashAnnotation] {
+		return true
+	}
+	// aerospike does not reload certificates, so pods must be restarted in
+	// order to use rotated ones
+	if tlsHash != pod.Annotations[tlsHashAnnotation] {
+		return true
+	}
+	// asprom only reads the password of the admin user on startup
+	if adminHash != pod.Annotations[adminHashAnnotation] {
+		return true
+	}
+	if configMap.Annotations[configMapHashAnnotation] == pod.Annotations[configMapHashAnnotation] {
+		return false
+	}
+	// pods created before configuration changes could be applied while
+	// running are not annotated with the static part of their configuration
+	if _, ok := pod.Annotations[staticConfigHashAnnotation]; !ok {
+		return true
+	}
+	if configMap.Annotations[staticConfigHashAnnotation] != pod.Annotations[staticConfigHashAnnotation] {
+		return true
+	}
+	applied, err := decodeDynamicConfig(pod.Annotations[dynamicConfigAnnotation])
+	if err != nil {
+		return true
+	}
+	desired, err := decodeDynamicConfig(configMap.Annotations[dynamicConfigAnnotation])
+	if err != nil {
+		return true
+	}
+	// parameters that are no longer set cannot be reverted to the defaults of
+	// aerospike while the pod is running
+	for key := range applied {
+		if _, ok := desired[key]; !ok {
+			return true
+		}
+	}
+	return false
+}
+
+// applyDynamicConfig applies to the running pod the changes to the values of
+// the parameters of the configuration that can be changed without restarting,
+// as held by configMap, and annotates the pod accordingly. It must only be
+// called if podRequiresRestart returns false.
+func (r *AerospikeClusterReconciler) applyDynamicConfig(aerospikeCluster *aerospikev1alpha2.AerospikeCluster, configMap *corev1.ConfigMap, pod *corev1.Pod) (*corev1.Pod, error) {
+	applied, err := decodeDynamicConfig(pod.Annotations[dynamicConfigAnnotation])
+	if err != nil {
+		return nil, err
+	}
+	desired, err := decodeDynamicConfig(configMap.Annotations[dynamicConfigAnnotation])
+	if err != nil {
+		return nil, err
+	}
+
+	creds, err := r.getCredentials(aerospikeCluster)
+	if err != nil {
+		return nil, err
+	}
+
+	params := make([]configParameter, 0)
+	for _, p := range getConfigParameters(aerospikeCluster) {
+		if value, ok := desired[p.key()]; ok && p.dynamic && applied[p.key()] != value {
+			params = append(params, p)
+		}
+	}
+	if len(params) > 0 {
+		log.WithFields(log.Fields{
+			logfields.AerospikeCluster: meta.Key(aerospikeCluster),
+			logfields.Pod:              meta.Key(pod),
+		}).Debugf("applying %d configuration changes to the running pod", len(params))
+
+		var sinks []string
+		for _, p := range params {
+			// logging levels are set for every log sink, which must be
+			// listed first
+			if p.context == asconfig.ContextLogging && sinks == nil {
+				if sinks, err = getLogSinks(pod, creds); err != nil {
+					return nil, err
+				}
+			}
+			for _, command := range setConfigCommands(p, sinks) {
+				if err := runSetConfigCommandOnPod(pod, command, creds); err != nil {
+					return nil, err
+				}
+			}
+		}
+
+		r.recorder.Eventf(aerospikeCluster, corev1.EventTypeNormal, events.ReasonNodeConfigUpdated,
+			"applied %d configuration changes to pod %s without restarting it",
+			len(params), meta.Key(pod))
+	}
+
+	// record the configuration now in use by the pod
+	podCopy := pod.DeepCopy()
+	podCopy.Annotations[configMapHashAnnotation] = configMap.Annotations[configMapHashAnnotation]
+	podCopy.Annotations[dynamicConfigAnnotation] = configMap.Annotations[dynamicConfigAnnotation]
+	return r.kubeclientset.CoreV1().Pods(pod.Namespace).Update(podCopy)
+}
+
+// setConfigCommands returns the info commands that set the parameter p to its
+// value on a running node whose log sinks have the specified ids.
+func setConfigCommands(p configParameter, sinks []string) []string {
+	switch p.context {
+	case asconfig.ContextService:
+		return []string{fmt.Sprintf("set-config:context=service;%s=%s", p.name, p.value)}
+	case asconfig.ContextHeartbeat:
+		return []string{fmt.Sprintf("set-config:context=network;heartbeat.%s=%s", p.name, p.value)}
+	case asconfig.ContextFabric:
+		return []string{fmt.Sprintf("set-config:context=network;fabric.%s=%s", p.name, p.value)}
+	case asconfig.ContextNamespace, asconfig.ContextStorageEngine:
+		return []string{fmt.Sprintf("set-config:context=namespace;id=%s;%s=%s", p.namespace, p.name, p.value)}
+	case asconfig.ContextLogging:
+		res := make([]string, 0, len(sinks))
+		for _, sink := range sinks {
+			res = append(res, fmt.Sprintf("log-set:id=%s;%s=%s", sink, p.name, p.value))
+		}
+		return res
+	}
+	return nil
+}
+
+// runSetConfigCommandOnPod runs the specified command on pod, returning an
+// error if aerospike does not acknowledge it.
+func runSetConfigCommandOnPod(pod *corev1.Pod, command string, creds *asutils.Credentials) error {
+	res, err := runInfoCommandOnPod(pod, command, creds)
+	if err != nil {
+		return err
+	}
+	if res[command] != "ok" {
+		return fmt.Errorf("failed to run %q on pod %s: %s", command, meta.Key(pod), res[command])
+	}
+	return nil
+}
+
+// getLogSinks returns the ids of the log sinks of the aerospike node running
+// in pod.
+func getLogSinks(pod *corev1.Pod, creds *asutils.Credentials) ([]string, error) {
+	res, err := runInfoCommandOnPod(pod, "logs", creds)
+	if err != nil {
+		return nil, err
+	}
+	sinks := parseLogSinks(res["logs"])
+	if len(sinks) == 0 {
+		return nil, fmt.Errorf("failed to get the log sinks of pod %s", meta.Key(pod))
+	}
+	return sinks, nil
+}
+
+// parseLogSinks returns the ids of the log sinks listed in the response to the
+// logs info command, which has the form <id>:<path>;<id>:<path>;...
+func parseLogSinks(res string) []string {
+	sinks := make([]string, 0)
+	for _, sink := range strings.Split(res, ";") {
+		if id := strings.SplitN(sink, ":", 2)[0]; id != "" {
+			sinks = append(sinks, id)
+		}
+	}
+	return sinks
+}
+
+// getAppliedConfig returns the values of the parameters of the aerospike
+// configuration of aerospikeCluster that can be overridden in its spec, as
+// applied to every pod in pods (and reported in its status). The values of
+// dynamic parameters are read from the annotations of the pods, and the values
+// of static parameters are only reported once every pod uses the static part
+// of the configuration held by configMap. Parameters whose value differs
+// between pods are omitted.
+func getAppliedConfig(aerospikeCluster *aerospikev1alpha2.AerospikeCluster, configMap *corev1.ConfigMap, pods []*corev1.Pod) []aerospikev1alpha2.AerospikeConfigParameterStatus {
+	res := make([]aerospikev1alpha2.AerospikeConfigParameterStatus, 0)
+	if len(pods) == 0 {
+		return res
+	}
+	staticApplied := true
+	dynamic := make([]map[string]string, 0, len(pods))
+	for _, pod := range pods {
+		if pod.Annotations[staticConfigHashAnnotation] != configMap.Annotations[staticConfigHashAnnotation] {
+			staticApplied = false
+		}
+		// pods whose annotation is missing or invalid have no known values
+		values, _ := decodeDynamicConfig(pod.Annotations[dynamicConfigAnnotation])
+		dynamic = append(dynamic, values)
+	}
+	for _, p := range getConfigParameters(aerospikeCluster) {
+		value, ok := p.value, staticApplied
+		if p.dynamic {
+			value, ok = dynamic[0][p.key()]
+			for _, values := range dynamic[1:] {
+				ok = ok && values[p.key()] == value
+			}
+		}
+		if !ok {
+			continue
+		}
+		res = append(res, aerospikev1alpha2.AerospikeConfigParameterStatus{
+			Context:   string(p.context),
+			Namespace: p.namespace,
+			Name:      p.name,
+			Value:     value,
+			Dynamic:   p.dynamic,
+		})
+	}
+	return res
+}
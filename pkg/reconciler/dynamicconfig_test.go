@@ -0,0 +1,189 @@
+/*
+Copyright 2019 The aerospike-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reconciler
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	aerospikev1alpha2 "github.com/travelaudience/aerospike-operator/pkg/apis/aerospike/v1alpha2"
+	"github.com/travelaudience/aerospike-operator/pkg/asconfig"
+)
+
+func TestPodRequiresRestart(t *testing.T) {
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Annotations: map[string]string{
+				configMapHashAnnotation:    "config-1",
+				staticConfigHashAnnotation: "static-0",
+				dynamicConfigAnnotation:    `{"service/proto-fd-max":"20000","logging/any":"info"}`,
+			},
+		},
+	}
+	newPod := func(annotations map[string]string) *corev1.Pod {
+		res := map[string]string{
+			imagesHashAnnotation:       "images-0",
+			configMapHashAnnotation:    "config-0",
+			staticConfigHashAnnotation: "static-0",
+			dynamicConfigAnnotation:    `{"service/proto-fd-max":"15000","logging/any":"info"}`,
+		}
+		for key, value := range annotations {
+			if value == "" {
+				delete(res, key)
+			} else {
+				res[key] = value
+			}
+		}
+		return &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Annotations: res}}
+	}
+
+	tests := []struct {
+		name       string
+		pod        *corev1.Pod
+		imagesHash string
+		tlsHash    string
+		adminHash  string
+		expected   bool
+	}{
+		{"dynamic changes", newPod(nil), "images-0", "", "", false},
+		{"up-to-date config", newPod(map[string]string{configMapHashAnnotation: "config-1"}), "images-0", "", "", false},
+		{"images changed", newPod(nil), "images-1", "", "", true},
+		{"tls enabled", newPod(nil), "images-0", "tls-0", "", true},
+		{"tls certificates rotated", newPod(map[string]string{tlsHashAnnotation: "tls-0"}), "images-0", "tls-1", "", true},
+		{"security enabled", newPod(nil), "images-0", "", "admin-0", true},
+		{"admin password changed", newPod(map[string]string{adminHashAnnotation: "admin-0"}), "images-0", "", "admin-1", true},
+		{"static changes", newPod(map[string]string{staticConfigHashAnnotation: "static-1"}), "images-0", "", "", true},
+		{"pod created before dynamic changes were supported", newPod(map[string]string{staticConfigHashAnnotation: ""}), "images-0", "", "", true},
+		{"invalid dynamic config", newPod(map[string]string{dynamicConfigAnnotation: "{"}), "images-0", "", "", true},
+		{"parameter no longer set", newPod(map[string]string{dynamicConfigAnnotation: `{"service/proto-fd-max":"15000","logging/any":"info","service/migrate-threads":"2"}`}), "images-0", "", "", true},
+		{"parameter newly set", newPod(map[string]string{dynamicConfigAnnotation: `{"logging/any":"info"}`}), "images-0", "", "", false},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.expected, podRequiresRestart(configMap, test.pod, test.imagesHash, test.tlsHash, test.adminHash), test.name)
+	}
+}
+
+func TestSetConfigCommands(t *testing.T) {
+	tests := []struct {
+		param    configParameter
+		sinks    []string
+		expected []string
+	}{
+		{
+			configParameter{context: asconfig.ContextService, name: "proto-fd-max", value: "20000"},
+			nil,
+			[]string{"set-config:context=service;proto-fd-max=20000"},
+		},
+		{
+			configParameter{context: asconfig.ContextHeartbeat, name: "interval", value: "150"},
+			nil,
+			[]string{"set-config:context=network;heartbeat.interval=150"},
+		},
+		{
+			configParameter{context: asconfig.ContextFabric, name: "channel-bulk-recv-threads", value: "8"},
+			nil,
+			[]string{"set-config:context=network;fabric.channel-bulk-recv-threads=8"},
+		},
+		{
+			configParameter{context: asconfig.ContextNamespace, namespace: "as-namespace-0", name: "high-water-memory-pct", value: "70"},
+			nil,
+			[]string{"set-config:context=namespace;id=as-namespace-0;high-water-memory-pct=70"},
+		},
+		{
+			configParameter{context: asconfig.ContextStorageEngine, namespace: "as-namespace-0", name: "defrag-lwm-pct", value: "60"},
+			nil,
+			[]string{"set-config:context=namespace;id=as-namespace-0;defrag-lwm-pct=60"},
+		},
+		{
+			configParameter{context: asconfig.ContextLogging, name: "migrate", value: "debug"},
+			[]string{"0", "1"},
+			[]string{"log-set:id=0;migrate=debug", "log-set:id=1;migrate=debug"},
+		},
+		{
+			configParameter{context: asconfig.ContextLogging, name: "migrate", value: "debug"},
+			[]string{},
+			[]string{},
+		},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.expected, setConfigCommands(test.param, test.sinks), test.param.key())
+	}
+}
+
+func TestParseLogSinks(t *testing.T) {
+	tests := []struct {
+		res      string
+		expected []string
+	}{
+		{"0:stderr", []string{"0"}},
+		{"0:stderr;1:/var/log/aerospike/aerospike.log", []string{"0", "1"}},
+		{"0:stderr;", []string{"0"}},
+		{"", []string{}},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.expected, parseLogSinks(test.res), test.res)
+	}
+}
+
+func TestGetAppliedConfig(t *testing.T) {
+	aerospikeCluster := &aerospikev1alpha2.AerospikeCluster{
+		Spec: aerospikev1alpha2.AerospikeClusterSpec{
+			Version: "4.3.0.10",
+		},
+	}
+	params := getConfigParameters(aerospikeCluster)
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Annotations: map[string]string{
+				staticConfigHashAnnotation: "static-0",
+				dynamicConfigAnnotation:    encodeDynamicConfig(params),
+			},
+		},
+	}
+	newPod := func(staticConfigHash, dynamicConfig string) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Annotations: map[string]string{
+					staticConfigHashAnnotation: staticConfigHash,
+					dynamicConfigAnnotation:    dynamicConfig,
+				},
+			},
+		}
+	}
+	desired := configMap.Annotations[dynamicConfigAnnotation]
+	dynamic := len(params) - len(staticConfigParameters(params))
+
+	// every parameter is reported once every pod uses the configuration
+	applied := getAppliedConfig(aerospikeCluster, configMap, []*corev1.Pod{newPod("static-0", desired), newPod("static-0", desired)})
+	assert.Len(t, applied, len(params))
+	// static parameters are only reported once every pod uses them
+	applied = getAppliedConfig(aerospikeCluster, configMap, []*corev1.Pod{newPod("static-0", desired), newPod("static-1", desired)})
+	assert.Len(t, applied, dynamic)
+	// dynamic parameters are reported with the values applied to the pods,
+	// unless they differ between pods
+	applied = getAppliedConfig(aerospikeCluster, configMap, []*corev1.Pod{newPod("static-1", `{"logging/any":"debug"}`), newPod("static-1", `{"logging/any":"debug"}`)})
+	assert.Equal(t, []aerospikev1alpha2.AerospikeConfigParameterStatus{
+		{Context: string(asconfig.ContextLogging), Name: "any", Value: "debug", Dynamic: true},
+	}, applied)
+	applied = getAppliedConfig(aerospikeCluster, configMap, []*corev1.Pod{newPod("static-1", `{"logging/any":"debug"}`), newPod("static-1", `{"logging/any":"info"}`)})
+	assert.Len(t, applied, 0)
+	// nothing is reported when there are no pods
+	assert.Len(t, getAppliedConfig(aerospikeCluster, configMap, nil), 0)
+}
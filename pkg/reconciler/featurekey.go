@@ -0,0 +1,64 @@
+/*
+Copyright 2019 The aerospike-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reconciler
+
+import (
+	"path"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/travelaudience/aerospike-operator/pkg/apis/aerospike/common"
+	aerospikev1alpha2 "github.com/travelaudience/aerospike-operator/pkg/apis/aerospike/v1alpha2"
+)
+
+// getFeatureKeyFile returns the path of the feature key file of Aerospike
+// Enterprise used by aerospikeCluster, or an empty string if it does not use
+// one. The path includes the name of the secret so that pods are restarted
+// when the secret is replaced.
+func getFeatureKeyFile(aerospikeCluster *aerospikev1alpha2.AerospikeCluster) string {
+	if aerospikeCluster.Spec.FeatureKeySecret == nil {
+		return ""
+	}
+	return path.Join(featureKeyMountPathPrefix, *aerospikeCluster.Spec.FeatureKeySecret, common.FeatureKeyFilename)
+}
+
+// getFeatureKeyVolumes returns the volumes and volume mounts required to
+// mount the secret holding the feature key file used by aerospikeCluster in
+// the aerospike-server container.
+func getFeatureKeyVolumes(aerospikeCluster *aerospikev1alpha2.AerospikeCluster) ([]corev1.Volume, []corev1.VolumeMount) {
+	if aerospikeCluster.Spec.FeatureKeySecret == nil {
+		return nil, nil
+	}
+	volumes := []corev1.Volume{
+		{
+			Name: featureKeyVolumeName,
+			VolumeSource: corev1.VolumeSource{
+				Secret: &corev1.SecretVolumeSource{
+					SecretName: *aerospikeCluster.Spec.FeatureKeySecret,
+				},
+			},
+		},
+	}
+	mounts := []corev1.VolumeMount{
+		{
+			Name:      featureKeyVolumeName,
+			MountPath: path.Dir(getFeatureKeyFile(aerospikeCluster)),
+			ReadOnly:  true,
+		},
+	}
+	return volumes, mounts
+}
@@ -39,6 +39,7 @@ var (
 )
 
 func (r *AerospikeClusterReconciler) ensureNetworkPolicy(aerospikeCluster *aerospikev1alpha2.AerospikeCluster) error {
+	tls := getTLSSpec(aerospikeCluster)
 	policy := networkv1.NetworkPolicy{
 		ObjectMeta: metav1.ObjectMeta{
 			Name: aerospikeCluster.Name,
@@ -166,6 +167,34 @@ func (r *AerospikeClusterReconciler) ensureNetworkPolicy(aerospikeCluster *aeros
 		},
 	}
 
+	// allow connections to the tls ports of the listeners that use tls, from
+	// other nodes for fabric and heartbeat and from anywhere for service
+	var tlsPorts []int32
+	if tls.Fabric != nil {
+		tlsPorts = append(tlsPorts, fabricTLSPort)
+	}
+	if tls.Heartbeat != nil {
+		tlsPorts = append(tlsPorts, HeartbeatTLSPort)
+	}
+	for _, port := range tlsPorts {
+		policyPort := networkv1.NetworkPolicyPort{
+			Protocol: &protocolTCP,
+			Port: &intstr.IntOrString{
+				IntVal: port,
+			},
+		}
+		policy.Spec.Ingress[0].Ports = append(policy.Spec.Ingress[0].Ports, policyPort)
+		policy.Spec.Egress[0].Ports = append(policy.Spec.Egress[0].Ports, policyPort)
+	}
+	if tls.Service != nil {
+		policy.Spec.Ingress[1].Ports = append(policy.Spec.Ingress[1].Ports, networkv1.NetworkPolicyPort{
+			Protocol: &protocolTCP,
+			Port: &intstr.IntOrString{
+				IntVal: serviceTLSPort,
+			},
+		})
+	}
+
 	if _, err := r.kubeclientset.NetworkingV1().NetworkPolicies(aerospikeCluster.Namespace).Create(&policy); err != nil {
 		if !errors.IsAlreadyExists(err) {
 			return err
@@ -19,6 +19,7 @@ package reconciler
 import (
 	"crypto/md5"
 	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"io"
 	"path"
@@ -39,8 +40,10 @@ import (
 	"github.com/travelaudience/aerospike-operator/pkg/apis/aerospike/common"
 	aerospikev1alpha2 "github.com/travelaudience/aerospike-operator/pkg/apis/aerospike/v1alpha2"
 	"github.com/travelaudience/aerospike-operator/pkg/asutils"
+	"github.com/travelaudience/aerospike-operator/pkg/backuprestore"
 	"github.com/travelaudience/aerospike-operator/pkg/crd"
 	"github.com/travelaudience/aerospike-operator/pkg/debug"
+	"github.com/travelaudience/aerospike-operator/pkg/images"
 	"github.com/travelaudience/aerospike-operator/pkg/logfields"
 	"github.com/travelaudience/aerospike-operator/pkg/meta"
 	"github.com/travelaudience/aerospike-operator/pkg/pointers"
@@ -62,6 +65,29 @@ func (r *AerospikeClusterReconciler) ensurePods(aerospikeCluster *aerospikev1alp
 	if err != nil {
 		return err
 	}
+	// grab the hash of the images requested for the cluster so we can tell
+	// whether pods must be restarted in order to use them
+	imagesHash, err := computeImagesHash(aerospikeCluster)
+	if err != nil {
+		return err
+	}
+	// grab the hash of the contents of the tls secrets so we can tell whether
+	// pods must be restarted in order to use rotated certificates
+	tlsHash, err := r.computeTLSHash(aerospikeCluster)
+	if err != nil {
+		return err
+	}
+	// grab the hash of the credentials of the admin user so we can tell
+	// whether pods must be restarted in order to use a changed password
+	adminHash, err := r.computeAdminHash(aerospikeCluster)
+	if err != nil {
+		return err
+	}
+	// make sure that the admin user can access the cluster before operating
+	// on its pods, in case security is enabled
+	if err := r.ensureAdminUser(aerospikeCluster); err != nil {
+		return err
+	}
 	// grab the current and desired size of the cluster
 	currentSize := len(pods)
 	desiredSize := int(aerospikeCluster.Spec.NodeCount)
@@ -126,7 +152,7 @@ func (r *AerospikeClusterReconciler) ensurePods(aerospikeCluster *aerospikev1alp
 				return err
 			}
 		// check whether the pod needs to be restarted
-		case configMap.Annotations[configMapHashAnnotation] != pod.Annotations[configMapHashAnnotation]:
+		case podRequiresRestart(configMap, pod, imagesHash, tlsHash, adminHash):
 			pod, err = r.safeRestartPodWithIndex(aerospikeCluster, configMap, i, upgrade)
 			if err != nil {
 				log.WithFields(log.Fields{
@@ -135,6 +161,16 @@ func (r *AerospikeClusterReconciler) ensurePods(aerospikeCluster *aerospikev1alp
 				}).Errorf("failed to restart pod: %v", err)
 				return err
 			}
+		// check whether configuration changes must be applied to the running pod
+		case configMap.Annotations[configMapHashAnnotation] != pod.Annotations[configMapHashAnnotation]:
+			pod, err = r.applyDynamicConfig(aerospikeCluster, configMap, pod)
+			if err != nil {
+				log.WithFields(log.Fields{
+					logfields.AerospikeCluster: meta.Key(aerospikeCluster),
+					logfields.PodIndex:         i,
+				}).Errorf("failed to apply configuration changes to pod: %v", err)
+				return err
+			}
 		default:
 			// ensure aerospike is reachable and reports the correct clusterSize
 			if err := r.ensureClusterSize(aerospikeCluster, pod); err != nil {
@@ -199,6 +235,34 @@ func (r *AerospikeClusterReconciler) createPodWithIndex(aerospikeCluster *aerosp
 		return nil, fmt.Errorf("failed to compute node id for %s: %v", podName, err)
 	}
 
+	// parse the version so we can get the image used to run it
+	version, err := versioning.NewVersionFromString(aerospikeCluster.Spec.Version)
+	if err != nil {
+		return nil, err
+	}
+	// imagesHash contains the hash of the images requested for the cluster
+	imagesHash, err := computeImagesHash(aerospikeCluster)
+	if err != nil {
+		return nil, err
+	}
+	// tlsHash contains the hash of the contents of the tls secrets used by the
+	// cluster
+	tlsHash, err := r.computeTLSHash(aerospikeCluster)
+	if err != nil {
+		return nil, err
+	}
+	// adminHash contains the hash of the credentials of the admin user
+	adminHash, err := r.computeAdminHash(aerospikeCluster)
+	if err != nil {
+		return nil, err
+	}
+	// tlsVolumes and tlsVolumeMounts mount the tls secrets used by the
+	// cluster in the aerospike-server container
+	tlsVolumes, tlsVolumeMounts := getTLSVolumes(aerospikeCluster)
+	// featureKeyVolumes and featureKeyVolumeMounts mount the feature key file
+	// used by the cluster in the aerospike-server container
+	featureKeyVolumes, featureKeyVolumeMounts := getFeatureKeyVolumes(aerospikeCluster)
+
 	// list all active pods so we can use those as mesh seeds for the pod
 	pods, err := r.listClusterPods(aerospikeCluster)
 	if err != nil {
@@ -216,6 +280,23 @@ func (r *AerospikeClusterReconciler) createPodWithIndex(aerospikeCluster *aerosp
 	}
 	// build the comma-separated list of peers which to pass to asinit
 	peerList := strings.Join(peers, ",")
+	// build the arguments to pass to asinit
+	initArgs := []string{
+		"/usr/local/bin/asinit",
+		"--node-id",
+		nodeId,
+		"--peer-list",
+		peerList,
+		"--source-config",
+		initialConfigFilePath,
+		"--target-config",
+		finalConfigFilePath,
+	}
+	// rack contains the rack the pod belongs to, if any
+	rack := getRackForIndex(aerospikeCluster, index)
+	if rack != nil {
+		initArgs = append(initArgs, "--rack-id", strconv.Itoa(int(rack.ID)))
+	}
 
 	// pod represents the pod that will be created
 	pod := &corev1.Pod{
@@ -237,29 +318,26 @@ func (r *AerospikeClusterReconciler) createPodWithIndex(aerospikeCluster *aerosp
 				},
 			},
 			Annotations: map[string]string{
-				configMapHashAnnotation: configMap.Annotations[configMapHashAnnotation],
-				nodeIdAnnotation:        nodeId,
+				configMapHashAnnotation:    configMap.Annotations[configMapHashAnnotation],
+				staticConfigHashAnnotation: configMap.Annotations[staticConfigHashAnnotation],
+				dynamicConfigAnnotation:    configMap.Annotations[dynamicConfigAnnotation],
+				imagesHashAnnotation:       imagesHash,
+				tlsHashAnnotation:          tlsHash,
+				adminHashAnnotation:        adminHash,
+				nodeIdAnnotation:           nodeId,
 			},
 		},
 		Spec: corev1.PodSpec{
 			// use a init container to set the values of service.node-id to the
-			// value of nodeId and of network.heartbeat.mesh-seed-adress-port[]
-			// to the list of currently active nodes
+			// value of nodeId, of network.heartbeat.mesh-seed-adress-port[]
+			// to the list of currently active nodes and of namespace.rack-id
+			// to the id of the pod's rack
 			InitContainers: []corev1.Container{
 				{
-					Name:  "init",
-					Image: fmt.Sprintf("%s:%s", "quay.io/travelaudience/aerospike-operator-tools", versioning.OperatorVersion),
-					Command: []string{
-						"/usr/local/bin/asinit",
-						"--node-id",
-						nodeId,
-						"--peer-list",
-						peerList,
-						"--source-config",
-						initialConfigFilePath,
-						"--target-config",
-						finalConfigFilePath,
-					},
+					Name:            "init",
+					Image:           images.Tools(aerospikeCluster.Spec.Images),
+					ImagePullPolicy: images.PullPolicy(aerospikeCluster.Spec.Images, ""),
+					Command:         initArgs,
 					VolumeMounts: []corev1.VolumeMount{
 						{
 							Name:      initialConfigVolumeName,
@@ -280,8 +358,9 @@ func (r *AerospikeClusterReconciler) createPodWithIndex(aerospikeCluster *aerosp
 			},
 			Containers: []corev1.Container{
 				{
-					Name:  "aerospike-server",
-					Image: fmt.Sprintf("aerospike/aerospike-server:%s", aerospikeCluster.Spec.Version),
+					Name:            "aerospike-server",
+					Image:           images.Server(version, aerospikeCluster.Spec.Images),
+					ImagePullPolicy: images.PullPolicy(aerospikeCluster.Spec.Images, ""),
 					Command: []string{
 						"/usr/bin/asd",
 						"--foreground",
@@ -335,11 +414,10 @@ func (r *AerospikeClusterReconciler) createPodWithIndex(aerospikeCluster *aerosp
 				},
 				{
 					Name:            "asprom",
-					Image:           fmt.Sprintf("%s:%s", "quay.io/travelaudience/aerospike-operator-tools", versioning.OperatorVersion),
-					ImagePullPolicy: corev1.PullAlways,
-					Command: []string{
-						"asprom",
-					},
+					Image:           images.Exporter(aerospikeCluster.Spec.Images),
+					ImagePullPolicy: images.PullPolicy(aerospikeCluster.Spec.Images, corev1.PullAlways),
+					Command:         aspromArgs(aerospikeCluster),
+					Env:             backuprestore.CredentialsEnv(aerospikeCluster.Spec.Security),
 					Ports: []corev1.ContainerPort{
 						{
 							Name:          "http",
@@ -386,6 +464,7 @@ func (r *AerospikeClusterReconciler) createPodWithIndex(aerospikeCluster *aerosp
 					},
 				},
 			},
+			ImagePullSecrets: images.PullSecrets(aerospikeCluster.Spec.Images),
 			// let the reconcile loop handle pod restarts
 			RestartPolicy: corev1.RestartPolicyNever,
 			// use the pod's (stable) name as the hostname
@@ -424,6 +503,24 @@ func (r *AerospikeClusterReconciler) createPodWithIndex(aerospikeCluster *aerosp
 		}
 	}
 
+	// run the pod on the kubernetes nodes of its rack
+	if rack != nil {
+		if pod.Spec.Affinity == nil {
+			pod.Spec.Affinity = &corev1.Affinity{}
+		}
+		pod.Spec.Affinity.NodeAffinity = getRackNodeAffinity(rack)
+	}
+
+	// expose the tls ports of the aerospike-server container and mount the
+	// tls secrets in it, in case tls is enabled
+	pod.Spec.Containers[0].Ports = append(pod.Spec.Containers[0].Ports, getTLSContainerPorts(aerospikeCluster)...)
+	pod.Spec.Containers[0].VolumeMounts = append(pod.Spec.Containers[0].VolumeMounts, tlsVolumeMounts...)
+	pod.Spec.Volumes = append(pod.Spec.Volumes, tlsVolumes...)
+	// mount the feature key file in the aerospike-server container, in case
+	// one is used
+	pod.Spec.Containers[0].VolumeMounts = append(pod.Spec.Containers[0].VolumeMounts, featureKeyVolumeMounts...)
+	pod.Spec.Volumes = append(pod.Spec.Volumes, featureKeyVolumes...)
+
 	// if the pod is being created during an upgrade operation
 	// get the corresponding upgradestrategy
 	var upgradeStrategy *versioning.UpgradeStrategy
@@ -443,7 +540,7 @@ func (r *AerospikeClusterReconciler) createPodWithIndex(aerospikeCluster *aerosp
 				return nil, err
 			}
 		} else {
-			if pvc, err = r.getPersistentVolumeClaim(aerospikeCluster, pod); err != nil {
+			if pvc, err = r.getPersistentVolumeClaim(aerospikeCluster, pod, &namespace); err != nil {
 				return nil, err
 			}
 			if pvc != nil {
@@ -630,8 +727,13 @@ func (r *AerospikeClusterReconciler) safeDeletePodWithIndex(aerospikeCluster *ae
 		// no pod with the specified index exists
 		return nil
 	}
+	// get the credentials used to access the cluster, if security is enabled
+	creds, err := r.getCredentials(aerospikeCluster)
+	if err != nil {
+		return err
+	}
 	// check whether the pod is participating in migrations
-	migrations, err := podHasMigrationsInProgress(pod)
+	migrations, err := podHasMigrationsInProgress(pod, creds)
 	if err != nil {
 		return err
 	}
@@ -674,7 +776,7 @@ func (r *AerospikeClusterReconciler) safeDeletePodWithIndex(aerospikeCluster *ae
 				}
 			}
 		}()
-		if err := waitForMigrationsToFinishOnPod(pod); err != nil {
+		if err := waitForMigrationsToFinishOnPod(pod, creds); err != nil {
 			log.WithFields(log.Fields{
 				logfields.AerospikeCluster: pod.Labels[selectors.LabelClusterKey],
 				logfields.Pod:              meta.Key(pod),
@@ -701,13 +803,13 @@ func (r *AerospikeClusterReconciler) safeDeletePodWithIndex(aerospikeCluster *ae
 	for _, p := range pods {
 		go func(p *corev1.Pod) {
 			defer wg.Done()
-			if err := tipClearHostname(p, fmt.Sprintf("%s.%s.%s", pod.Name, aerospikeCluster.Name, aerospikeCluster.Namespace)); err != nil {
+			if err := tipClearHostname(p, fmt.Sprintf("%s.%s.%s", pod.Name, aerospikeCluster.Name, aerospikeCluster.Namespace), creds); err != nil {
 				log.WithFields(log.Fields{
 					logfields.AerospikeCluster: pod.Labels[selectors.LabelClusterKey],
 					logfields.Pod:              meta.Key(pod),
 				}).Errorf("failed tip-clear ip on pod %q", meta.Key(p))
 			}
-			if err := alumniReset(p); err != nil {
+			if err := alumniReset(p, creds); err != nil {
 				log.WithFields(log.Fields{
 					logfields.AerospikeCluster: pod.Labels[selectors.LabelClusterKey],
 					logfields.Pod:              meta.Key(pod),
@@ -744,7 +846,26 @@ func (r *AerospikeClusterReconciler) computeMeshHash(aerospikeCluster *aerospike
 	return asstrings.HashSlice(addrList), nil
 }
 
+// computeImagesHash computes the hash of the images requested in the spec of
+// aerospikeCluster, which is empty when no images are requested so that pods
+// are not restarted when the defaults change.
+func computeImagesHash(aerospikeCluster *aerospikev1alpha2.AerospikeCluster) (string, error) {
+	if aerospikeCluster.Spec.Images == nil {
+		return "", nil
+	}
+	b, err := json.Marshal(aerospikeCluster.Spec.Images)
+	if err != nil {
+		return "", err
+	}
+	return asstrings.Hash(string(b)), nil
+}
+
 func (r *AerospikeClusterReconciler) ensureClusterSize(aerospikeCluster *aerospikev1alpha2.AerospikeCluster, pod *corev1.Pod) error {
+	// get the credentials used to access the cluster, if security is enabled
+	creds, err := r.getCredentials(aerospikeCluster)
+	if err != nil {
+		return err
+	}
 	timer := time.NewTimer(waitClusterSizeTimeout)
 	defer timer.Stop()
 	ticker := time.NewTicker(time.Second)
@@ -758,7 +879,7 @@ func (r *AerospikeClusterReconciler) ensureClusterSize(aerospikeCluster *aerospi
 				return err
 			}
 			// get the cluster size reported by the current node
-			clusterSize, err := asutils.GetClusterSize(pod.Status.PodIP, ServicePort)
+			clusterSize, err := asutils.GetClusterSize(pod.Status.PodIP, ServicePort, creds)
 			if err != nil {
 				return err
 			}
@@ -32,6 +32,7 @@ import (
 	"k8s.io/client-go/tools/watch"
 	podutil "k8s.io/kubernetes/pkg/api/v1/pod"
 
+	"github.com/travelaudience/aerospike-operator/pkg/asutils"
 	"github.com/travelaudience/aerospike-operator/pkg/meta"
 	"github.com/travelaudience/aerospike-operator/pkg/utils/selectors"
 )
@@ -122,8 +123,8 @@ func (r *AerospikeClusterReconciler) waitForPodCondition(pod *v1.Pod, fn watch.C
 	return nil
 }
 
-func podHasMigrationsInProgress(pod *v1.Pod) (bool, error) {
-	client, err := as.NewClient(pod.Status.PodIP, ServicePort)
+func podHasMigrationsInProgress(pod *v1.Pod, creds *asutils.Credentials) (bool, error) {
+	client, err := as.NewClientWithPolicy(asutils.NewClientPolicy(creds), pod.Status.PodIP, ServicePort)
 	if err != nil {
 		return false, err
 	}
@@ -138,8 +139,8 @@ func podHasMigrationsInProgress(pod *v1.Pod) (bool, error) {
 	return false, fmt.Errorf("failed to find node %s in the cluster", pod.Annotations[nodeIdAnnotation])
 }
 
-func waitForMigrationsToFinishOnPod(pod *v1.Pod) error {
-	client, err := as.NewClient(pod.Status.PodIP, ServicePort)
+func waitForMigrationsToFinishOnPod(pod *v1.Pod, creds *asutils.Credentials) error {
+	client, err := as.NewClientWithPolicy(asutils.NewClientPolicy(creds), pod.Status.PodIP, ServicePort)
 	if err != nil {
 		return err
 	}
@@ -154,9 +155,9 @@ func waitForMigrationsToFinishOnPod(pod *v1.Pod) error {
 	return fmt.Errorf("failed to find node %s in the cluster", pod.Annotations[nodeIdAnnotation])
 }
 
-func runInfoCommandOnPod(pod *v1.Pod, command string) (map[string]string, error) {
+func runInfoCommandOnPod(pod *v1.Pod, command string, creds *asutils.Credentials) (map[string]string, error) {
 	addr := fmt.Sprintf("%s:%d", pod.Status.PodIP, ServicePort)
-	conn, err := as.NewConnection(addr, aerospikeClientTimeout)
+	conn, err := asutils.NewConnection(addr, aerospikeClientTimeout, creds)
 	if err != nil {
 		return nil, err
 	}
@@ -164,8 +165,8 @@ func runInfoCommandOnPod(pod *v1.Pod, command string) (map[string]string, error)
 	return as.RequestInfo(conn, command)
 }
 
-func getAerospikeServerVersionFromPod(pod *v1.Pod) (string, error) {
-	res, err := runInfoCommandOnPod(pod, "build")
+func getAerospikeServerVersionFromPod(pod *v1.Pod, creds *asutils.Credentials) (string, error) {
+	res, err := runInfoCommandOnPod(pod, "build", creds)
 	if err != nil {
 		return "", err
 	}
@@ -177,12 +178,12 @@ func getAerospikeServerVersionFromPod(pod *v1.Pod) (string, error) {
 	return version, nil
 }
 
-func tipClearHostname(pod *v1.Pod, address string) error {
-	_, err := runInfoCommandOnPod(pod, fmt.Sprintf("tip-clear:host-port-list=%s:%d", address, HeartbeatPort))
+func tipClearHostname(pod *v1.Pod, address string, creds *asutils.Credentials) error {
+	_, err := runInfoCommandOnPod(pod, fmt.Sprintf("tip-clear:host-port-list=%s:%d", address, HeartbeatPort), creds)
 	return err
 }
 
-func alumniReset(pod *v1.Pod) error {
-	_, err := runInfoCommandOnPod(pod, "services-alumni-reset")
+func alumniReset(pod *v1.Pod, creds *asutils.Credentials) error {
+	_, err := runInfoCommandOnPod(pod, "services-alumni-reset", creds)
 	return err
 }
@@ -60,9 +60,9 @@ func (pvcs fromMostRecent) Less(i, j int) bool {
 	return pvcs[j].CreationTimestamp.Before(&pvcs[i].CreationTimestamp)
 }
 
-func (r *AerospikeClusterReconciler) getPersistentVolumeClaim(aerospikeCluster *aerospikev1alpha2.AerospikeCluster, pod *v1.Pod) (*v1.PersistentVolumeClaim, error) {
-	// get all the pvcs owned by the aerospikecluster
-	pvcs, err := r.pvcsLister.PersistentVolumeClaims(aerospikeCluster.Namespace).List(selectors.ResourcesByClusterName(aerospikeCluster.Name))
+func (r *AerospikeClusterReconciler) getPersistentVolumeClaim(aerospikeCluster *aerospikev1alpha2.AerospikeCluster, pod *v1.Pod, namespace *aerospikev1alpha2.AerospikeNamespaceSpec) (*v1.PersistentVolumeClaim, error) {
+	// get all the pvcs owned by the aerospikecluster for the specified namespace
+	pvcs, err := r.pvcsLister.PersistentVolumeClaims(aerospikeCluster.Namespace).List(selectors.ResourcesByNamespaceName(aerospikeCluster.Name, namespace.Name))
 	if err != nil {
 		return nil, err
 	}
@@ -113,7 +113,7 @@ func (r *AerospikeClusterReconciler) getPersistentVolumeClaim(aerospikeCluster *
 		logfields.AerospikeCluster:      meta.Key(aerospikeCluster),
 		logfields.Pod:                   meta.Key(pod),
 		logfields.PersistentVolumeClaim: podPVCs[0].Name,
-	}).Debug("using existing persistentvolumeclaim")
+	}).Debugf("using existing persistentvolumeclaim for namespace %s", namespace.Name)
 	// return the most recent pvc
 	return podPVCs[0], nil
 }
@@ -193,7 +193,7 @@ func (r *AerospikeClusterReconciler) createPersistentVolumeClaim(aerospikeCluste
 // getIndexBasedDevicePath returns the device path for the namespace
 // with the specified index (e.g. 0 --> /dev/xvda, 1 --> /dev/xvdb, ...).
 func getIndexBasedDevicePath(index int) string {
-	return fmt.Sprintf("%s%s", defaultDevicePathPrefix, string('a'+index))
+	return fmt.Sprintf("%s%s", defaultDevicePathPrefix, string(rune('a'+index)))
 }
 
 func (r *AerospikeClusterReconciler) signalMounted(pvc *v1.PersistentVolumeClaim) error {
@@ -0,0 +1,71 @@
+/*
+Copyright 2019 The aerospike-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reconciler
+
+import (
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+
+	aerospikev1alpha2 "github.com/travelaudience/aerospike-operator/pkg/apis/aerospike/v1alpha2"
+)
+
+// getRackForIndex returns the rack the pod with the specified index belongs
+// to, or nil if the racks of aerospikeCluster have not been specified. Pods
+// are assigned to racks in a round-robin fashion so that they are spread
+// evenly across racks.
+func getRackForIndex(aerospikeCluster *aerospikev1alpha2.AerospikeCluster, index int) *aerospikev1alpha2.AerospikeRackSpec {
+	if len(aerospikeCluster.Spec.Racks) == 0 {
+		return nil
+	}
+	return &aerospikeCluster.Spec.Racks[index%len(aerospikeCluster.Spec.Racks)]
+}
+
+// getRackNodeAffinity returns the node affinity that makes a pod run on the
+// kubernetes nodes of rack.
+func getRackNodeAffinity(rack *aerospikev1alpha2.AerospikeRackSpec) *corev1.NodeAffinity {
+	labels := make(map[string]string, len(rack.NodeLabels)+1)
+	for key, value := range rack.NodeLabels {
+		labels[key] = value
+	}
+	if rack.Zone != nil {
+		labels[corev1.LabelZoneFailureDomain] = *rack.Zone
+	}
+	// sort the requirements so that the pod's spec is stable
+	keys := make([]string, 0, len(labels))
+	for key := range labels {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	requirements := make([]corev1.NodeSelectorRequirement, 0, len(keys))
+	for _, key := range keys {
+		requirements = append(requirements, corev1.NodeSelectorRequirement{
+			Key:      key,
+			Operator: corev1.NodeSelectorOpIn,
+			Values:   []string{labels[key]},
+		})
+	}
+	return &corev1.NodeAffinity{
+		RequiredDuringSchedulingIgnoredDuringExecution: &corev1.NodeSelector{
+			NodeSelectorTerms: []corev1.NodeSelectorTerm{
+				{
+					MatchExpressions: requirements,
+				},
+			},
+		},
+	}
+}
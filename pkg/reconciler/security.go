@@ -0,0 +1,523 @@
+/*
+Copyright 2019 The aerospike-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reconciler
+
+import (
+	"fmt"
+
+	as "github.com/aerospike/aerospike-client-go"
+	log "github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/travelaudience/aerospike-operator/pkg/apis/aerospike/common"
+	aerospikev1alpha2 "github.com/travelaudience/aerospike-operator/pkg/apis/aerospike/v1alpha2"
+	"github.com/travelaudience/aerospike-operator/pkg/asutils"
+	"github.com/travelaudience/aerospike-operator/pkg/backuprestore"
+	"github.com/travelaudience/aerospike-operator/pkg/crd"
+	"github.com/travelaudience/aerospike-operator/pkg/logfields"
+	"github.com/travelaudience/aerospike-operator/pkg/meta"
+	"github.com/travelaudience/aerospike-operator/pkg/pointers"
+	"github.com/travelaudience/aerospike-operator/pkg/utils/events"
+	"github.com/travelaudience/aerospike-operator/pkg/utils/selectors"
+	asstrings "github.com/travelaudience/aerospike-operator/pkg/utils/strings"
+)
+
+var (
+	// adminRoles holds the roles granted to the admin user, which allow it
+	// to manage users and roles, to change the configuration and to backup
+	// and restore data.
+	adminRoles = []string{
+		common.PrivilegeUserAdmin,
+		common.PrivilegeSysAdmin,
+		common.PrivilegeDataAdmin,
+		common.PrivilegeReadWriteUDF,
+	}
+	// predefinedRoles holds the roles that are predefined by aerospike, and
+	// that are therefore never removed.
+	predefinedRoles = map[string]bool{
+		common.PrivilegeUserAdmin:    true,
+		common.PrivilegeSysAdmin:     true,
+		common.PrivilegeDataAdmin:    true,
+		common.PrivilegeRead:         true,
+		common.PrivilegeReadWrite:    true,
+		common.PrivilegeReadWriteUDF: true,
+		"write":                      true,
+	}
+	// privilegeCodes maps the codes of the privileges that can be granted by
+	// user-defined roles to the ones used by the aerospike client.
+	privilegeCodes = map[string]as.Privilege{
+		common.PrivilegeUserAdmin:    {Code: as.UserAdmin},
+		common.PrivilegeSysAdmin:     {Code: as.SysAdmin},
+		common.PrivilegeDataAdmin:    {Code: as.DataAdmin},
+		common.PrivilegeRead:         {Code: as.Read},
+		common.PrivilegeReadWrite:    {Code: as.ReadWrite},
+		common.PrivilegeReadWriteUDF: {Code: as.ReadWriteUDF},
+	}
+)
+
+// getCredentials returns the credentials of the admin user of
+// aerospikeCluster, as held by its admin secret, or nil if security is not
+// enabled.
+func (r *AerospikeClusterReconciler) getCredentials(aerospikeCluster *aerospikev1alpha2.AerospikeCluster) (*asutils.Credentials, error) {
+	if aerospikeCluster.Spec.Security == nil {
+		return nil, nil
+	}
+	secret, err := r.kubeclientset.CoreV1().Secrets(aerospikeCluster.Namespace).Get(aerospikeCluster.Spec.Security.AdminSecret, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return parseCredentials(secret)
+}
+
+// parseCredentials returns the credentials held by secret.
+func parseCredentials(secret *corev1.Secret) (*asutils.Credentials, error) {
+	user, ok := secret.Data[corev1.BasicAuthUsernameKey]
+	if !ok || len(user) == 0 {
+		return nil, fmt.Errorf("secret %s does not contain expected field %q", meta.Key(secret), corev1.BasicAuthUsernameKey)
+	}
+	password, ok := secret.Data[corev1.BasicAuthPasswordKey]
+	if !ok {
+		return nil, fmt.Errorf("secret %s does not contain expected field %q", meta.Key(secret), corev1.BasicAuthPasswordKey)
+	}
+	return &asutils.Credentials{
+		User:     string(user),
+		Password: string(password),
+	}, nil
+}
+
+// computeAdminHash computes the hash of the credentials of the admin user of
+// aerospikeCluster, so that pods are restarted when its password is changed
+// (asprom reads it on startup). It is empty when security is not enabled.
+func (r *AerospikeClusterReconciler) computeAdminHash(aerospikeCluster *aerospikev1alpha2.AerospikeCluster) (string, error) {
+	creds, err := r.getCredentials(aerospikeCluster)
+	if err != nil || creds == nil {
+		return "", err
+	}
+	return asstrings.HashSlice([]string{asstrings.Hash(creds.User), asstrings.Hash(creds.Password)}), nil
+}
+
+// appliedAdminSecretName returns the name of the secret holding the
+// credentials of the admin user as currently set in aerospikeCluster.
+func appliedAdminSecretName(aerospikeCluster *aerospikev1alpha2.AerospikeCluster) string {
+	return fmt.Sprintf("%s-%s", aerospikeCluster.Name, appliedAdminSecretSuffix)
+}
+
+// getAppliedCredentials returns the credentials of the admin user as currently
+// set in aerospikeCluster, or nil if they have not been recorded yet.
+func (r *AerospikeClusterReconciler) getAppliedCredentials(aerospikeCluster *aerospikev1alpha2.AerospikeCluster) (*asutils.Credentials, error) {
+	secret, err := r.kubeclientset.CoreV1().Secrets(aerospikeCluster.Namespace).Get(appliedAdminSecretName(aerospikeCluster), metav1.GetOptions{})
+	if err != nil {
+		if kerrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return parseCredentials(secret)
+}
+
+// setAppliedCredentials records creds as the credentials of the admin user as
+// currently set in aerospikeCluster, so that its password can be changed
+// when the admin secret is updated.
+func (r *AerospikeClusterReconciler) setAppliedCredentials(aerospikeCluster *aerospikev1alpha2.AerospikeCluster, creds *asutils.Credentials) error {
+	data := map[string][]byte{
+		corev1.BasicAuthUsernameKey: []byte(creds.User),
+		corev1.BasicAuthPasswordKey: []byte(creds.Password),
+	}
+	secrets := r.kubeclientset.CoreV1().Secrets(aerospikeCluster.Namespace)
+	secret, err := secrets.Get(appliedAdminSecretName(aerospikeCluster), metav1.GetOptions{})
+	if err != nil {
+		if !kerrors.IsNotFound(err) {
+			return err
+		}
+		_, err = secrets.Create(&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: appliedAdminSecretName(aerospikeCluster),
+				Labels: map[string]string{
+					selectors.LabelAppKey:     selectors.LabelAppVal,
+					selectors.LabelClusterKey: aerospikeCluster.Name,
+				},
+				OwnerReferences: []metav1.OwnerReference{
+					{
+						APIVersion:         aerospikev1alpha2.SchemeGroupVersion.String(),
+						Kind:               crd.AerospikeClusterKind,
+						Name:               aerospikeCluster.Name,
+						UID:                aerospikeCluster.UID,
+						Controller:         pointers.NewBool(true),
+						BlockOwnerDeletion: pointers.NewBool(true),
+					},
+				},
+			},
+			Data: data,
+			Type: corev1.SecretTypeBasicAuth,
+		})
+		return err
+	}
+	if string(secret.Data[corev1.BasicAuthUsernameKey]) == creds.User && string(secret.Data[corev1.BasicAuthPasswordKey]) == creds.Password {
+		return nil
+	}
+	secret = secret.DeepCopy()
+	secret.Data = data
+	_, err = secrets.Update(secret)
+	return err
+}
+
+// getUserPassword returns the password of the specified user of
+// aerospikeCluster, as held by its password secret.
+func (r *AerospikeClusterReconciler) getUserPassword(aerospikeCluster *aerospikev1alpha2.AerospikeCluster, user *aerospikev1alpha2.AerospikeUserSpec) (string, error) {
+	secret, err := r.kubeclientset.CoreV1().Secrets(aerospikeCluster.Namespace).Get(user.PasswordSecret, metav1.GetOptions{})
+	if err != nil {
+		return "", err
+	}
+	password, ok := secret.Data[corev1.BasicAuthPasswordKey]
+	if !ok {
+		return "", fmt.Errorf("secret %s does not contain expected field %q", meta.Key(secret), corev1.BasicAuthPasswordKey)
+	}
+	return string(password), nil
+}
+
+// ensureAdminUser makes sure that the admin user of aerospikeCluster can
+// access the cluster if security is enabled. When the admin user cannot
+// authenticate because the admin secret has been updated, its password is
+// changed using the credentials that were last applied. Otherwise (i.e. on a
+// newly created cluster), it is set up using the default admin user created
+// by aerospike, which is removed (or whose password is changed) afterwards.
+func (r *AerospikeClusterReconciler) ensureAdminUser(aerospikeCluster *aerospikev1alpha2.AerospikeCluster) error {
+	creds, err := r.getCredentials(aerospikeCluster)
+	if err != nil || creds == nil {
+		return err
+	}
+	pods, err := r.listClusterRunningPods(aerospikeCluster)
+	if err != nil || len(pods) == 0 {
+		return err
+	}
+	pod := pods[0]
+
+	// check whether the admin user can authenticate
+	conn, err := asutils.NewConnection(fmt.Sprintf("%s:%d", pod.Status.PodIP, ServicePort), aerospikeClientTimeout, creds)
+	if err == nil {
+		conn.Close()
+		return r.setAppliedCredentials(aerospikeCluster, creds)
+	}
+	if !asutils.IsAuthenticationError(err) {
+		return err
+	}
+
+	// the password in the admin secret may have been changed since it was
+	// last applied
+	applied, err := r.getAppliedCredentials(aerospikeCluster)
+	if err != nil {
+		return err
+	}
+	if applied != nil && applied.User == creds.User {
+		changed, err := r.changeAdminPassword(aerospikeCluster, pod, applied, creds)
+		if err != nil || changed {
+			return err
+		}
+	}
+
+	log.WithFields(log.Fields{
+		logfields.AerospikeCluster: meta.Key(aerospikeCluster),
+	}).Infof("setting up admin user %s", creds.User)
+
+	defaultCreds := &asutils.Credentials{
+		User:     defaultAdminUser,
+		Password: defaultAdminPassword,
+	}
+	client, err := as.NewClientWithPolicy(asutils.NewClientPolicy(defaultCreds), pod.Status.PodIP, ServicePort)
+	if err != nil {
+		return fmt.Errorf("failed to authenticate as %s or as the default admin user: %v", creds.User, err)
+	}
+	defer client.Close()
+	if creds.User == defaultAdminUser {
+		// grant the roles before changing the password, so that the admin
+		// user never authenticates without them
+		if err := client.GrantRoles(nil, defaultAdminUser, adminRoles); err != nil {
+			return err
+		}
+		if err := client.ChangePassword(nil, defaultAdminUser, creds.Password); err != nil {
+			return err
+		}
+	} else {
+		if err := client.CreateUser(nil, creds.User, creds.Password, adminRoles); err != nil {
+			return err
+		}
+		// the password of the default admin user is well-known
+		if err := client.DropUser(nil, defaultAdminUser); err != nil {
+			return err
+		}
+	}
+
+	r.recorder.Eventf(aerospikeCluster, corev1.EventTypeNormal, events.ReasonSecurityUpdated,
+		"set up admin user %s", creds.User)
+	return r.setAppliedCredentials(aerospikeCluster, creds)
+}
+
+// changeAdminPassword changes the password of the admin user of
+// aerospikeCluster to the one in creds, authenticating with the credentials
+// that were last applied. It returns false if these no longer allow the admin
+// user to authenticate.
+func (r *AerospikeClusterReconciler) changeAdminPassword(aerospikeCluster *aerospikev1alpha2.AerospikeCluster, pod *corev1.Pod, applied, creds *asutils.Credentials) (bool, error) {
+	client, err := as.NewClientWithPolicy(asutils.NewClientPolicy(applied), pod.Status.PodIP, ServicePort)
+	if err != nil {
+		if asutils.IsAuthenticationError(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	defer client.Close()
+
+	log.WithFields(log.Fields{
+		logfields.AerospikeCluster: meta.Key(aerospikeCluster),
+	}).Infof("changing the password of admin user %s", creds.User)
+
+	if err := client.ChangePassword(nil, creds.User, creds.Password); err != nil {
+		return false, err
+	}
+	r.recorder.Eventf(aerospikeCluster, corev1.EventTypeNormal, events.ReasonSecurityUpdated,
+		"changed the password of admin user %s", creds.User)
+	return true, r.setAppliedCredentials(aerospikeCluster, creds)
+}
+
+// ensureUsersAndRoles makes sure that the users and the user-defined roles of
+// aerospikeCluster match the ones in its spec if security is enabled, creating,
+// updating and removing them as required. The admin user is left untouched.
+func (r *AerospikeClusterReconciler) ensureUsersAndRoles(aerospikeCluster *aerospikev1alpha2.AerospikeCluster) error {
+	creds, err := r.getCredentials(aerospikeCluster)
+	if err != nil || creds == nil {
+		return err
+	}
+	pods, err := r.listClusterRunningPods(aerospikeCluster)
+	if err != nil || len(pods) == 0 {
+		return err
+	}
+	client, err := as.NewClientWithPolicy(asutils.NewClientPolicy(creds), pods[0].Status.PodIP, ServicePort)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	// roles must exist before being granted to users
+	if err := r.ensureRoles(aerospikeCluster, client); err != nil {
+		return err
+	}
+	return r.ensureUsers(aerospikeCluster, client, creds, pods[0])
+}
+
+// ensureRoles makes sure that the user-defined roles of aerospikeCluster match
+// the ones in its spec.
+func (r *AerospikeClusterReconciler) ensureRoles(aerospikeCluster *aerospikev1alpha2.AerospikeCluster, client *as.Client) error {
+	roles, err := client.QueryRoles(nil)
+	if err != nil {
+		return err
+	}
+	current := make(map[string]*as.Role, len(roles))
+	for _, role := range roles {
+		if !predefinedRoles[role.Name] {
+			current[role.Name] = role
+		}
+	}
+
+	for _, role := range aerospikeCluster.Spec.Security.Roles {
+		desired := getPrivileges(&role)
+		existing, ok := current[role.Name]
+		delete(current, role.Name)
+		if !ok {
+			if err := client.CreateRole(nil, role.Name, desired); err != nil {
+				return err
+			}
+			r.recordSecurityUpdate(aerospikeCluster, "created role %s", role.Name)
+			continue
+		}
+		grant := privilegesDiff(desired, existing.Privileges)
+		revoke := privilegesDiff(existing.Privileges, desired)
+		if len(grant) > 0 {
+			if err := client.GrantPrivileges(nil, role.Name, grant); err != nil {
+				return err
+			}
+		}
+		if len(revoke) > 0 {
+			if err := client.RevokePrivileges(nil, role.Name, revoke); err != nil {
+				return err
+			}
+		}
+		if len(grant) > 0 || len(revoke) > 0 {
+			r.recordSecurityUpdate(aerospikeCluster, "updated the privileges of role %s", role.Name)
+		}
+	}
+
+	// remove the user-defined roles no longer in the spec
+	for name := range current {
+		if err := client.DropRole(nil, name); err != nil {
+			return err
+		}
+		r.recordSecurityUpdate(aerospikeCluster, "removed role %s", name)
+	}
+	return nil
+}
+
+// ensureUsers makes sure that the users of aerospikeCluster other than the
+// admin user (whose credentials are creds) match the ones in its spec. pod is
+// the pod against which the passwords of existing users are checked.
+func (r *AerospikeClusterReconciler) ensureUsers(aerospikeCluster *aerospikev1alpha2.AerospikeCluster, client *as.Client, creds *asutils.Credentials, pod *corev1.Pod) error {
+	users, err := client.QueryUsers(nil)
+	if err != nil {
+		return err
+	}
+	current := make(map[string]*as.UserRoles, len(users))
+	for _, user := range users {
+		if user.User != creds.User {
+			current[user.User] = user
+		}
+	}
+
+	for _, user := range aerospikeCluster.Spec.Security.Users {
+		if user.Name == creds.User {
+			log.WithFields(log.Fields{
+				logfields.AerospikeCluster: meta.Key(aerospikeCluster),
+			}).Warnf("ignoring user %s, which is the admin user", user.Name)
+			continue
+		}
+		password, err := r.getUserPassword(aerospikeCluster, &user)
+		if err != nil {
+			return err
+		}
+		existing, ok := current[user.Name]
+		delete(current, user.Name)
+		if !ok {
+			if err := client.CreateUser(nil, user.Name, password, user.Roles); err != nil {
+				return err
+			}
+			r.recordSecurityUpdate(aerospikeCluster, "created user %s", user.Name)
+			continue
+		}
+		grant := stringsDiff(user.Roles, existing.Roles)
+		revoke := stringsDiff(existing.Roles, user.Roles)
+		if len(grant) > 0 {
+			if err := client.GrantRoles(nil, user.Name, grant); err != nil {
+				return err
+			}
+		}
+		if len(revoke) > 0 {
+			if err := client.RevokeRoles(nil, user.Name, revoke); err != nil {
+				return err
+			}
+		}
+		if len(grant) > 0 || len(revoke) > 0 {
+			r.recordSecurityUpdate(aerospikeCluster, "updated the roles of user %s", user.Name)
+		}
+		// the password of a user cannot be read, so we check whether the user
+		// can authenticate with the one in its secret instead
+		conn, err := asutils.NewConnection(fmt.Sprintf("%s:%d", pod.Status.PodIP, ServicePort), aerospikeClientTimeout, &asutils.Credentials{
+			User:     user.Name,
+			Password: password,
+		})
+		if err == nil {
+			conn.Close()
+			continue
+		}
+		if !asutils.IsAuthenticationError(err) {
+			return err
+		}
+		if err := client.ChangePassword(nil, user.Name, password); err != nil {
+			return err
+		}
+		r.recordSecurityUpdate(aerospikeCluster, "changed the password of user %s", user.Name)
+	}
+
+	// remove the users no longer in the spec
+	for name := range current {
+		if err := client.DropUser(nil, name); err != nil {
+			return err
+		}
+		r.recordSecurityUpdate(aerospikeCluster, "removed user %s", name)
+	}
+	return nil
+}
+
+// recordSecurityUpdate logs and records an event describing a change to the
+// users or roles of aerospikeCluster.
+func (r *AerospikeClusterReconciler) recordSecurityUpdate(aerospikeCluster *aerospikev1alpha2.AerospikeCluster, format string, args ...interface{}) {
+	log.WithFields(log.Fields{
+		logfields.AerospikeCluster: meta.Key(aerospikeCluster),
+	}).Infof(format, args...)
+	r.recorder.Eventf(aerospikeCluster, corev1.EventTypeNormal, events.ReasonSecurityUpdated, format, args...)
+}
+
+// getPrivileges returns the privileges granted by role.
+func getPrivileges(role *aerospikev1alpha2.AerospikeRoleSpec) []as.Privilege {
+	res := make([]as.Privilege, 0, len(role.Privileges))
+	for _, privilege := range role.Privileges {
+		p := privilegeCodes[privilege.Code]
+		if privilege.Namespace != nil {
+			p.Namespace = *privilege.Namespace
+		}
+		if privilege.Set != nil {
+			p.SetName = *privilege.Set
+		}
+		res = append(res, p)
+	}
+	return res
+}
+
+// privilegesDiff returns the privileges in a that are not in b.
+func privilegesDiff(a, b []as.Privilege) []as.Privilege {
+	in := make(map[as.Privilege]bool, len(b))
+	for _, p := range b {
+		in[p] = true
+	}
+	res := make([]as.Privilege, 0)
+	for _, p := range a {
+		if !in[p] {
+			res = append(res, p)
+		}
+	}
+	return res
+}
+
+// stringsDiff returns the strings in a that are not in b.
+func stringsDiff(a, b []string) []string {
+	in := make(map[string]bool, len(b))
+	for _, s := range b {
+		in[s] = true
+	}
+	res := make([]string, 0)
+	for _, s := range a {
+		if !in[s] {
+			res = append(res, s)
+		}
+	}
+	return res
+}
+
+// aspromArgs returns the command that runs asprom in the pods of
+// aerospikeCluster. If security is enabled, asprom authenticates with the
+// credentials of the admin user, held by the environment variables returned by
+// backuprestore.CredentialsEnv.
+func aspromArgs(aerospikeCluster *aerospikev1alpha2.AerospikeCluster) []string {
+	if aerospikeCluster.Spec.Security == nil {
+		return []string{"asprom"}
+	}
+	return []string{
+		"asprom",
+		"-username",
+		fmt.Sprintf("$(%s)", backuprestore.UserEnvVar),
+		"-password",
+		fmt.Sprintf("$(%s)", backuprestore.PasswordEnvVar),
+	}
+}
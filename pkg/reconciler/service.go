@@ -32,6 +32,7 @@ import (
 )
 
 func (r *AerospikeClusterReconciler) ensureService(aerospikeCluster *aerospikev1alpha2.AerospikeCluster) error {
+	tls := getTLSSpec(aerospikeCluster)
 	service := &v1.Service{
 		ObjectMeta: metav1.ObjectMeta{
 			Name: aerospikeCluster.Name,
@@ -77,6 +78,22 @@ func (r *AerospikeClusterReconciler) ensureService(aerospikeCluster *aerospikev1
 		},
 	}
 
+	// expose the tls ports of the listeners that use tls
+	if tls.Service != nil {
+		service.Spec.Ports = append(service.Spec.Ports, v1.ServicePort{
+			Name:       serviceTLSPortName,
+			Port:       serviceTLSPort,
+			TargetPort: intstr.IntOrString{StrVal: serviceTLSPortName},
+		})
+	}
+	if tls.Heartbeat != nil {
+		service.Spec.Ports = append(service.Spec.Ports, v1.ServicePort{
+			Name:       heartbeatTLSPortName,
+			Port:       HeartbeatTLSPort,
+			TargetPort: intstr.IntOrString{StrVal: heartbeatTLSPortName},
+		})
+	}
+
 	if _, err := r.kubeclientset.CoreV1().Services(aerospikeCluster.Namespace).Create(service); err != nil {
 		if !errors.IsAlreadyExists(err) {
 			return err
@@ -21,24 +21,50 @@ import (
 	"reflect"
 
 	log "github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apiextensions-apiserver/pkg/apis/apiextensions"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/strategicpatch"
 
 	aerospikev1alpha2 "github.com/travelaudience/aerospike-operator/pkg/apis/aerospike/v1alpha2"
 	"github.com/travelaudience/aerospike-operator/pkg/logfields"
 	"github.com/travelaudience/aerospike-operator/pkg/meta"
+	"github.com/travelaudience/aerospike-operator/pkg/utils/selectors"
 )
 
-// updateStatus updates the status of aerospikeCluster to match the spec.
+// updateStatus updates the status of aerospikeCluster to match the spec, and
+// reports the configuration applied to its pods.
 // IMPORTANT this method MUST only be called after a successful reconcile
-func (r *AerospikeClusterReconciler) updateStatus(aerospikeCluster *aerospikev1alpha2.AerospikeCluster) {
+func (r *AerospikeClusterReconciler) updateStatus(aerospikeCluster *aerospikev1alpha2.AerospikeCluster, configMap *corev1.ConfigMap) error {
 	// update status to match the spec - the correctness of this is ensured by
 	// the reconcile loop
 	aerospikeCluster.Status.BackupSpec = aerospikeCluster.Spec.BackupSpec
 	aerospikeCluster.Status.Namespaces = aerospikeCluster.Spec.Namespaces
 	aerospikeCluster.Status.NodeCount = aerospikeCluster.Spec.NodeCount
 	aerospikeCluster.Status.Version = aerospikeCluster.Spec.Version
+	aerospikeCluster.Status.UpgradePolicy = aerospikeCluster.Spec.UpgradePolicy
+	aerospikeCluster.Status.Images = aerospikeCluster.Spec.Images
+	aerospikeCluster.Status.Config = aerospikeCluster.Spec.Config
+	aerospikeCluster.Status.Racks = aerospikeCluster.Spec.Racks
+	aerospikeCluster.Status.Security = aerospikeCluster.Spec.Security
+	aerospikeCluster.Status.TLS = aerospikeCluster.Spec.TLS
+	aerospikeCluster.Status.FeatureKeySecret = aerospikeCluster.Spec.FeatureKeySecret
+
+	// read the pods from the api rather than from the cache, as their
+	// annotations have just been updated when applying configuration changes
+	pods, err := r.kubeclientset.CoreV1().Pods(aerospikeCluster.Namespace).List(metav1.ListOptions{
+		LabelSelector: selectors.ResourcesByClusterName(aerospikeCluster.Name).String(),
+	})
+	if err != nil {
+		return err
+	}
+	podPtrs := make([]*corev1.Pod, 0, len(pods.Items))
+	for i := range pods.Items {
+		podPtrs = append(podPtrs, &pods.Items[i])
+	}
+	aerospikeCluster.Status.AppliedConfig = getAppliedConfig(aerospikeCluster, configMap, podPtrs)
+	return nil
 }
 
 // patchCluster updates the aerospikecluster resource.
@@ -0,0 +1,176 @@
+/*
+Copyright 2019 The aerospike-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reconciler
+
+import (
+	"fmt"
+	"path"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/travelaudience/aerospike-operator/pkg/apis/aerospike/common"
+	aerospikev1alpha2 "github.com/travelaudience/aerospike-operator/pkg/apis/aerospike/v1alpha2"
+	asstrings "github.com/travelaudience/aerospike-operator/pkg/utils/strings"
+)
+
+// getTLSSpec returns the tls configuration of aerospikeCluster, which is empty
+// (i.e. no listener uses tls) if it has not been specified.
+func getTLSSpec(aerospikeCluster *aerospikev1alpha2.AerospikeCluster) *aerospikev1alpha2.AerospikeClusterTLSSpec {
+	if aerospikeCluster.Spec.TLS == nil {
+		return &aerospikev1alpha2.AerospikeClusterTLSSpec{}
+	}
+	return aerospikeCluster.Spec.TLS
+}
+
+// tlsListeners returns the tls configuration of the service, fabric and
+// heartbeat listeners of aerospikeCluster (in this order), which is nil for
+// the listeners that do not use tls.
+func tlsListeners(aerospikeCluster *aerospikev1alpha2.AerospikeCluster) []*aerospikev1alpha2.AerospikeTLSListenerSpec {
+	tls := getTLSSpec(aerospikeCluster)
+	return []*aerospikev1alpha2.AerospikeTLSListenerSpec{tls.Service, tls.Fabric, tls.Heartbeat}
+}
+
+// getTLSSecretNames returns the names of the tls secrets used by
+// aerospikeCluster, without duplicates.
+func getTLSSecretNames(aerospikeCluster *aerospikev1alpha2.AerospikeCluster) []string {
+	names := make([]string, 0)
+	seen := make(map[string]bool)
+	for _, listener := range tlsListeners(aerospikeCluster) {
+		if listener != nil && !seen[listener.SecretName] {
+			names = append(names, listener.SecretName)
+			seen[listener.SecretName] = true
+		}
+	}
+	return names
+}
+
+// getTLSName returns the tls-name of listener, or an empty string if the
+// listener does not use tls.
+func getTLSName(listener *aerospikev1alpha2.AerospikeTLSListenerSpec) string {
+	if listener == nil {
+		return ""
+	}
+	return listener.TLSName
+}
+
+// getTLSContexts returns the properties of the tls contexts of the aerospike
+// configuration of aerospikeCluster (used for templating). There is one
+// context per tls-name, the listeners sharing a tls-name being required to
+// use the same secret by the admission webhook.
+func getTLSContexts(aerospikeCluster *aerospikev1alpha2.AerospikeCluster) []map[string]string {
+	contexts := make([]map[string]string, 0)
+	indexes := make(map[string]int)
+	for i, listener := range tlsListeners(aerospikeCluster) {
+		if listener == nil {
+			continue
+		}
+		mountPath := path.Join(tlsMountPathPrefix, listener.SecretName)
+		index, ok := indexes[listener.TLSName]
+		if !ok {
+			contexts = append(contexts, map[string]string{
+				tlsNameKey:     listener.TLSName,
+				tlsCertFileKey: path.Join(mountPath, corev1.TLSCertKey),
+				tlsKeyFileKey:  path.Join(mountPath, corev1.TLSPrivateKeyKey),
+			})
+			index = len(contexts) - 1
+			indexes[listener.TLSName] = index
+		}
+		// the fabric and heartbeat listeners verify the certificates of
+		// other nodes, which requires the ca certificate
+		if i > 0 {
+			contexts[index][tlsCAFileKey] = path.Join(mountPath, common.TLSCACertFilename)
+		}
+	}
+	return contexts
+}
+
+// getTLSContainerPorts returns the ports of the aerospike-server container on
+// which the listeners of aerospikeCluster that use tls accept connections.
+func getTLSContainerPorts(aerospikeCluster *aerospikev1alpha2.AerospikeCluster) []corev1.ContainerPort {
+	tls := getTLSSpec(aerospikeCluster)
+	var ports []corev1.ContainerPort
+	if tls.Service != nil {
+		ports = append(ports, corev1.ContainerPort{
+			Name:          serviceTLSPortName,
+			ContainerPort: serviceTLSPort,
+		})
+	}
+	if tls.Heartbeat != nil {
+		ports = append(ports, corev1.ContainerPort{
+			Name:          heartbeatTLSPortName,
+			ContainerPort: HeartbeatTLSPort,
+		})
+	}
+	if tls.Fabric != nil {
+		ports = append(ports, corev1.ContainerPort{
+			Name:          fabricTLSPortName,
+			ContainerPort: fabricTLSPort,
+		})
+	}
+	return ports
+}
+
+// getTLSVolumes returns the volumes holding the tls secrets used by
+// aerospikeCluster, and the corresponding volume mounts for the
+// aerospike-server container.
+func getTLSVolumes(aerospikeCluster *aerospikev1alpha2.AerospikeCluster) ([]corev1.Volume, []corev1.VolumeMount) {
+	var (
+		volumes []corev1.Volume
+		mounts  []corev1.VolumeMount
+	)
+	for i, secretName := range getTLSSecretNames(aerospikeCluster) {
+		// secret names may be longer than volume names, hence the index
+		volumeName := fmt.Sprintf("%s-%d", tlsVolumePrefix, i)
+		volumes = append(volumes, corev1.Volume{
+			Name: volumeName,
+			VolumeSource: corev1.VolumeSource{
+				Secret: &corev1.SecretVolumeSource{
+					SecretName: secretName,
+				},
+			},
+		})
+		mounts = append(mounts, corev1.VolumeMount{
+			Name:      volumeName,
+			MountPath: path.Join(tlsMountPathPrefix, secretName),
+			ReadOnly:  true,
+		})
+	}
+	return volumes, mounts
+}
+
+// computeTLSHash computes the hash of the contents of the tls secrets used by
+// aerospikeCluster, so that pods are restarted when certificates are rotated.
+// It is empty when tls is not enabled so that existing pods are not
+// restarted.
+func (r *AerospikeClusterReconciler) computeTLSHash(aerospikeCluster *aerospikev1alpha2.AerospikeCluster) (string, error) {
+	secretNames := getTLSSecretNames(aerospikeCluster)
+	if len(secretNames) == 0 {
+		return "", nil
+	}
+	data := make([]string, 0, len(secretNames)*3)
+	for _, secretName := range secretNames {
+		secret, err := r.kubeclientset.CoreV1().Secrets(aerospikeCluster.Namespace).Get(secretName, metav1.GetOptions{})
+		if err != nil {
+			return "", err
+		}
+		for _, key := range []string{corev1.TLSCertKey, corev1.TLSPrivateKeyKey, common.TLSCACertFilename} {
+			data = append(data, asstrings.Hash(string(secret.Data[key])))
+		}
+	}
+	return asstrings.HashSlice(data), nil
+}
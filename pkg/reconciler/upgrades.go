@@ -18,6 +18,7 @@ package reconciler
 
 import (
 	"fmt"
+	"strings"
 	"time"
 
 	log "github.com/sirupsen/logrus"
@@ -44,8 +45,13 @@ func (r *AerospikeClusterReconciler) maybeUpgradePodWithIndex(aerospikeCluster *
 		// no pod with the specified index exists, so we return
 		return nil, nil
 	}
+	// get the credentials used to access the cluster, if security is enabled
+	creds, err := r.getCredentials(aerospikeCluster)
+	if err != nil {
+		return nil, err
+	}
 	// get the version of aerospike server running on the pod
-	version, err := getAerospikeServerVersionFromPod(pod)
+	version, err := getAerospikeServerVersionFromPod(pod, creds)
 	if err != nil {
 		return nil, err
 	}
@@ -67,7 +73,7 @@ func (r *AerospikeClusterReconciler) maybeUpgradePodWithIndex(aerospikeCluster *
 		return nil, err
 	}
 	// ensure the pod has the target version
-	version, err = getAerospikeServerVersionFromPod(newPod)
+	version, err = getAerospikeServerVersionFromPod(newPod, creds)
 	if err != nil {
 		return nil, err
 	}
@@ -170,16 +176,64 @@ func (r *AerospikeClusterReconciler) signalBackupFailed(aerospikeCluster *aerosp
 	return aerospikeCluster, nil
 }
 
-func (r *AerospikeClusterReconciler) signalUpgradeStarted(aerospikeCluster *aerospikev1alpha2.AerospikeCluster, upgrade *versioning.VersionUpgrade) (*aerospikev1alpha2.AerospikeCluster, error) {
+// retryUpgrade prepares for retrying the failed upgrade of aerospikeCluster,
+// deleting any failed pre-upgrade backups and removing the annotations marking
+// the upgrade as failed once they are gone. The upgrade is then started over,
+// keeping the pre-upgrade backups that have finished and skipping the pods
+// that have already been upgraded.
+func (r *AerospikeClusterReconciler) retryUpgrade(aerospikeCluster *aerospikev1alpha2.AerospikeCluster) (*aerospikev1alpha2.AerospikeCluster, error) {
+	deleted, err := r.deleteFailedBackups(aerospikeCluster)
+	if err != nil {
+		return nil, err
+	}
+	if !deleted {
+		// keep the annotations so that the upgrade is retried once the
+		// deletion has been observed
+		return nil, fmt.Errorf("waiting for the failed pre-upgrade backups to be deleted")
+	}
+
+	// grab a copy of aerospikeCluster in its current state so we can later
+	// create a patch
+	oldCluster := aerospikeCluster.DeepCopy()
+
+	message := fmt.Sprintf("retrying upgrade from version %s to %s", aerospikeCluster.Status.Version, aerospikeCluster.Spec.Version)
+	appendCondition(aerospikeCluster, apiextensions.CustomResourceDefinitionCondition{
+		Type:               common.ConditionUpgradeRetried,
+		Status:             apiextensions.ConditionTrue,
+		Reason:             events.ReasonClusterUpgradeRetried,
+		Message:            message,
+		LastTransitionTime: metav1.NewTime(time.Now()),
+	})
+	removeAerospikeClusterAnnotation(aerospikeCluster, UpgradeStatusAnnotationKey)
+	removeAerospikeClusterAnnotation(aerospikeCluster, UpgradeRetryAnnotationKey)
+
+	if err := r.patchCluster(oldCluster, aerospikeCluster); err != nil {
+		return nil, err
+	}
+
+	r.recorder.Event(aerospikeCluster, v1.EventTypeNormal, events.ReasonClusterUpgradeRetried, message)
+
+	log.WithFields(log.Fields{
+		logfields.AerospikeCluster: meta.Key(aerospikeCluster),
+	}).Debug(message)
+
+	return aerospikeCluster, nil
+}
+
+// signalUpgradeStarted signals that the upgrade has started, recording the
+// backup policy that was followed. reusedBackups holds the names of the
+// backups reused instead of backing up the cluster, if any.
+func (r *AerospikeClusterReconciler) signalUpgradeStarted(aerospikeCluster *aerospikev1alpha2.AerospikeCluster, upgrade *versioning.VersionUpgrade, reusedBackups []string) (*aerospikev1alpha2.AerospikeCluster, error) {
 	// grab a copy of aerospikeCluster in its current state so we can later
 	// create a patch
 	oldCluster := aerospikeCluster.DeepCopy()
 
+	message := upgradeStartedMessage(aerospikeCluster, upgrade, reusedBackups)
 	appendCondition(aerospikeCluster, apiextensions.CustomResourceDefinitionCondition{
 		Type:               common.ConditionUpgradeStarted,
 		Status:             apiextensions.ConditionTrue,
 		Reason:             events.ReasonClusterUpgradeStarted,
-		Message:            fmt.Sprintf("upgrade from version %s to %s started", upgrade.Source, upgrade.Target),
+		Message:            message,
 		LastTransitionTime: metav1.NewTime(time.Now()),
 	})
 	setAerospikeClusterAnnotation(aerospikeCluster, UpgradeStatusAnnotationKey, UpgradeStatusStartedAnnotationValue)
@@ -188,16 +242,33 @@ func (r *AerospikeClusterReconciler) signalUpgradeStarted(aerospikeCluster *aero
 		return nil, err
 	}
 
-	r.recorder.Eventf(aerospikeCluster, v1.EventTypeNormal, events.ReasonClusterUpgradeStarted,
-		"upgrade from version %s to %s started", upgrade.Source, upgrade.Target)
+	r.recorder.Event(aerospikeCluster, v1.EventTypeNormal, events.ReasonClusterUpgradeStarted, message)
 
 	log.WithFields(log.Fields{
 		logfields.AerospikeCluster: meta.Key(aerospikeCluster),
-	}).Debugf("upgrade from version %s to %s started", upgrade.Source, upgrade.Target)
+	}).Debug(message)
 
 	return aerospikeCluster, nil
 }
 
+// upgradeStartedMessage returns the message describing the start of the
+// upgrade, including the backup policy that was followed and the backups
+// made or reused before upgrading.
+func upgradeStartedMessage(aerospikeCluster *aerospikev1alpha2.AerospikeCluster, upgrade *versioning.VersionUpgrade, reusedBackups []string) string {
+	message := fmt.Sprintf("upgrade from version %s to %s started (backup policy: %s", upgrade.Source, upgrade.Target, aerospikeCluster.Spec.GetBackupPolicy())
+	switch {
+	case reusedBackups != nil:
+		message += fmt.Sprintf(", reused backups: %s", strings.Join(reusedBackups, ", "))
+	case aerospikeCluster.Spec.GetBackupPolicy() != common.UpgradeBackupPolicySkip:
+		backups := make([]string, 0, len(aerospikeCluster.Spec.Namespaces))
+		for _, namespace := range aerospikeCluster.Spec.Namespaces {
+			backups = append(backups, GetBackupName(namespace.Name, aerospikeCluster.Status.Version, aerospikeCluster.Spec.Version))
+		}
+		message += fmt.Sprintf(", pre-upgrade backups: %s", strings.Join(backups, ", "))
+	}
+	return message + ")"
+}
+
 func (r *AerospikeClusterReconciler) signalUpgradeFailed(aerospikeCluster *aerospikev1alpha2.AerospikeCluster, upgrade *versioning.VersionUpgrade) (*aerospikev1alpha2.AerospikeCluster, error) {
 	// grab a copy of aerospikeCluster in its current state so we can later
 	// create a patch
@@ -0,0 +1,247 @@
+/*
+Copyright 2019 The aerospike-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cron
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// maxYears is the number of years after which Next gives up looking for a
+	// matching time (e.g. for "0 0 30 2 *").
+	maxYears = 5
+)
+
+// field describes the range of values accepted by a field of a cron
+// expression.
+type field struct {
+	name  string
+	min   int
+	max   int
+	names map[string]int
+}
+
+var (
+	minuteField = field{name: "minute", min: 0, max: 59}
+	hourField   = field{name: "hour", min: 0, max: 23}
+	domField    = field{name: "day of month", min: 1, max: 31}
+	monthField  = field{name: "month", min: 1, max: 12, names: map[string]int{
+		"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+		"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+	}}
+	// 7 is accepted as an alias for sunday and folded into 0 after parsing.
+	dowField = field{name: "day of week", min: 0, max: 7, names: map[string]int{
+		"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+	}}
+
+	// aliases maps the supported predefined schedules to the cron expression
+	// they stand for.
+	aliases = map[string]string{
+		"@yearly":   "0 0 1 1 *",
+		"@annually": "0 0 1 1 *",
+		"@monthly":  "0 0 1 * *",
+		"@weekly":   "0 0 * * 0",
+		"@daily":    "0 0 * * *",
+		"@midnight": "0 0 * * *",
+		"@hourly":   "0 * * * *",
+	}
+)
+
+// Schedule represents a parsed cron expression. Schedules are evaluated in
+// UTC.
+type Schedule struct {
+	minute uint64
+	hour   uint64
+	dom    uint64
+	month  uint64
+	dow    uint64
+	// domStar and dowStar indicate whether the day of month and day of week
+	// fields are unrestricted ("*"). when both fields are restricted a day
+	// matches if either of them matches, as in most cron implementations.
+	domStar bool
+	dowStar bool
+}
+
+// Parse parses a standard five-field cron expression (minute, hour, day of
+// month, month and day of week), or one of the @yearly, @annually, @monthly,
+// @weekly, @daily, @midnight and @hourly aliases.
+func Parse(spec string) (*Schedule, error) {
+	spec = strings.TrimSpace(spec)
+	if strings.HasPrefix(spec, "@") {
+		expr, ok := aliases[strings.ToLower(spec)]
+		if !ok {
+			return nil, fmt.Errorf("unsupported cron alias %q", spec)
+		}
+		spec = expr
+	}
+
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("expected 5 fields in cron expression %q, found %d", spec, len(fields))
+	}
+
+	var (
+		s   Schedule
+		err error
+	)
+	if s.minute, _, err = minuteField.parse(fields[0]); err != nil {
+		return nil, err
+	}
+	if s.hour, _, err = hourField.parse(fields[1]); err != nil {
+		return nil, err
+	}
+	if s.dom, s.domStar, err = domField.parse(fields[2]); err != nil {
+		return nil, err
+	}
+	if s.month, _, err = monthField.parse(fields[3]); err != nil {
+		return nil, err
+	}
+	if s.dow, s.dowStar, err = dowField.parse(fields[4]); err != nil {
+		return nil, err
+	}
+	// fold sunday-as-7 into sunday-as-0
+	if s.dow&(1<<7) != 0 {
+		s.dow = s.dow&^(1<<7) | 1
+	}
+	return &s, nil
+}
+
+// Next returns the first time matching the schedule that is strictly after t,
+// or the zero time if no such time exists in the next few years.
+func (s *Schedule) Next(t time.Time) time.Time {
+	t = t.UTC().Truncate(time.Minute).Add(time.Minute)
+	limit := t.Year() + maxYears
+
+	for t.Year() <= limit {
+		if !has(s.month, int(t.Month())) {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+			continue
+		}
+		if !s.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, time.UTC)
+			continue
+		}
+		if !has(s.hour, t.Hour()) {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, time.UTC)
+			continue
+		}
+		if !has(s.minute, t.Minute()) {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+// dayMatches returns whether the day of t matches the day of month and day of
+// week fields of the schedule.
+func (s *Schedule) dayMatches(t time.Time) bool {
+	domMatch := has(s.dom, t.Day())
+	dowMatch := has(s.dow, int(t.Weekday()))
+	if s.domStar || s.dowStar {
+		return domMatch && dowMatch
+	}
+	return domMatch || dowMatch
+}
+
+// parse parses a comma-separated list of values, ranges and steps, returning
+// the set of matching values as a bitmask and whether the field is
+// unrestricted.
+func (f field) parse(expr string) (uint64, bool, error) {
+	var bits uint64
+	for _, part := range strings.Split(expr, ",") {
+		b, err := f.parsePart(part)
+		if err != nil {
+			return 0, false, err
+		}
+		bits |= b
+	}
+	return bits, expr == "*", nil
+}
+
+// parsePart parses a single element of a list (e.g. "*", "5", "1-5", "*/15",
+// "10-50/10" or "mon-fri").
+func (f field) parsePart(part string) (uint64, error) {
+	rangeExpr, step := part, 1
+	if i := strings.Index(part, "/"); i >= 0 {
+		v, err := strconv.Atoi(part[i+1:])
+		if err != nil || v <= 0 {
+			return 0, fmt.Errorf("invalid step %q in %s field", part[i+1:], f.name)
+		}
+		rangeExpr, step = part[:i], v
+	}
+
+	var (
+		low, high int
+		err       error
+	)
+	switch {
+	case rangeExpr == "*":
+		low, high = f.min, f.max
+	case strings.Contains(rangeExpr, "-"):
+		bounds := strings.SplitN(rangeExpr, "-", 2)
+		if low, err = f.value(bounds[0]); err != nil {
+			return 0, err
+		}
+		if high, err = f.value(bounds[1]); err != nil {
+			return 0, err
+		}
+		if low > high {
+			return 0, fmt.Errorf("invalid range %q in %s field", rangeExpr, f.name)
+		}
+	default:
+		if low, err = f.value(rangeExpr); err != nil {
+			return 0, err
+		}
+		high = low
+		// a single value followed by a step (e.g. "5/15") means "starting at"
+		if step > 1 {
+			high = f.max
+		}
+	}
+
+	var bits uint64
+	for v := low; v <= high; v += step {
+		bits |= 1 << uint(v)
+	}
+	return bits, nil
+}
+
+// value parses a single numeric or named value, making sure it lies within
+// the accepted range.
+func (f field) value(s string) (int, error) {
+	if v, ok := f.names[strings.ToLower(s)]; ok {
+		return v, nil
+	}
+	v, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value %q in %s field", s, f.name)
+	}
+	if v < f.min || v > f.max {
+		return 0, fmt.Errorf("value %d out of range [%d, %d] in %s field", v, f.min, f.max, f.name)
+	}
+	return v, nil
+}
+
+// has returns whether bit v is set in bits.
+func has(bits uint64, v int) bool {
+	return bits&(1<<uint(v)) != 0
+}
@@ -0,0 +1,97 @@
+/*
+Copyright 2019 The aerospike-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cron
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		spec  string
+		valid bool
+	}{
+		{"* * * * *", true},
+		{"0 3 * * *", true},
+		{"*/15 0-6,22-23 1,15 jan-mar,DEC mon-fri", true},
+		{"5/10 * * * 7", true},
+		{"@daily", true},
+		{"@Weekly", true},
+		{"@every 1h", false},
+		{"", false},
+		{"* * * *", false},
+		{"* * * * * *", false},
+		{"60 * * * *", false},
+		{"* 24 * * *", false},
+		{"* * 0 * *", false},
+		{"* * * 13 *", false},
+		{"* * * * 8", false},
+		{"5-1 * * * *", false},
+		{"*/0 * * * *", false},
+		{"a * * * *", false},
+		{"* * * foo *", false},
+	}
+	for _, test := range tests {
+		_, err := Parse(test.spec)
+		if test.valid {
+			assert.NoError(t, err, test.spec)
+		} else {
+			assert.Error(t, err, test.spec)
+		}
+	}
+}
+
+func TestNext(t *testing.T) {
+	tests := []struct {
+		spec     string
+		from     string
+		expected string
+	}{
+		{"* * * * *", "2019-01-01T00:00:00Z", "2019-01-01T00:01:00Z"},
+		{"* * * * *", "2019-01-01T00:00:30Z", "2019-01-01T00:01:00Z"},
+		{"0 3 * * *", "2019-01-01T03:00:00Z", "2019-01-02T03:00:00Z"},
+		{"0 3 * * *", "2019-01-01T02:59:59Z", "2019-01-01T03:00:00Z"},
+		{"*/15 * * * *", "2019-01-01T00:16:00Z", "2019-01-01T00:30:00Z"},
+		{"5/20 * * * *", "2019-01-01T00:26:00Z", "2019-01-01T00:45:00Z"},
+		{"30 22-23 * * *", "2019-01-31T23:30:00Z", "2019-02-01T22:30:00Z"},
+		{"0 0 * * mon-fri", "2019-01-04T12:00:00Z", "2019-01-07T00:00:00Z"},
+		{"0 0 * * 7", "2019-01-01T00:00:00Z", "2019-01-06T00:00:00Z"},
+		{"0 0 29 2 *", "2019-01-01T00:00:00Z", "2020-02-29T00:00:00Z"},
+		{"0 0 31 * *", "2019-04-01T00:00:00Z", "2019-05-31T00:00:00Z"},
+		// when both day fields are restricted, either of them must match
+		{"0 0 13 * fri", "2019-01-01T00:00:00Z", "2019-01-04T00:00:00Z"},
+		{"0 0 13 * fri", "2019-01-11T00:00:00Z", "2019-01-13T00:00:00Z"},
+		{"@monthly", "2019-12-15T10:00:00Z", "2020-01-01T00:00:00Z"},
+		{"@hourly", "2019-12-31T23:00:00Z", "2020-01-01T00:00:00Z"},
+		// times in other locations are converted to utc
+		{"0 0 * * *", "2019-01-01T23:30:00-02:00", "2019-01-03T00:00:00Z"},
+		// impossible schedules never match
+		{"0 0 30 2 *", "2019-01-01T00:00:00Z", "0001-01-01T00:00:00Z"},
+	}
+	for _, test := range tests {
+		s, err := Parse(test.spec)
+		assert.NoError(t, err, test.spec)
+		from, err := time.Parse(time.RFC3339, test.from)
+		assert.NoError(t, err)
+		expected, err := time.Parse(time.RFC3339, test.expected)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, s.Next(from), "%s from %s", test.spec, test.from)
+	}
+}
@@ -45,6 +45,14 @@ const (
 	// upgrade operation finishes on a pod.
 	ReasonNodeUpgradeFinished = "NodeUpgradeFinished"
 
+	// ReasonNodeConfigUpdated is the reason used in corev1.Event objects created when
+	// configuration changes are applied to a running pod.
+	ReasonNodeConfigUpdated = "NodeConfigUpdated"
+
+	// ReasonSecurityUpdated is the reason used in corev1.Event objects created when users or
+	// roles of a cluster are created, updated or removed.
+	ReasonSecurityUpdated = "SecurityUpdated"
+
 	// ReasonWaitForMigrationsStarted is the reason used in corev1.Event objects created when
 	// migrations have started.
 	ReasonWaitForMigrationsStarted = "WaitForMigrationsStarted"
@@ -69,6 +77,18 @@ const (
 	// restore job has been created
 	ReasonJobCreated = "JobCreated"
 
+	// ReasonBackupNotFound is the reason used in corev1.Event objects indicating that the
+	// backup to restore could not be found
+	ReasonBackupNotFound = "BackupNotFound"
+
+	// ReasonBackupScheduled is the reason used in corev1.Event objects indicating that a
+	// backup has been created by a backup schedule
+	ReasonBackupScheduled = "BackupScheduled"
+
+	// ReasonBackupPruned is the reason used in corev1.Event objects indicating that a
+	// backup has been marked for deletion by a backup schedule
+	ReasonBackupPruned = "BackupPruned"
+
 	// ReasonClusterUpgradeStarted is the reason used in corev1.Event objects indicating that a
 	// cluster upgrade has started
 	ReasonClusterUpgradeStarted = "ClusterUpgradeStarted"
@@ -81,6 +101,10 @@ const (
 	// cluster upgrade has finished
 	ReasonClusterUpgradeFinished = "ClusterUpgradeFinished"
 
+	// ReasonClusterUpgradeRetried is the reason used in corev1.Event objects indicating that a
+	// failed cluster upgrade is being retried
+	ReasonClusterUpgradeRetried = "ClusterUpgradeRetried"
+
 	// ReasonClusterAutoBackupStarted is the reason used in corev1.Event objects indicating that a
 	// cluster backup has started
 	ReasonClusterAutoBackupStarted = "ClusterAutoBackupStarted"
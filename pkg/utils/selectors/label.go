@@ -31,6 +31,9 @@ const (
 	LabelClusterKey = "cluster"
 	// LabelNamespaceKey represents the name of the "namespace" label added to every persistent volume claim.
 	LabelNamespaceKey = "namespace"
+	// LabelBackupScheduleKey represents the name of the "backup-schedule" label added to every backup created by an
+	// AerospikeNamespaceBackupSchedule.
+	LabelBackupScheduleKey = "backup-schedule"
 )
 
 // ResourcesByClusterName returns a selector that matches all resources belonging to a given AerospikeCluster.
@@ -42,6 +45,27 @@ func ResourcesByClusterName(name string) labels.Selector {
 	return labels.SelectorFromSet(set)
 }
 
+// ResourcesByNamespaceName returns a selector that matches all resources belonging to a given Aerospike namespace of
+// a given AerospikeCluster.
+func ResourcesByNamespaceName(clusterName, namespaceName string) labels.Selector {
+	set := map[string]string{
+		LabelAppKey:       LabelAppVal,
+		LabelClusterKey:   clusterName,
+		LabelNamespaceKey: namespaceName,
+	}
+	return labels.SelectorFromSet(set)
+}
+
+// ResourcesByBackupScheduleName returns a selector that matches all backups created by a given
+// AerospikeNamespaceBackupSchedule.
+func ResourcesByBackupScheduleName(name string) labels.Selector {
+	set := map[string]string{
+		LabelAppKey:            LabelAppVal,
+		LabelBackupScheduleKey: name,
+	}
+	return labels.SelectorFromSet(set)
+}
+
 // ResourcesByBackupRestoreObject returns a selector that matches all resources belonging to a given BackupRestoreObject.
 func ResourcesByBackupRestoreObject(obj aerospikev1alpha2.BackupRestoreObject) labels.Selector {
 	set := map[string]string{
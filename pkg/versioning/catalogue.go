@@ -0,0 +1,257 @@
+/*
+Copyright 2019 The aerospike-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package versioning
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/util/yaml"
+)
+
+// CatalogueEntry describes a version of Aerospike supported by the operator.
+type CatalogueEntry struct {
+	// Version is the version of Aerospike (e.g. 4.3.0.10).
+	Version string `json:"version"`
+	// Image is the container image used to run the version of Aerospike.
+	// Defaults to an image from the default server repository tagged with
+	// the version.
+	Image string `json:"image,omitempty"`
+	// RecreatePersistentVolumeClaims indicates whether the data stored by
+	// each node must be wiped when crossing this version (i.e. when upgrading
+	// to it from an older version or downgrading from it to an older one),
+	// for example because the storage format has changed.
+	RecreatePersistentVolumeClaims bool `json:"recreatePersistentVolumeClaims,omitempty"`
+	// RewriteConfig indicates whether the configuration of older versions
+	// cannot be used as-is when crossing this version.
+	RewriteConfig bool `json:"rewriteConfig,omitempty"`
+}
+
+// Catalogue holds the versions of Aerospike supported by the operator,
+// together with hints about how to upgrade to them.
+type Catalogue struct {
+	Versions []CatalogueEntry `json:"versions"`
+}
+
+var (
+	// catalogue is the catalogue currently in use, which defaults to the
+	// versions listed in AerospikeServerSupportedVersions.
+	catalogue   = defaultCatalogue()
+	catalogueMu sync.RWMutex
+)
+
+// defaultCatalogue returns the catalogue made of the versions listed in
+// AerospikeServerSupportedVersions.
+func defaultCatalogue() *Catalogue {
+	c := &Catalogue{Versions: make([]CatalogueEntry, 0, len(AerospikeServerSupportedVersions))}
+	for _, v := range AerospikeServerSupportedVersions {
+		c.Versions = append(c.Versions, CatalogueEntry{Version: v})
+	}
+	return c
+}
+
+// GetCatalogue returns the catalogue currently in use.
+func GetCatalogue() *Catalogue {
+	catalogueMu.RLock()
+	defer catalogueMu.RUnlock()
+	return catalogue
+}
+
+// SetCatalogue replaces the catalogue currently in use with c, or with the
+// default catalogue if c is nil.
+func SetCatalogue(c *Catalogue) {
+	if c == nil {
+		c = defaultCatalogue()
+	}
+	catalogueMu.Lock()
+	defer catalogueMu.Unlock()
+	catalogue = c
+}
+
+// ParseCatalogue parses and validates a catalogue in YAML or JSON format.
+func ParseCatalogue(data []byte) (*Catalogue, error) {
+	c := &Catalogue{}
+	if err := yaml.NewYAMLOrJSONDecoder(bytes.NewReader(data), len(data)).Decode(c); err != nil {
+		return nil, fmt.Errorf("failed to parse version catalogue: %v", err)
+	}
+	if err := c.validate(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// validate makes sure that the catalogue is not empty and that its entries
+// hold valid and distinct versions.
+func (c *Catalogue) validate() error {
+	if len(c.Versions) == 0 {
+		return fmt.Errorf("the version catalogue must contain at least one version")
+	}
+	seen := make(map[Version]bool, len(c.Versions))
+	for _, entry := range c.Versions {
+		v, err := NewVersionFromString(entry.Version)
+		if err != nil {
+			return fmt.Errorf("invalid version %q in version catalogue: %v", entry.Version, err)
+		}
+		if seen[v] {
+			return fmt.Errorf("version %s is listed more than once in version catalogue", v)
+		}
+		seen[v] = true
+	}
+	return nil
+}
+
+// entry returns the entry of the catalogue corresponding to v, or nil if v is
+// not listed in the catalogue.
+func (c *Catalogue) entry(v Version) *CatalogueEntry {
+	for i, entry := range c.Versions {
+		// entries have been validated when the catalogue was loaded
+		if ev, err := NewVersionFromString(entry.Version); err == nil && ev == v {
+			return &c.Versions[i]
+		}
+	}
+	return nil
+}
+
+// hints returns the upgrade strategy hinted by the entries of the catalogue
+// corresponding to the versions crossed by vu (i.e. the versions newer than
+// the oldest of its source and target versions and not newer than the other).
+func (c *Catalogue) hints(vu VersionUpgrade) UpgradeStrategy {
+	oldest, newest := vu.Source, vu.Target
+	if vu.isDowngrade() {
+		oldest, newest = newest, oldest
+	}
+	res := UpgradeStrategy{}
+	for _, entry := range c.Versions {
+		v, err := NewVersionFromString(entry.Version)
+		if err != nil || v.Compare(oldest) <= 0 || v.Compare(newest) > 0 {
+			continue
+		}
+		res.RecreatePersistentVolumeClaims = res.RecreatePersistentVolumeClaims || entry.RecreatePersistentVolumeClaims
+		res.RewriteConfig = res.RewriteConfig || entry.RewriteConfig
+	}
+	return res
+}
+
+// VersionsInUseFunc returns the versions of Aerospike run by existing
+// Aerospike clusters.
+type VersionsInUseFunc func() ([]Version, error)
+
+// withVersions returns a copy of c that also lists the versions in versions,
+// using the entries of current for the versions that c does not list. This
+// makes sure that Aerospike clusters running versions removed from the
+// catalogue can still be validated and upgraded.
+func (c *Catalogue) withVersions(current *Catalogue, versions []Version) *Catalogue {
+	res := &Catalogue{Versions: append([]CatalogueEntry(nil), c.Versions...)}
+	for _, v := range versions {
+		if res.entry(v) != nil {
+			continue
+		}
+		entry := CatalogueEntry{Version: v.String()}
+		if e := current.entry(v); e != nil {
+			entry = *e
+		}
+		res.Versions = append(res.Versions, entry)
+		log.Warnf("version %s is not listed in the version catalogue but is in use, keeping it", v)
+	}
+	return res
+}
+
+// keepVersionsInUse returns c extended with the versions returned by inUse
+// that it does not list (see withVersions).
+func keepVersionsInUse(c *Catalogue, inUse VersionsInUseFunc) (*Catalogue, error) {
+	if inUse == nil {
+		return c, nil
+	}
+	versions, err := inUse()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the versions in use: %v", err)
+	}
+	return c.withVersions(GetCatalogue(), versions), nil
+}
+
+// LoadCatalogue reads the catalogue stored in the file at the specified path
+// and starts using it, keeping the versions returned by inUse (if not nil).
+func LoadCatalogue(path string, inUse VersionsInUseFunc) error {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read version catalogue: %v", err)
+	}
+	c, err := ParseCatalogue(data)
+	if err != nil {
+		return err
+	}
+	if c, err = keepVersionsInUse(c, inUse); err != nil {
+		return err
+	}
+	SetCatalogue(c)
+	return nil
+}
+
+// WatchCatalogue periodically reloads the catalogue stored in the file at the
+// specified path (e.g. mounted from a ConfigMap) until stopCh is closed.
+// Changes are picked up as soon as they are detected, and invalid catalogues
+// are ignored so that the last valid catalogue remains in use. The versions
+// returned by inUse (if not nil) are kept even if they are removed from the
+// catalogue.
+func WatchCatalogue(path string, period time.Duration, inUse VersionsInUseFunc, stopCh <-chan struct{}) {
+	last, _ := ioutil.ReadFile(path)
+	ticker := time.NewTicker(period)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stopCh:
+			return
+		case <-ticker.C:
+		}
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			log.Errorf("failed to read version catalogue: %v", err)
+			continue
+		}
+		if bytes.Equal(data, last) {
+			continue
+		}
+		last = data
+		c, err := ParseCatalogue(data)
+		if err != nil {
+			log.Errorf("ignoring invalid version catalogue: %v", err)
+			continue
+		}
+		if c, err = keepVersionsInUse(c, inUse); err != nil {
+			// try again at the next period
+			last = nil
+			log.Errorf("failed to reload version catalogue: %v", err)
+			continue
+		}
+		SetCatalogue(c)
+		log.Infof("reloaded version catalogue with %d versions", len(c.Versions))
+	}
+}
+
+// CatalogueImage returns the container image used to run the version of
+// Aerospike represented by the current struct according to the version
+// catalogue, or an empty string if the catalogue does not specify one.
+func (v Version) CatalogueImage() string {
+	if entry := GetCatalogue().entry(v); entry != nil {
+		return entry.Image
+	}
+	return ""
+}
@@ -0,0 +1,124 @@
+/*
+Copyright 2019 The aerospike-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package versioning
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseCatalogue(t *testing.T) {
+	tests := []struct {
+		data  string
+		valid bool
+	}{
+		{`
+versions:
+- version: 4.3.0.10
+- version: 4.5.0.5
+  image: registry.example.com/aerospike-server:4.5.0.5
+  recreatePersistentVolumeClaims: true
+`, true},
+		{`{"versions": [{"version": "4.3.0.10"}]}`, true},
+		// empty catalogue
+		{`versions: []`, false},
+		// invalid version
+		{`
+versions:
+- version: latest
+`, false},
+		// duplicate version
+		{`
+versions:
+- version: 4.3.0.10
+- version: 4.3.0.10
+`, false},
+		// malformed data
+		{`versions: [`, false},
+	}
+	for _, test := range tests {
+		_, err := ParseCatalogue([]byte(test.data))
+		assert.Equal(t, test.valid, err == nil, test.data)
+	}
+}
+
+func TestCatalogue(t *testing.T) {
+	defer SetCatalogue(nil)
+
+	c, err := ParseCatalogue([]byte(`
+versions:
+- version: 4.3.0.10
+- version: 4.5.0.5
+  image: registry.example.com/aerospike-server:4.5.0.5
+  recreatePersistentVolumeClaims: true
+- version: 4.5.1.5
+- version: 4.5.2.5
+  rewriteConfig: true
+`))
+	assert.NoError(t, err)
+	SetCatalogue(c)
+
+	assert.True(t, Version{4, 5, 1, 5}.IsSupported())
+	assert.Equal(t, false, Version{4, 2, 0, 10}.IsSupported())
+
+	assert.Equal(t, "registry.example.com/aerospike-server:4.5.0.5", Version{4, 5, 0, 5}.CatalogueImage())
+	assert.Equal(t, "", Version{4, 5, 1, 5}.CatalogueImage())
+
+	tests := []struct {
+		upgrade  VersionUpgrade
+		strategy *UpgradeStrategy
+	}{
+		// crossing 4.5.0.5 (upwards and downwards) wipes data
+		{VersionUpgrade{Version{4, 3, 0, 10}, Version{4, 5, 0, 5}}, &UpgradeStrategy{RecreatePersistentVolumeClaims: true}},
+		{VersionUpgrade{Version{4, 3, 0, 10}, Version{4, 5, 1, 5}}, &UpgradeStrategy{RecreatePersistentVolumeClaims: true}},
+		{VersionUpgrade{Version{4, 5, 0, 5}, Version{4, 5, 1, 5}}, DefaultStrategy},
+		{VersionUpgrade{Version{4, 5, 1, 5}, Version{4, 5, 0, 5}}, DefaultStrategy},
+		{VersionUpgrade{Version{4, 5, 0, 5}, Version{4, 3, 0, 10}}, MinorDowngradeStrategy},
+	}
+	for _, test := range tests {
+		s, err := test.upgrade.GetStrategy()
+		assert.NoError(t, err)
+		assert.Equal(t, test.strategy, s)
+	}
+
+	// crossing 4.5.2.5 requires the configuration to be rewritten, which is
+	// not supported
+	assert.False(t, VersionUpgrade{Version{4, 5, 1, 5}, Version{4, 5, 2, 5}}.IsValid())
+	assert.False(t, VersionUpgrade{Version{4, 5, 2, 5}, Version{4, 3, 0, 10}}.IsValid())
+
+	// versions in use are kept when missing from a new catalogue, together
+	// with their hints
+	c, err = ParseCatalogue([]byte(`
+versions:
+- version: 4.5.1.5
+`))
+	assert.NoError(t, err)
+	c = c.withVersions(GetCatalogue(), []Version{{4, 5, 0, 5}, {4, 5, 1, 5}, {4, 6, 0, 2}})
+	assert.Len(t, c.Versions, 3)
+	assert.Equal(t, CatalogueEntry{
+		Version:                        "4.5.0.5",
+		Image:                          "registry.example.com/aerospike-server:4.5.0.5",
+		RecreatePersistentVolumeClaims: true,
+	}, *c.entry(Version{4, 5, 0, 5}))
+	assert.Equal(t, CatalogueEntry{Version: "4.6.0.2"}, *c.entry(Version{4, 6, 0, 2}))
+
+	// the default catalogue is used once the catalogue is reset
+	SetCatalogue(nil)
+	assert.True(t, Version{4, 2, 0, 10}.IsSupported())
+	assert.Equal(t, false, Version{4, 5, 1, 5}.IsSupported())
+}
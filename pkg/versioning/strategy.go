@@ -19,14 +19,26 @@ package versioning
 // UpgradeStrategy describes how to upgrade a pod.
 type UpgradeStrategy struct {
 	// RecreatePersistentVolumeClaims indicates whether new persistent
-	// volume claims should be created for pods.
+	// volume claims should be created for pods (i.e. whether the data stored
+	// by each node must be wiped and re-populated through migrations).
 	RecreatePersistentVolumeClaims bool
+	// RewriteConfig indicates whether the configuration of the source
+	// version cannot be used as-is by the target version (e.g. because
+	// configuration keys have been renamed or removed). The operator does not
+	// implement such a rewrite, as the configuration it generates is the same
+	// for every version, so transitions requiring it are refused by IsValid.
+	RewriteConfig bool
+	// RequireBackupRestore indicates whether data cannot be carried over to
+	// the target version by the nodes themselves, so that a full backup and
+	// restore cycle is required. The operator does not implement such a
+	// cycle, so transitions requiring it are refused by IsValid.
+	RequireBackupRestore bool
 }
 
 var (
 	// DefaultStrategy represents the strategy used for performing
 	// version upgrades between versions that do not require any special
-	// treatment
+	// treatment, as well as patch and revision downgrades
 	DefaultStrategy = &UpgradeStrategy{
 		RecreatePersistentVolumeClaims: false,
 	}
@@ -37,4 +49,32 @@ var (
 	To42XYStrategy = &UpgradeStrategy{
 		RecreatePersistentVolumeClaims: true,
 	}
+
+	// MajorUpgradeStrategy describes major version upgrades (e.g. from
+	// 4.X.Y.Z to 5.X.Y.Z), which may change both the storage format and the
+	// configuration format. As it requires the configuration to be
+	// rewritten, these upgrades are refused rather than performed.
+	MajorUpgradeStrategy = &UpgradeStrategy{
+		RecreatePersistentVolumeClaims: true,
+		RewriteConfig:                  true,
+	}
+
+	// MinorDowngradeStrategy represents the strategy used for performing
+	// minor version downgrades (e.g. from 4.3.X.Y to 4.2.X.Y), as data
+	// written by the source version may not be readable by the target
+	// version
+	MinorDowngradeStrategy = &UpgradeStrategy{
+		RecreatePersistentVolumeClaims: true,
+	}
+
+	// MajorDowngradeStrategy describes major version downgrades (e.g. from
+	// 5.X.Y.Z to 4.X.Y.Z), in which nodes running the target version cannot
+	// be expected to migrate data from nodes running the source version. As
+	// it requires a full backup and restore cycle, these downgrades are
+	// refused rather than performed.
+	MajorDowngradeStrategy = &UpgradeStrategy{
+		RecreatePersistentVolumeClaims: true,
+		RewriteConfig:                  true,
+		RequireBackupRestore:           true,
+	}
 )
@@ -28,10 +28,19 @@ type VersionUpgrade struct {
 // isDowngrade returns a boolean value indicating whether the current transition
 // is a downgrade.
 func (vu VersionUpgrade) isDowngrade() bool {
-	return vu.Target.Major < vu.Source.Major ||
-		vu.Target.Minor < vu.Source.Minor ||
-		vu.Target.Patch < vu.Source.Patch ||
-		vu.Target.Revision < vu.Target.Revision
+	return vu.Target.Compare(vu.Source) < 0
+}
+
+// isMajorDowngrade returns a boolean value indicating whether the current
+// transition is a major version downgrade.
+func (vu VersionUpgrade) isMajorDowngrade() bool {
+	return vu.isDowngrade() && vu.Target.Major < vu.Source.Major
+}
+
+// isMinorDowngrade returns a boolean value indicating whether the current
+// transition is a minor version downgrade.
+func (vu VersionUpgrade) isMinorDowngrade() bool {
+	return vu.isDowngrade() && !vu.isMajorDowngrade() && vu.Target.Minor < vu.Source.Minor
 }
 
 // isMajorUpgrade returns a boolean value indicating whether the current
@@ -58,11 +67,17 @@ func (vu VersionUpgrade) isRevisionUpgrade() bool {
 	return !vu.isDowngrade() && !vu.isMajorUpgrade() && !vu.isMinorUpgrade() && !vu.isPatchUpgrade() && vu.Target.Revision > vu.Source.Revision
 }
 
-// IsValid indicates whether the transition is valid. This initially means
-// that the source and target versions are both well-known, supported versions,
-// and that the transition is patch or revision only.
+// IsValid indicates whether the transition is valid. This means that the
+// source and target versions are both well-known, supported versions, that
+// they differ, and that the transition can be performed in-place (i.e. that
+// it does not require a full backup and restore cycle, nor the configuration
+// to be rewritten, which the operator does not know how to do).
 func (vu VersionUpgrade) IsValid() bool {
-	return vu.Source.IsSupported() && vu.Target.IsSupported() && (vu.isMinorUpgrade() || vu.isPatchUpgrade() || vu.isRevisionUpgrade())
+	if !vu.Source.IsSupported() || !vu.Target.IsSupported() || vu.Source == vu.Target {
+		return false
+	}
+	s := vu.strategy()
+	return !s.RequireBackupRestore && !s.RewriteConfig
 }
 
 // GetStrategy returns the UpgradeStrategy for performing the
@@ -72,13 +87,41 @@ func (vu VersionUpgrade) GetStrategy() (*UpgradeStrategy, error) {
 	if !vu.IsValid() {
 		return nil, fmt.Errorf("cannot upgrade from version %v to %v", vu.Source, vu.Target)
 	}
+	return vu.strategy(), nil
+}
+
+// strategy returns the UpgradeStrategy corresponding to the kind of the
+// current transition, regardless of whether it is valid, taking into account
+// the hints in the version catalogue for the versions it crosses.
+func (vu VersionUpgrade) strategy() *UpgradeStrategy {
+	s := vu.builtinStrategy()
+	hints := GetCatalogue().hints(vu)
+	if (hints.RecreatePersistentVolumeClaims && !s.RecreatePersistentVolumeClaims) || (hints.RewriteConfig && !s.RewriteConfig) {
+		res := *s
+		res.RecreatePersistentVolumeClaims = res.RecreatePersistentVolumeClaims || hints.RecreatePersistentVolumeClaims
+		res.RewriteConfig = res.RewriteConfig || hints.RewriteConfig
+		return &res
+	}
+	return s
+}
 
-	// when upgrading from a pre-4.2.X.Y version to 4.2.X.Y or newer existing
-	// data must be erased, so we delete and re-create existing persistent
-	// volume claims.
-	// https://www.aerospike.com/docs/operations/upgrade/storage_to_4_2
-	if vu.Target.Major == 4 && vu.Source.Minor <= 1 && vu.Target.Minor >= 2 {
-		return To42XYStrategy, nil
+// builtinStrategy returns the UpgradeStrategy corresponding to the kind of
+// the current transition according to the rules known to the operator.
+func (vu VersionUpgrade) builtinStrategy() *UpgradeStrategy {
+	switch {
+	case vu.isMajorDowngrade():
+		return MajorDowngradeStrategy
+	case vu.isMinorDowngrade():
+		return MinorDowngradeStrategy
+	case vu.isMajorUpgrade():
+		return MajorUpgradeStrategy
+	case vu.Target.Major == 4 && vu.Source.Minor <= 1 && vu.Target.Minor >= 2:
+		// when upgrading from a pre-4.2.X.Y version to 4.2.X.Y or newer
+		// existing data must be erased, so we delete and re-create existing
+		// persistent volume claims.
+		// https://www.aerospike.com/docs/operations/upgrade/storage_to_4_2
+		return To42XYStrategy
+	default:
+		return DefaultStrategy
 	}
-	return DefaultStrategy, nil
 }
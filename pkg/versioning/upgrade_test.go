@@ -173,3 +173,121 @@ func TestIsRevision(t *testing.T) {
 		assert.Equal(t, test.upgrade.isRevisionUpgrade(), test.result)
 	}
 }
+
+func TestIsDowngrade(t *testing.T) {
+	tests := []struct {
+		upgrade VersionUpgrade
+		result  bool
+	}{
+		{VersionUpgrade{
+			Version{4, 0, 0, 0},
+			Version{4, 0, 0, 0},
+		}, false},
+		{VersionUpgrade{
+			Version{4, 0, 0, 1},
+			Version{4, 0, 0, 0},
+		}, true},
+		{VersionUpgrade{
+			Version{4, 1, 0, 0},
+			Version{4, 0, 1, 1},
+		}, true},
+		{VersionUpgrade{
+			Version{5, 0, 0, 0},
+			Version{4, 3, 0, 10},
+		}, true},
+		{VersionUpgrade{
+			Version{4, 3, 0, 10},
+			Version{5, 0, 0, 0},
+		}, false},
+		{VersionUpgrade{
+			Version{4, 2, 1, 0},
+			Version{4, 3, 0, 0},
+		}, false},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.result, test.upgrade.isDowngrade())
+	}
+}
+
+func TestStrategy(t *testing.T) {
+	tests := []struct {
+		upgrade  VersionUpgrade
+		strategy *UpgradeStrategy
+	}{
+		{VersionUpgrade{
+			Version{4, 0, 0, 4},
+			Version{4, 0, 0, 6},
+		}, DefaultStrategy},
+		{VersionUpgrade{
+			Version{4, 0, 0, 4},
+			Version{4, 1, 0, 6},
+		}, DefaultStrategy},
+		{VersionUpgrade{
+			Version{4, 1, 0, 6},
+			Version{4, 3, 0, 10},
+		}, To42XYStrategy},
+		{VersionUpgrade{
+			Version{4, 3, 0, 10},
+			Version{5, 0, 0, 0},
+		}, MajorUpgradeStrategy},
+		{VersionUpgrade{
+			Version{5, 0, 0, 0},
+			Version{6, 0, 0, 0},
+		}, MajorUpgradeStrategy},
+		{VersionUpgrade{
+			Version{4, 0, 0, 6},
+			Version{4, 0, 0, 4},
+		}, DefaultStrategy},
+		{VersionUpgrade{
+			Version{4, 3, 0, 10},
+			Version{4, 2, 0, 10},
+		}, MinorDowngradeStrategy},
+		{VersionUpgrade{
+			Version{4, 2, 0, 10},
+			Version{4, 1, 0, 6},
+		}, MinorDowngradeStrategy},
+		{VersionUpgrade{
+			Version{5, 0, 0, 0},
+			Version{4, 3, 0, 10},
+		}, MajorDowngradeStrategy},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.strategy, test.upgrade.strategy())
+	}
+}
+
+func TestIsValid(t *testing.T) {
+	tests := []struct {
+		upgrade VersionUpgrade
+		result  bool
+	}{
+		{VersionUpgrade{
+			Version{4, 0, 0, 4},
+			Version{4, 0, 0, 4},
+		}, false},
+		{VersionUpgrade{
+			Version{4, 0, 0, 4},
+			Version{4, 3, 0, 10},
+		}, true},
+		{VersionUpgrade{
+			Version{4, 3, 0, 10},
+			Version{4, 2, 0, 10},
+		}, true},
+		{VersionUpgrade{
+			Version{4, 2, 0, 10},
+			Version{4, 2, 0, 3},
+		}, true},
+		// unsupported versions
+		{VersionUpgrade{
+			Version{4, 0, 0, 4},
+			Version{4, 0, 0, 7},
+		}, false},
+		{VersionUpgrade{
+			Version{4, 3, 0, 10},
+			Version{5, 0, 0, 0},
+		}, false},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.result, test.upgrade.IsValid())
+	}
+}
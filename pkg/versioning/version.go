@@ -56,18 +56,23 @@ func (v Version) String() string {
 	return fmt.Sprintf("%d.%d.%d.%d", v.Major, v.Minor, v.Patch, v.Revision)
 }
 
-// IsSupported indicated whether the version of Aerospike represented by the
-// current struct is supported by the operator.
-func (v Version) IsSupported() bool {
-	return contains(AerospikeServerSupportedVersions, v.String())
-}
-
-// contains returns a boolean indicating whether e is contained in the s slice.
-func contains(s []string, e string) bool {
-	for _, a := range s {
-		if a == e {
-			return true
+// Compare returns -1, 0 or 1 depending on whether the current version is
+// older than, equal to or newer than other.
+func (v Version) Compare(other Version) int {
+	for _, d := range []int{v.Major - other.Major, v.Minor - other.Minor, v.Patch - other.Patch, v.Revision - other.Revision} {
+		switch {
+		case d < 0:
+			return -1
+		case d > 0:
+			return 1
 		}
 	}
-	return false
+	return 0
+}
+
+// IsSupported indicated whether the version of Aerospike represented by the
+// current struct is supported by the operator (i.e. whether it is listed in
+// the version catalogue).
+func (v Version) IsSupported() bool {
+	return GetCatalogue().entry(v) != nil
 }
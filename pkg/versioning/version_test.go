@@ -55,3 +55,21 @@ func TestString(t *testing.T) {
 		assert.Equal(t, test.version.String(), test.versionString)
 	}
 }
+
+func TestCompare(t *testing.T) {
+	tests := []struct {
+		version Version
+		other   Version
+		result  int
+	}{
+		{Version{4, 0, 0, 4}, Version{4, 0, 0, 4}, 0},
+		{Version{4, 0, 0, 4}, Version{4, 0, 0, 5}, -1},
+		{Version{4, 0, 0, 5}, Version{4, 0, 0, 4}, 1},
+		{Version{4, 3, 0, 0}, Version{5, 1, 0, 0}, -1},
+		{Version{5, 1, 0, 0}, Version{4, 3, 0, 0}, 1},
+		{Version{4, 2, 0, 10}, Version{4, 3, 0, 2}, -1},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.result, test.version.Compare(test.other))
+	}
+}
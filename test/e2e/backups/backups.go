@@ -20,7 +20,6 @@ import (
 	. "github.com/onsi/gomega"
 	"k8s.io/api/core/v1"
 
-	"github.com/travelaudience/aerospike-operator/pkg/apis/aerospike/common"
 	"github.com/travelaudience/aerospike-operator/pkg/apis/aerospike/v1alpha2"
 	"github.com/travelaudience/aerospike-operator/test/e2e/framework"
 )
@@ -39,7 +38,7 @@ func testNamespaceBackupRestore(tf *framework.TestFramework, ns *v1.Namespace, n
 	Expect(err).NotTo(HaveOccurred())
 	c1.Close()
 
-	asBackup := tf.NewAerospikeNamespaceBackupGCS(asc, asc.Spec.Namespaces[0].Name, nil)
+	asBackup := tf.NewAerospikeNamespaceBackup(asc, asc.Spec.Namespaces[0].Name, nil)
 	backup, err := tf.AerospikeClient.AerospikeV1alpha2().AerospikeNamespaceBackups(ns.Name).Create(&asBackup)
 	Expect(err).NotTo(HaveOccurred())
 
@@ -53,7 +52,7 @@ func testNamespaceBackupRestore(tf *framework.TestFramework, ns *v1.Namespace, n
 	err = tf.WaitForClusterNodeCount(asc, aerospikeCluster.Spec.NodeCount)
 	Expect(err).NotTo(HaveOccurred())
 
-	asRestore := tf.NewAerospikeNamespaceRestoreGCS(asc, asc.Spec.Namespaces[0].Name, backup)
+	asRestore := tf.NewAerospikeNamespaceRestore(asc, asc.Spec.Namespaces[0].Name, backup)
 	restore, err := tf.AerospikeClient.AerospikeV1alpha2().AerospikeNamespaceRestores(ns.Name).Create(&asRestore)
 	Expect(err).NotTo(HaveOccurred())
 
@@ -82,7 +81,7 @@ func testNamespaceRestoreFromDifferentNamespace(tf *framework.TestFramework, ns
 	Expect(err).NotTo(HaveOccurred())
 	c1.Close()
 
-	asBackup := tf.NewAerospikeNamespaceBackupGCS(asc, asc.Spec.Namespaces[0].Name, nil)
+	asBackup := tf.NewAerospikeNamespaceBackup(asc, asc.Spec.Namespaces[0].Name, nil)
 	backup, err := tf.AerospikeClient.AerospikeV1alpha2().AerospikeNamespaceBackups(ns.Name).Create(&asBackup)
 	Expect(err).NotTo(HaveOccurred())
 
@@ -99,7 +98,7 @@ func testNamespaceRestoreFromDifferentNamespace(tf *framework.TestFramework, ns
 	err = tf.WaitForClusterNodeCount(asc, aerospikeCluster.Spec.NodeCount)
 	Expect(err).NotTo(HaveOccurred())
 
-	asRestore := tf.NewAerospikeNamespaceRestoreGCS(asc, asc.Spec.Namespaces[0].Name, backup)
+	asRestore := tf.NewAerospikeNamespaceRestore(asc, asc.Spec.Namespaces[0].Name, backup)
 	restore, err := tf.AerospikeClient.AerospikeV1alpha2().AerospikeNamespaceRestores(ns.Name).Create(&asRestore)
 	Expect(err).NotTo(HaveOccurred())
 
@@ -116,13 +115,7 @@ func testNamespaceRestoreFromDifferentNamespace(tf *framework.TestFramework, ns
 func testNamespaceBackupRestoreWithoutBackupStorageSpec(tf *framework.TestFramework, ns *v1.Namespace, nRecords int) {
 	aerospikeCluster := tf.NewAerospikeClusterWithDefaults()
 	aerospikeCluster.Spec.BackupSpec = &v1alpha2.AerospikeClusterBackupSpec{
-		Storage: v1alpha2.BackupStorageSpec{
-			Type:            common.StorageTypeGCS,
-			Bucket:          framework.GCSBucketName,
-			Secret:          framework.GCSSecretName,
-			SecretNamespace: &framework.GCSSecretNamespace,
-			SecretKey:       &framework.GCSSecretKey,
-		},
+		Storage: tf.NewBackupStorageSpec(),
 	}
 	asc, err := tf.AerospikeClient.AerospikeV1alpha2().AerospikeClusters(ns.Name).Create(&aerospikeCluster)
 	Expect(err).NotTo(HaveOccurred())
@@ -136,7 +129,7 @@ func testNamespaceBackupRestoreWithoutBackupStorageSpec(tf *framework.TestFramew
 	Expect(err).NotTo(HaveOccurred())
 	c1.Close()
 
-	asBackup := tf.NewAerospikeNamespaceBackupGCSWithoutBackupStorageSpec(asc, asc.Spec.Namespaces[0].Name, nil)
+	asBackup := tf.NewAerospikeNamespaceBackupWithoutBackupStorageSpec(asc, asc.Spec.Namespaces[0].Name, nil)
 	backup, err := tf.AerospikeClient.AerospikeV1alpha2().AerospikeNamespaceBackups(ns.Name).Create(&asBackup)
 	Expect(err).NotTo(HaveOccurred())
 
@@ -150,7 +143,7 @@ func testNamespaceBackupRestoreWithoutBackupStorageSpec(tf *framework.TestFramew
 	err = tf.WaitForClusterNodeCount(asc, aerospikeCluster.Spec.NodeCount)
 	Expect(err).NotTo(HaveOccurred())
 
-	asRestore := tf.NewAerospikeNamespaceRestoreGCSWithoutBackupStorageSpec(asc, asc.Spec.Namespaces[0].Name, backup)
+	asRestore := tf.NewAerospikeNamespaceRestoreWithoutBackupStorageSpec(asc, asc.Spec.Namespaces[0].Name, backup)
 	restore, err := tf.AerospikeClient.AerospikeV1alpha2().AerospikeNamespaceRestores(ns.Name).Create(&asRestore)
 	Expect(err).NotTo(HaveOccurred())
 
@@ -88,18 +88,20 @@ func testCreateAerospikeClusterWithZeroNamespaces(tf *framework.TestFramework, n
 	aerospikeCluster.Spec.Namespaces = []aerospikev1alpha2.AerospikeNamespaceSpec{}
 	_, err := tf.AerospikeClient.AerospikeV1alpha2().AerospikeClusters(ns.Name).Create(&aerospikeCluster)
 	Expect(err).To(HaveOccurred())
-	Expect(tf.ErrorCauses(err)).To(ContainElement(MatchRegexp("the number of namespaces in the cluster must be exactly one")))
+	Expect(tf.ErrorCauses(err)).To(ContainElement(MatchRegexp("spec.namespaces.*should have at least 1 items")))
 }
 
-func testCreateAerospikeClusterWithTwoNamespaces(tf *framework.TestFramework, ns *corev1.Namespace) {
+func testCreateAerospikeClusterWithDuplicateNamespaces(tf *framework.TestFramework, ns *corev1.Namespace) {
 	aerospikeCluster := tf.NewAerospikeClusterWithDefaults()
 	aerospikeCluster.Spec.Namespaces = []aerospikev1alpha2.AerospikeNamespaceSpec{
 		tf.NewAerospikeNamespaceWithFileStorage("aerospike-namespace-0", 1, 1, 0, 1),
-		tf.NewAerospikeNamespaceWithFileStorage("aerospike-namespace-1", 1, 1, 0, 1),
+		tf.NewAerospikeNamespaceWithFileStorage("aerospike-namespace-0", 1, 1, 0, 1),
 	}
 	_, err := tf.AerospikeClient.AerospikeV1alpha2().AerospikeClusters(ns.Name).Create(&aerospikeCluster)
 	Expect(err).To(HaveOccurred())
-	Expect(tf.ErrorCauses(err)).To(ContainElement(MatchRegexp("the number of namespaces in the cluster must be exactly one")))
+	status := err.(*errors.StatusError)
+	Expect(status.ErrStatus.Status).To(Equal(metav1.StatusFailure))
+	Expect(status.ErrStatus.Message).To(MatchRegexp("namespace names must be unique"))
 }
 
 func testCreateAerospikeClusterWithInvalidReplicationFactor(tf *framework.TestFramework, ns *corev1.Namespace) {
@@ -128,7 +130,7 @@ func testCreateAerospikeClusterWithNodeCount(tf *framework.TestFramework, ns *co
 	Expect(err).NotTo(HaveOccurred())
 	Expect(int32(len(pods.Items))).To(Equal(nodeCount))
 
-	clusterSize, err := asutils.GetClusterSize(fmt.Sprintf("%s.%s", res.Name, res.Namespace), 3000)
+	clusterSize, err := asutils.GetClusterSize(fmt.Sprintf("%s.%s", res.Name, res.Namespace), 3000, nil)
 	Expect(err).NotTo(HaveOccurred())
 	Expect(int32(clusterSize)).To(Equal(nodeCount))
 }
@@ -160,7 +162,7 @@ func testCreateAerospikeClusterWithResources(tf *framework.TestFramework, ns *co
 	Expect(pods.Items[0].Spec.Containers[0].Resources.Limits.Cpu()).To(Equal(aerospikeCluster.Spec.Resources.Limits.Cpu()))
 	Expect(pods.Items[0].Spec.Containers[0].Resources.Limits.Memory()).To(Equal(aerospikeCluster.Spec.Resources.Limits.Memory()))
 
-	clusterSize, err := asutils.GetClusterSize(fmt.Sprintf("%s.%s", res.Name, res.Namespace), 3000)
+	clusterSize, err := asutils.GetClusterSize(fmt.Sprintf("%s.%s", res.Name, res.Namespace), 3000, nil)
 	Expect(err).NotTo(HaveOccurred())
 	Expect(int32(clusterSize)).To(Equal(int32(1)))
 }
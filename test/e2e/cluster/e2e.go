@@ -64,8 +64,8 @@ var _ = Describe("AerospikeCluster", func() {
 		It("cannot be created with len(spec.namespaces)==0", func() {
 			testCreateAerospikeClusterWithZeroNamespaces(tf, ns)
 		})
-		It("cannot be created with len(spec.namespaces)==2", func() {
-			testCreateAerospikeClusterWithTwoNamespaces(tf, ns)
+		It("cannot be created with duplicate spec.namespaces[*].name", func() {
+			testCreateAerospikeClusterWithDuplicateNamespaces(tf, ns)
 		})
 		It("cannot be created if spec.namespaces.replicationFactor[*] > spec.nodeCount", func() {
 			testCreateAerospikeClusterWithInvalidReplicationFactor(tf, ns)
@@ -88,6 +88,12 @@ var _ = Describe("AerospikeCluster", func() {
 		It("supports file storage", func() {
 			testFileStorage(tf, ns, 1, 2)
 		})
+		It("supports multiple namespaces", func() {
+			testMultipleNamespaces(tf, ns, 2)
+		})
+		It("supports adding a namespace to a live cluster", func() {
+			testAddNamespace(tf, ns, 2)
+		})
 		It("reuses the persistent volume of a deleted pod", func() {
 			testVolumeIsReused(tf, ns, 2)
 		})
@@ -136,6 +142,9 @@ var _ = Describe("AerospikeCluster", func() {
 		It("makes pre-upgrade backups, re-uses persistent volumes, and does not lose data in a namespace after an upgrade from 4.2.0.10 to 4.3.0.10", func() {
 			testReusePVCsAndNoDataLossOnAerospikeUpgrade(tf, ns, 2, 10000, "4.2.0.10", "4.3.0.10")
 		})
+		It("skips the pre-upgrade backup when requested by the upgrade policy after an upgrade from 4.2.0.10 to 4.3.0.10", func() {
+			testSkipPreUpgradeBackupOnAerospikeUpgrade(tf, ns, 1, "4.2.0.10", "4.3.0.10")
+		})
 		It("node IDs are kept after restart", func() {
 			testNodeIDsAfterRestart(tf, ns, 2)
 		})
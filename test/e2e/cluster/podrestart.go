@@ -43,7 +43,7 @@ func testNodeCountAfterRestart(tf *framework.TestFramework, ns *v1.Namespace, no
 	Expect(err).NotTo(HaveOccurred())
 	Expect(asc.Status.NodeCount).To(Equal(nodeCount))
 
-	clusterSize, err := asutils.GetClusterSize(fmt.Sprintf("%s.%s", asc.Name, asc.Namespace), 3000)
+	clusterSize, err := asutils.GetClusterSize(fmt.Sprintf("%s.%s", asc.Name, asc.Namespace), 3000, nil)
 	Expect(err).NotTo(HaveOccurred())
 	Expect(int32(clusterSize)).To(Equal(nodeCount))
 }
@@ -64,7 +64,7 @@ func testNodeCountAfterRestartAndScaling(tf *framework.TestFramework, ns *v1.Nam
 	Expect(err).NotTo(HaveOccurred())
 	Expect(asc.Status.NodeCount).To(Equal(finalNodeCount))
 
-	clusterSize, err := asutils.GetClusterSize(fmt.Sprintf("%s.%s", asc.Name, asc.Namespace), 3000)
+	clusterSize, err := asutils.GetClusterSize(fmt.Sprintf("%s.%s", asc.Name, asc.Namespace), 3000, nil)
 	Expect(err).NotTo(HaveOccurred())
 	Expect(int32(clusterSize)).To(Equal(finalNodeCount))
 }
@@ -118,7 +118,7 @@ func testNoDataLossAfterRestartAndScaleDown(tf *framework.TestFramework, ns *v1.
 	Expect(err).NotTo(HaveOccurred())
 	err = tf.ScaleCluster(asc, nodeCount)
 
-	clusterSize, err := asutils.GetClusterSize(fmt.Sprintf("%s.%s", asc.Name, asc.Namespace), 3000)
+	clusterSize, err := asutils.GetClusterSize(fmt.Sprintf("%s.%s", asc.Name, asc.Namespace), 3000, nil)
 	Expect(err).NotTo(HaveOccurred())
 	Expect(int32(clusterSize)).To(Equal(nodeCount))
 
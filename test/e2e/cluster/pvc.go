@@ -151,3 +151,71 @@ func testVolumeIsReused(tf *framework.TestFramework, ns *v1.Namespace, nodeCount
 		}
 	}
 }
+
+func testMultipleNamespaces(tf *framework.TestFramework, ns *v1.Namespace, nodeCount int32) {
+	aerospikeCluster := tf.NewAerospikeClusterWithDefaults()
+	aerospikeCluster.Spec.NodeCount = nodeCount
+	ns1 := tf.NewAerospikeNamespaceWithFileStorage("aerospike-namespace-0", 1, 1, 0, 1)
+	ns2 := tf.NewAerospikeNamespaceWithDeviceStorage("aerospike-namespace-1", 1, 1, 0, 2)
+	aerospikeCluster.Spec.Namespaces = []aerospikev1alpha2.AerospikeNamespaceSpec{ns1, ns2}
+	res, err := tf.AerospikeClient.AerospikeV1alpha2().AerospikeClusters(ns.Name).Create(&aerospikeCluster)
+	Expect(err).NotTo(HaveOccurred())
+
+	err = tf.WaitForClusterNodeCount(res, nodeCount)
+	Expect(err).NotTo(HaveOccurred())
+
+	pods, err := tf.KubeClient.CoreV1().Pods(ns.Name).List(listoptions.ResourcesByClusterName(res.Name))
+	Expect(err).NotTo(HaveOccurred())
+	Expect(int32(len(pods.Items))).To(Equal(nodeCount))
+
+	for _, pod := range pods.Items {
+		// each pod must mount exactly one pvc per namespace
+		claims := make(map[string]string)
+		for _, volume := range pod.Spec.Volumes {
+			if volume.VolumeSource.PersistentVolumeClaim != nil {
+				claim, err := tf.KubeClient.CoreV1().PersistentVolumeClaims(ns.Name).Get(volume.VolumeSource.PersistentVolumeClaim.ClaimName, metav1.GetOptions{})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(claims).NotTo(HaveKey(claim.Labels[selectors.LabelNamespaceKey]))
+				claims[claim.Labels[selectors.LabelNamespaceKey]] = claim.Name
+			}
+		}
+		Expect(claims).To(HaveLen(2))
+		Expect(claims).To(HaveKey(ns1.Name))
+		Expect(claims).To(HaveKey(ns2.Name))
+	}
+
+	c, err := framework.NewAerospikeClient(res)
+	Expect(err).NotTo(HaveOccurred())
+	t, err := c.GetNamespaceStorageEngine(ns1.Name)
+	Expect(err).NotTo(HaveOccurred())
+	Expect(t).To(Equal(common.StorageTypeFile))
+	t, err = c.GetNamespaceStorageEngine(ns2.Name)
+	Expect(err).NotTo(HaveOccurred())
+	Expect(t).To(Equal(common.StorageTypeDevice))
+}
+
+func testAddNamespace(tf *framework.TestFramework, ns *v1.Namespace, nodeCount int32) {
+	aerospikeCluster := tf.NewAerospikeClusterWithDefaults()
+	aerospikeCluster.Spec.NodeCount = nodeCount
+	res, err := tf.AerospikeClient.AerospikeV1alpha2().AerospikeClusters(ns.Name).Create(&aerospikeCluster)
+	Expect(err).NotTo(HaveOccurred())
+
+	err = tf.WaitForClusterNodeCount(res, nodeCount)
+	Expect(err).NotTo(HaveOccurred())
+
+	ns2 := tf.NewAerospikeNamespaceWithFileStorage("aerospike-namespace-1", 1, 1, 0, 1)
+	err = tf.AddNamespaceAndWait(res, ns2)
+	Expect(err).NotTo(HaveOccurred())
+
+	pvcs, err := tf.KubeClient.CoreV1().PersistentVolumeClaims(ns.Name).List(metav1.ListOptions{
+		LabelSelector: selectors.ResourcesByNamespaceName(res.Name, ns2.Name).String(),
+	})
+	Expect(err).NotTo(HaveOccurred())
+	Expect(int32(len(pvcs.Items))).To(Equal(nodeCount))
+
+	c, err := framework.NewAerospikeClient(res)
+	Expect(err).NotTo(HaveOccurred())
+	t, err := c.GetNamespaceStorageEngine(ns2.Name)
+	Expect(err).NotTo(HaveOccurred())
+	Expect(t).To(Equal(common.StorageTypeFile))
+}
@@ -45,7 +45,7 @@ func testNodeCountAfterScaling(tf *framework.TestFramework, ns *v1.Namespace, in
 	Expect(err).NotTo(HaveOccurred())
 	Expect(asc.Status.NodeCount).To(Equal(finalNodeCount))
 
-	clusterSize, err := asutils.GetClusterSize(fmt.Sprintf("%s.%s", asc.Name, asc.Namespace), 3000)
+	clusterSize, err := asutils.GetClusterSize(fmt.Sprintf("%s.%s", asc.Name, asc.Namespace), 3000, nil)
 	Expect(err).NotTo(HaveOccurred())
 	Expect(int32(clusterSize)).To(Equal(finalNodeCount))
 }
@@ -92,7 +92,7 @@ func testNoDowntimeDuringScaling(tf *framework.TestFramework, ns *v1.Namespace,
 	err = tf.ScaleCluster(asc, finalNodeCount)
 	Expect(err).NotTo(HaveOccurred())
 
-	clusterSize, err := asutils.GetClusterSize(fmt.Sprintf("%s.%s", asc.Name, asc.Namespace), 3000)
+	clusterSize, err := asutils.GetClusterSize(fmt.Sprintf("%s.%s", asc.Name, asc.Namespace), 3000, nil)
 	Expect(err).NotTo(HaveOccurred())
 	Expect(int32(clusterSize)).To(Equal(finalNodeCount))
 
@@ -22,6 +22,7 @@ import (
 	. "github.com/onsi/gomega"
 	"k8s.io/api/core/v1"
 	"k8s.io/apiextensions-apiserver/pkg/apis/apiextensions"
+	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	"github.com/travelaudience/aerospike-operator/pkg/apis/aerospike/common"
@@ -38,13 +39,7 @@ func testReusePVCsAndNoDataLossOnAerospikeUpgrade(tf *framework.TestFramework, n
 	aerospikeCluster := tf.NewAerospikeClusterWithDefaults()
 	aerospikeCluster.Spec.Version = sourceVersion
 	aerospikeCluster.Spec.BackupSpec = &aerospikev1alpha2.AerospikeClusterBackupSpec{
-		Storage: aerospikev1alpha2.BackupStorageSpec{
-			Type:            common.StorageTypeGCS,
-			Bucket:          framework.GCSBucketName,
-			Secret:          framework.GCSSecretName,
-			SecretNamespace: &framework.GCSSecretNamespace,
-			SecretKey:       &framework.GCSSecretKey,
-		},
+		Storage: tf.NewBackupStorageSpec(),
 	}
 	aerospikeCluster.Spec.NodeCount = nodeCount
 	aerospikeCluster.Spec.Namespaces[0].ReplicationFactor = pointers.NewInt32(2)
@@ -74,7 +69,7 @@ func testReusePVCsAndNoDataLossOnAerospikeUpgrade(tf *framework.TestFramework, n
 	asc, err = tf.UpgradeClusterAndWait(asc, targetVersion)
 	Expect(err).NotTo(HaveOccurred())
 
-	clusterSize, err := asutils.GetClusterSize(fmt.Sprintf("%s.%s", asc.Name, asc.Namespace), 3000)
+	clusterSize, err := asutils.GetClusterSize(fmt.Sprintf("%s.%s", asc.Name, asc.Namespace), 3000, nil)
 	Expect(err).NotTo(HaveOccurred())
 	Expect(int32(clusterSize)).To(Equal(asc.Status.NodeCount))
 
@@ -126,13 +121,7 @@ func testRecreatePVCsAndNoDataLossOnAerospikeUpgrade(tf *framework.TestFramework
 	aerospikeCluster := tf.NewAerospikeClusterWithDefaults()
 	aerospikeCluster.Spec.Version = sourceVersion
 	aerospikeCluster.Spec.BackupSpec = &aerospikev1alpha2.AerospikeClusterBackupSpec{
-		Storage: aerospikev1alpha2.BackupStorageSpec{
-			Type:            common.StorageTypeGCS,
-			Bucket:          framework.GCSBucketName,
-			Secret:          framework.GCSSecretName,
-			SecretNamespace: &framework.GCSSecretNamespace,
-			SecretKey:       &framework.GCSSecretKey,
-		},
+		Storage: tf.NewBackupStorageSpec(),
 	}
 	aerospikeCluster.Spec.NodeCount = nodeCount
 	aerospikeCluster.Spec.Namespaces[0].ReplicationFactor = pointers.NewInt32(2)
@@ -162,7 +151,7 @@ func testRecreatePVCsAndNoDataLossOnAerospikeUpgrade(tf *framework.TestFramework
 	asc, err = tf.UpgradeClusterAndWait(asc, targetVersion)
 	Expect(err).NotTo(HaveOccurred())
 
-	clusterSize, err := asutils.GetClusterSize(fmt.Sprintf("%s.%s", asc.Name, asc.Namespace), 3000)
+	clusterSize, err := asutils.GetClusterSize(fmt.Sprintf("%s.%s", asc.Name, asc.Namespace), 3000, nil)
 	Expect(err).NotTo(HaveOccurred())
 	Expect(int32(clusterSize)).To(Equal(asc.Status.NodeCount))
 
@@ -208,3 +197,48 @@ func testRecreatePVCsAndNoDataLossOnAerospikeUpgrade(tf *framework.TestFramework
 		Expect(completed).To(Equal(true))
 	}
 }
+
+func testSkipPreUpgradeBackupOnAerospikeUpgrade(tf *framework.TestFramework, ns *v1.Namespace, nodeCount int32, sourceVersion, targetVersion string) {
+	// create an Aerospike cluster without .spec.backupSpec
+	aerospikeCluster := tf.NewAerospikeClusterWithDefaults()
+	aerospikeCluster.Spec.Version = sourceVersion
+	aerospikeCluster.Spec.NodeCount = nodeCount
+	asc, err := tf.AerospikeClient.AerospikeV1alpha2().AerospikeClusters(ns.Name).Create(&aerospikeCluster)
+	Expect(err).NotTo(HaveOccurred())
+
+	// wait until the Aerospike cluster is ready
+	err = tf.WaitForClusterNodeCount(asc, nodeCount)
+	Expect(err).NotTo(HaveOccurred())
+
+	// get the latest version of the aerospikecluster resource
+	asc, err = tf.AerospikeClient.AerospikeV1alpha2().AerospikeClusters(asc.Namespace).Get(asc.Name, metav1.GetOptions{})
+	Expect(err).NotTo(HaveOccurred())
+
+	// upgrading the Aerospike cluster must fail as no backup spec has been specified
+	_, err = tf.UpgradeClusterAndWait(asc.DeepCopy(), targetVersion)
+	Expect(err).To(HaveOccurred())
+
+	// upgrade the Aerospike cluster to targetVersion skipping the pre-upgrade backup
+	asc.Spec.UpgradePolicy = &aerospikev1alpha2.AerospikeClusterUpgradePolicy{
+		Backup: common.UpgradeBackupPolicySkip,
+	}
+	asc, err = tf.UpgradeClusterAndWait(asc, targetVersion)
+	Expect(err).NotTo(HaveOccurred())
+
+	// make sure that the policy has been recorded in the UpgradeStarted condition
+	started := false
+	for _, condition := range asc.Status.Conditions {
+		if condition.Type == common.ConditionUpgradeStarted {
+			started = true
+			Expect(condition.Message).To(ContainSubstring(fmt.Sprintf("backup policy: %s", common.UpgradeBackupPolicySkip)))
+		}
+		Expect(condition.Type).NotTo(Equal(common.ConditionAutoBackupStarted))
+	}
+	Expect(started).To(Equal(true))
+
+	// make sure that no AerospikeNamespaceBackup has been created
+	for _, namespace := range asc.Spec.Namespaces {
+		_, err := tf.AerospikeClient.AerospikeV1alpha2().AerospikeNamespaceBackups(ns.Name).Get(reconciler.GetBackupName(namespace.Name, sourceVersion, targetVersion), metav1.GetOptions{})
+		Expect(errors.IsNotFound(err)).To(Equal(true))
+	}
+}
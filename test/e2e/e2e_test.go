@@ -29,6 +29,7 @@ import (
 
 func init() {
 	flag.StringVar(&kubeconfig, "kubeconfig", "", "path to the kubeconfig file to be used")
+	flag.StringVar(&framework.BackupStorageType, "backup-storage-type", common.StorageTypeGCS, "the type of storage to be used to store backups (gcs or pvc)")
 	flag.StringVar(&framework.GCSBucketName, "gcs-bucket-name", "aerospike-operator", "the name of the GCS bucket to be used to store backups")
 	flag.StringVar(&framework.GCSSecretName, "gcs-secret-name", "aerospike-operator", "the name of the secret containing the credentials to access the GCS bucket")
 	flag.StringVar(&framework.GCSSecretNamespace, "gcs-secret-namespace", v1.NamespaceDefault, "the name of the namespace where the secret has been created")
@@ -38,13 +38,35 @@ const (
 )
 
 var (
+	BackupStorageType  string
 	GCSBucketName      string
 	GCSSecretName      string
 	GCSSecretNamespace string
 	GCSSecretKey       string
 )
 
-func (tf *TestFramework) NewAerospikeNamespaceBackupGCS(cluster *aerospikev1alpha2.AerospikeCluster, namespace string, ttl *string) aerospikev1alpha2.AerospikeNamespaceBackup {
+// NewBackupStorageSpec returns a BackupStorageSpec for the storage type chosen
+// for the current test run.
+func (tf *TestFramework) NewBackupStorageSpec() aerospikev1alpha2.BackupStorageSpec {
+	if BackupStorageType == common.StorageTypePVC {
+		return aerospikev1alpha2.BackupStorageSpec{
+			Type: common.StorageTypePVC,
+			PVC: &aerospikev1alpha2.PVCStorageSpec{
+				ClaimName: backupPVCName,
+			},
+		}
+	}
+	return aerospikev1alpha2.BackupStorageSpec{
+		Type:            common.StorageTypeGCS,
+		Bucket:          GCSBucketName,
+		Secret:          GCSSecretName,
+		SecretNamespace: &GCSSecretNamespace,
+		SecretKey:       &GCSSecretKey,
+	}
+}
+
+func (tf *TestFramework) NewAerospikeNamespaceBackup(cluster *aerospikev1alpha2.AerospikeCluster, namespace string, ttl *string) aerospikev1alpha2.AerospikeNamespaceBackup {
+	storage := tf.NewBackupStorageSpec()
 	return aerospikev1alpha2.AerospikeNamespaceBackup{
 		ObjectMeta: metav1.ObjectMeta{
 			GenerateName: backupPrefix,
@@ -54,19 +76,13 @@ func (tf *TestFramework) NewAerospikeNamespaceBackupGCS(cluster *aerospikev1alph
 				Cluster:   cluster.Name,
 				Namespace: namespace,
 			},
-			Storage: &aerospikev1alpha2.BackupStorageSpec{
-				Type:            common.StorageTypeGCS,
-				Bucket:          GCSBucketName,
-				Secret:          GCSSecretName,
-				SecretNamespace: &GCSSecretNamespace,
-				SecretKey:       &GCSSecretKey,
-			},
-			TTL: ttl,
+			Storage: &storage,
+			TTL:     ttl,
 		},
 	}
 }
 
-func (tf *TestFramework) NewAerospikeNamespaceBackupGCSWithoutBackupStorageSpec(cluster *aerospikev1alpha2.AerospikeCluster, namespace string, ttl *string) aerospikev1alpha2.AerospikeNamespaceBackup {
+func (tf *TestFramework) NewAerospikeNamespaceBackupWithoutBackupStorageSpec(cluster *aerospikev1alpha2.AerospikeCluster, namespace string, ttl *string) aerospikev1alpha2.AerospikeNamespaceBackup {
 	return aerospikev1alpha2.AerospikeNamespaceBackup{
 		ObjectMeta: metav1.ObjectMeta{
 			GenerateName: backupPrefix,
@@ -81,7 +97,8 @@ func (tf *TestFramework) NewAerospikeNamespaceBackupGCSWithoutBackupStorageSpec(
 	}
 }
 
-func (tf *TestFramework) NewAerospikeNamespaceRestoreGCS(cluster *aerospikev1alpha2.AerospikeCluster, namespace string, backup *aerospikev1alpha2.AerospikeNamespaceBackup) aerospikev1alpha2.AerospikeNamespaceRestore {
+func (tf *TestFramework) NewAerospikeNamespaceRestore(cluster *aerospikev1alpha2.AerospikeCluster, namespace string, backup *aerospikev1alpha2.AerospikeNamespaceBackup) aerospikev1alpha2.AerospikeNamespaceRestore {
+	storage := tf.NewBackupStorageSpec()
 	return aerospikev1alpha2.AerospikeNamespaceRestore{
 		ObjectMeta: metav1.ObjectMeta{
 			Name: backup.Name,
@@ -91,18 +108,12 @@ func (tf *TestFramework) NewAerospikeNamespaceRestoreGCS(cluster *aerospikev1alp
 				Cluster:   cluster.Name,
 				Namespace: namespace,
 			},
-			Storage: &aerospikev1alpha2.BackupStorageSpec{
-				Type:            common.StorageTypeGCS,
-				Bucket:          GCSBucketName,
-				Secret:          GCSSecretName,
-				SecretNamespace: &GCSSecretNamespace,
-				SecretKey:       &GCSSecretKey,
-			},
+			Storage: &storage,
 		},
 	}
 }
 
-func (tf *TestFramework) NewAerospikeNamespaceRestoreGCSWithoutBackupStorageSpec(cluster *aerospikev1alpha2.AerospikeCluster, namespace string, backup *aerospikev1alpha2.AerospikeNamespaceBackup) aerospikev1alpha2.AerospikeNamespaceRestore {
+func (tf *TestFramework) NewAerospikeNamespaceRestoreWithoutBackupStorageSpec(cluster *aerospikev1alpha2.AerospikeCluster, namespace string, backup *aerospikev1alpha2.AerospikeNamespaceBackup) aerospikev1alpha2.AerospikeNamespaceRestore {
 	return aerospikev1alpha2.AerospikeNamespaceRestore{
 		ObjectMeta: metav1.ObjectMeta{
 			Name: backup.Name,
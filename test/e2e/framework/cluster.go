@@ -148,6 +148,23 @@ func (tf *TestFramework) ChangeNamespaceMemorySizeAndScaleClusterAndWait(aerospi
 	return tf.WaitForClusterNodeCount(res, nodeCount)
 }
 
+func (tf *TestFramework) AddNamespaceAndWait(aerospikeCluster *aerospikev1alpha2.AerospikeCluster, namespace aerospikev1alpha2.AerospikeNamespaceSpec) error {
+	res, err := tf.AerospikeClient.AerospikeV1alpha2().AerospikeClusters(aerospikeCluster.Namespace).Get(aerospikeCluster.Name, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	res.Spec.Namespaces = append(res.Spec.Namespaces, namespace)
+	if res, err = tf.AerospikeClient.AerospikeV1alpha2().AerospikeClusters(res.Namespace).Update(res); err != nil {
+		return err
+	}
+	return tf.WaitForClusterCondition(res, func(event watchapi.Event) (bool, error) {
+		// grab the current cluster object from the event
+		obj := event.Object.(*aerospikev1alpha2.AerospikeCluster)
+		// the status is only synced with the spec after every pod has been restarted
+		return len(obj.Status.Namespaces) == len(res.Spec.Namespaces), nil
+	}, watchTimeout)
+}
+
 func (tf *TestFramework) NewAerospikeClusterV1alpha1(version string, nodeCount int32, namespaces []aerospikev1alpha1.AerospikeNamespaceSpec) aerospikev1alpha1.AerospikeCluster {
 	return aerospikev1alpha1.AerospikeCluster{
 		ObjectMeta: metav1.ObjectMeta{
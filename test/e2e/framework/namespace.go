@@ -18,19 +18,54 @@ package framework
 
 import (
 	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/travelaudience/aerospike-operator/pkg/apis/aerospike/common"
 )
 
 const (
 	randomNamespacePrefix = "as-e2e-"
+	// backupPVCName is the name of the persistent volume claim used to store
+	// backups when running with the pvc storage type.
+	backupPVCName = "as-e2e-backups"
 )
 
 func (tf *TestFramework) CreateRandomNamespace() (*v1.Namespace, error) {
-	return tf.KubeClient.CoreV1().Namespaces().Create(&v1.Namespace{
+	ns, err := tf.KubeClient.CoreV1().Namespaces().Create(&v1.Namespace{
 		ObjectMeta: metav1.ObjectMeta{
 			GenerateName: randomNamespacePrefix,
 		},
 	})
+	if err != nil {
+		return nil, err
+	}
+	// create the persistent volume claim where backups are stored, if needed
+	if BackupStorageType == common.StorageTypePVC {
+		if err := tf.createBackupPVC(ns); err != nil {
+			return nil, err
+		}
+	}
+	return ns, nil
+}
+
+func (tf *TestFramework) createBackupPVC(ns *v1.Namespace) error {
+	_, err := tf.KubeClient.CoreV1().PersistentVolumeClaims(ns.Name).Create(&v1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: backupPVCName,
+		},
+		Spec: v1.PersistentVolumeClaimSpec{
+			AccessModes: []v1.PersistentVolumeAccessMode{
+				v1.ReadWriteOnce,
+			},
+			Resources: v1.ResourceRequirements{
+				Requests: v1.ResourceList{
+					v1.ResourceStorage: resource.MustParse("1Gi"),
+				},
+			},
+		},
+	})
+	return err
 }
 
 func (tf *TestFramework) DeleteNamespace(ns *v1.Namespace) error {
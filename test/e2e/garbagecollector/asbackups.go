@@ -38,7 +38,7 @@ func testDeleteExpiredAerospikeNamespaceBackup(tf *framework.TestFramework, ns *
 	err = tf.WaitForClusterNodeCount(asc, aerospikeCluster.Spec.NodeCount)
 	Expect(err).NotTo(HaveOccurred())
 
-	asBackup := tf.NewAerospikeNamespaceBackupGCS(asc, asc.Spec.Namespaces[0].Name, pointers.NewString(ttl))
+	asBackup := tf.NewAerospikeNamespaceBackup(asc, asc.Spec.Namespaces[0].Name, pointers.NewString(ttl))
 	backup, err := tf.AerospikeClient.AerospikeV1alpha2().AerospikeNamespaceBackups(ns.Name).Create(&asBackup)
 	Expect(err).NotTo(HaveOccurred())
 
@@ -79,7 +79,7 @@ func testDoNotDeleteAerospikeNamespaceBackupWithoutTTL(tf *framework.TestFramewo
 	err = tf.WaitForClusterNodeCount(asc, aerospikeCluster.Spec.NodeCount)
 	Expect(err).NotTo(HaveOccurred())
 
-	asBackup := tf.NewAerospikeNamespaceBackupGCS(asc, asc.Spec.Namespaces[0].Name, nil)
+	asBackup := tf.NewAerospikeNamespaceBackup(asc, asc.Spec.Namespaces[0].Name, nil)
 	backup, err := tf.AerospikeClient.AerospikeV1alpha2().AerospikeNamespaceBackups(ns.Name).Create(&asBackup)
 	Expect(err).NotTo(HaveOccurred())
 